@@ -12,15 +12,35 @@ type Config struct {
 	RescanFromHeight uint64
 	PersistencyFile  string
 	StellarNetwork   string // stellar network
-	StellarSecret    string // secret of the stellar account that activates new accounts
+	// HorizonURL and NetworkPassphrase override the settings of the stellar network,
+	// both are required for other networks than testnet and production.
+	HorizonURL        string
+	NetworkPassphrase string
+	StellarSecret     string // secret of the stellar account that activates new accounts
 }
 
-func (c *Config) Validate() (err error) {
-	if c.StellarNetwork != "testnet" && c.StellarNetwork != "production" {
-		return errors.New("The Stellar network has to be testnet or production")
+func (c *Config) Validate() error {
+	var errs []error
+	if !stellar.IsBuiltinNetwork(c.StellarNetwork) && (c.HorizonURL == "" || c.NetworkPassphrase == "") {
+		errs = append(errs, errors.New("The Stellar network has to be testnet or production, other networks need a horizon url and network passphrase"))
 	}
 	if !stellar.IsValidStellarSecret(c.StellarSecret) {
-		return errors.New("Invalid account activation secret")
+		errs = append(errs, errors.New("Invalid account activation secret"))
 	}
-	return
+	return errors.Join(errs...)
+}
+
+// SetNetwork makes the configured horizon url and passphrase available under the name of the stellar network
+func (c *Config) SetNetwork() {
+	if c.HorizonURL == "" && c.NetworkPassphrase == "" {
+		return
+	}
+	n, _ := stellar.GetNetwork(c.StellarNetwork)
+	if c.HorizonURL != "" {
+		n.HorizonURL = c.HorizonURL
+	}
+	if c.NetworkPassphrase != "" {
+		n.Passphrase = c.NetworkPassphrase
+	}
+	stellar.SetNetwork(c.StellarNetwork, n)
 }
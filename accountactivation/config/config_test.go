@@ -0,0 +1,69 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "ACTIVATION_RESCAN_HEIGHT", EnvName("ACTIVATION", "rescanHeight"))
+	assert.Equal(t, "ACTIVATION_ETHURL", EnvName("ACTIVATION", "ethurl"))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS\n"), 0600))
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+network: production
+ethurl: ws://localhost:8551
+secret_file: `+secretFile+`
+debug: true
+rescanHeight: 100
+`), 0644))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(FileFlag, "", "")
+	network := fs.String("network", "testnet", "")
+	ethURL := fs.String("ethurl", "", "")
+	secret := fs.String("secret", "", "")
+	debug := fs.Bool("debug", false, "")
+	rescanHeight := fs.Uint64("rescanHeight", 0, "")
+	contract := fs.String("contract", "", "")
+	require.NoError(t, fs.Parse([]string{"-config", configFile, "-ethurl", "ws://10.0.0.1:8551"}))
+
+	t.Setenv("TEST_CONTRACT", "0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf")
+	t.Setenv("TEST_RESCAN_HEIGHT", "200")
+	require.NoError(t, Load(fs, "TEST"))
+
+	assert.Equal(t, "production", *network)
+	assert.Equal(t, "ws://10.0.0.1:8551", *ethURL, "the command line takes precedence")
+	assert.Equal(t, "SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS", *secret)
+	assert.True(t, *debug)
+	assert.Equal(t, uint64(200), *rescanHeight, "the environment takes precedence over the config file")
+	assert.Equal(t, "0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf", *contract)
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("rescanHeight: lots\nunknown: 1\n"), 0644))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(FileFlag, "", "")
+	fs.Uint64("rescanHeight", 0, "")
+	fs.String("secret", "", "")
+	require.NoError(t, fs.Parse([]string{"-config", configFile}))
+
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	err := Load(fs, "TEST")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rescanHeight")
+	assert.Contains(t, err.Error(), "unknown setting unknown")
+	assert.Contains(t, err.Error(), "TEST_SECRET_FILE")
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/threefoldfoundation/tft/accountactivation/stellar"
 )
 
 func TestStellarConfigValidate(t *testing.T) {
@@ -13,3 +14,19 @@ func TestStellarConfigValidate(t *testing.T) {
 	c.StellarSecret = "SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS"
 	assert.NoError(t, c.Validate())
 }
+
+func TestStellarConfigCustomNetwork(t *testing.T) {
+	c := Config{
+		StellarNetwork: "standalone",
+		StellarSecret:  "SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS",
+	}
+	assert.Error(t, c.Validate())
+	c.HorizonURL = "http://localhost:8000"
+	c.NetworkPassphrase = "Standalone Network ; February 2017"
+	assert.NoError(t, c.Validate())
+
+	c.SetNetwork()
+	passphrase, err := stellar.GetNetworkPassPhrase(c.StellarNetwork)
+	assert.NoError(t, err)
+	assert.Equal(t, c.NetworkPassphrase, passphrase)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/threefoldfoundation/tft/accountactivation/config"
 	"github.com/threefoldfoundation/tft/accountactivation/eth"
 	"github.com/threefoldfoundation/tft/accountactivation/state"
 	"github.com/threefoldfoundation/tft/accountactivation/stellar"
@@ -16,6 +18,9 @@ import (
 
 var Version = "development"
 
+// envPrefix is the prefix of the environment variables the settings are read from
+const envPrefix = "ACTIVATION"
+
 func main() {
 	var cfg Config
 
@@ -25,11 +30,14 @@ func main() {
 	flag.StringVar(&cfg.PersistencyFile, "persistency", "./state.json", "file where last seen blockheight is stored")
 
 	flag.StringVar(&cfg.StellarSecret, "secret", "", "secret of the stellar account that activates new accounts")
-	flag.StringVar(&cfg.StellarNetwork, "network", "testnet", "stellar network, testnet or production")
+	flag.StringVar(&cfg.StellarNetwork, "network", "testnet", "stellar network, testnet, production or the name of a network configured with the horizon url and passphrase")
+	flag.StringVar(&cfg.HorizonURL, "horizon-url", "", "horizon url, overrides the horizon of the stellar network")
+	flag.StringVar(&cfg.NetworkPassphrase, "network-passphrase", "", "stellar network passphrase, overrides the passphrase of the stellar network")
 	flag.Uint64Var(&cfg.RescanFromHeight, "rescanHeight", 0, "if provided, the bridge will rescan all withdraws from the given height")
 	version := flag.Bool("version", false, "Print the version and exit")
 	var debug bool
 	flag.BoolVar(&debug, "debug", false, "sets debug level log output")
+	flag.String(config.FileFlag, "", "yaml config file with flag names as keys, settings can also be given as ACTIVATION_<FLAG> environment variables and read from files with <flag>_file or ACTIVATION_<FLAG>_FILE")
 
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s (version %s):\n", os.Args[0], Version)
@@ -40,9 +48,11 @@ func main() {
 		fmt.Println(Version)
 		os.Exit(0)
 	}
-	if err := cfg.Validate(); err != nil {
-		panic(err)
+	if err := errors.Join(config.Load(flag.CommandLine, envPrefix), cfg.Validate()); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
 	}
+	cfg.SetNetwork()
 
 	logLevel := log.LvlInfo
 	if debug {
@@ -2,8 +2,9 @@ package stellar
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/stellar/go/clients/horizonclient"
@@ -24,28 +25,71 @@ func AccountAdressFromSecret(secret string) (address string, err error) {
 	return
 }
 
+// Network holds the settings of a Stellar network
+type Network struct {
+	HorizonURL string
+	Passphrase string
+}
+
+var (
+	networksLock sync.RWMutex
+	networks     = map[string]Network{
+		"testnet": {
+			HorizonURL: horizonclient.DefaultTestNetClient.HorizonURL,
+			Passphrase: network.TestNetworkPassphrase,
+		},
+		"production": {
+			HorizonURL: horizonclient.DefaultPublicNetClient.HorizonURL,
+			Passphrase: network.PublicNetworkPassphrase,
+		},
+	}
+	horizonClients = make(map[string]*horizonclient.Client)
+)
+
+// IsBuiltinNetwork returns if the settings of a network are known without configuring them
+func IsBuiltinNetwork(name string) bool {
+	return name == "testnet" || name == "production"
+}
+
+// GetNetwork returns the settings of a network
+func GetNetwork(name string) (Network, error) {
+	networksLock.RLock()
+	defer networksLock.RUnlock()
+	n, ok := networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("stellar network %s is not supported", name)
+	}
+	return n, nil
+}
+
+// SetNetwork adds a network, like a standalone or private network, or overrides the settings of a built-in one
+func SetNetwork(name string, n Network) {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	networks[name] = n
+	delete(horizonClients, name)
+}
+
 // GetHorizonClient gets an horizon client for a specific network
 func GetHorizonClient(network string) (*horizonclient.Client, error) {
-	switch network {
-	case "testnet":
-		return horizonclient.DefaultTestNetClient, nil
-	case "production":
-		return horizonclient.DefaultPublicNetClient, nil
-	default:
-		return nil, errors.New("network is not supported")
+	n, err := GetNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	client, ok := horizonClients[network]
+	if !ok {
+		client = &horizonclient.Client{HorizonURL: n.HorizonURL, HTTP: http.DefaultClient}
+		horizonClients[network] = client
 	}
+	return client, nil
 }
 
 // GetNetworkPassPhrase gets the Stellar network passphrase based on a network input
-func GetNetworkPassPhrase(ntwrk string) string {
-	switch ntwrk {
-	case "testnet":
-		return network.TestNetworkPassphrase
-	case "production":
-		return network.PublicNetworkPassphrase
-	default:
-		return network.TestNetworkPassphrase
-	}
+func GetNetworkPassPhrase(network string) (string, error) {
+	n, err := GetNetwork(network)
+	return n.Passphrase, err
 }
 
 func fetchTransactions(ctx context.Context, client *horizonclient.Client, address string, cursor string, handler func(op hProtocol.Transaction) error) error {
@@ -53,7 +53,11 @@ func (w *Wallet) ActivateAccount(account string, memoHash [32]byte) (err error)
 	}
 
 	// Sign the transaction
-	tx, err = tx.Sign(GetNetworkPassPhrase(w.network), w.keypair)
+	passphrase, err := GetNetworkPassPhrase(w.network)
+	if err != nil {
+		return
+	}
+	tx, err = tx.Sign(passphrase, w.keypair)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,251 @@
+package bridge
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/limits"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
+)
+
+const (
+	// headStallTimeout is the time without a new head after which the bridge is considered stuck
+	headStallTimeout = 5 * time.Minute
+	// adminShutdownTimeout is the time in flight admin requests get to finish on shutdown
+	adminShutdownTimeout = 5 * time.Second
+)
+
+// Status is the state of the bridge as reported by the admin server
+type Status struct {
+	Follower bool        `json:"follower"`
+	DryRun   bool        `json:"dryRun"`
+	Synced   bool        `json:"synced"`
+	Pause    pause.State `json:"pause"`
+	// HeadHeight is the last head received from the ethereum node and HeadSeen when it was received
+	HeadHeight uint64    `json:"headHeight"`
+	HeadSeen   time.Time `json:"headSeen"`
+	// LastHeight is the last processed head and WithdrawCheckpoint the height up to which
+	// all Withdraw events are queued, both as persisted
+	LastHeight         uint64             `json:"lastHeight"`
+	WithdrawCheckpoint uint64             `json:"withdrawCheckpoint"`
+	StellarCursor      string             `json:"stellarCursor"`
+	PendingWithdrawals []state.Withdrawal `json:"pendingWithdrawals"`
+	// HeldMints are the deposits which wait for the mint volume caps
+	HeldMints []state.HeldMint `json:"heldMints"`
+	// MintVolume and WithdrawVolume are the amounts counted against the volume caps
+	MintVolume     limits.Volume `json:"mintVolume"`
+	WithdrawVolume limits.Volume `json:"withdrawVolume"`
+	// Cosigners is only set on the master bridge
+	Cosigners []CosignerStatus `json:"cosigners,omitempty"`
+	// Lease is only set with failover
+	Lease    *lease.Status  `json:"lease,omitempty"`
+	Balances BalancesStatus `json:"balances"`
+}
+
+// BalancesStatus holds the balances of the hot wallets of the bridge.
+// Balances which could not be fetched are reported in Errors.
+type BalancesStatus struct {
+	Eth     *ERC20BalanceInfo        `json:"eth,omitempty"`
+	Stellar []stellar.AccountBalance `json:"stellar,omitempty"`
+	Errors  []string                 `json:"errors,omitempty"`
+}
+
+func (bridge *Bridge) setHead(height uint64, synced bool) {
+	bridge.statusLock.Lock()
+	defer bridge.statusLock.Unlock()
+	bridge.headHeight = height
+	bridge.headSeen = time.Now()
+	bridge.headSynced = synced
+}
+
+// Status collects the current status of the bridge
+func (bridge *Bridge) Status() (Status, error) {
+	bridge.statusLock.RLock()
+	status := Status{
+		Follower:   !bridge.leading(),
+		DryRun:     bridge.decisions != nil,
+		Synced:     bridge.headSynced,
+		HeadHeight: bridge.headHeight,
+		HeadSeen:   bridge.headSeen,
+		Pause:      bridge.pause.State(),
+	}
+	bridge.statusLock.RUnlock()
+
+	persisted, err := bridge.blockPersistency.GetHeight()
+	if err != nil {
+		return status, err
+	}
+	status.LastHeight = persisted.LastHeight
+	status.WithdrawCheckpoint = persisted.WithdrawCheckpoint
+	status.StellarCursor = persisted.StellarCursor
+	status.PendingWithdrawals = bridge.withdrawals.Open()
+	status.HeldMints = bridge.heldMints.All()
+	status.MintVolume = bridge.volume(state.VolumeMint)
+	status.WithdrawVolume = bridge.volume(state.VolumeWithdraw)
+	if bridge.signersClient != nil {
+		status.Cosigners = bridge.signersClient.Cosigners()
+	}
+	if bridge.elector != nil {
+		election := bridge.elector.Status()
+		status.Lease = &election
+	}
+
+	if status.Balances.Eth, err = bridge.bridgeContract.ethc.GetBalanceInfo(); err != nil {
+		status.Balances.Errors = append(status.Balances.Errors, "eth: "+err.Error())
+	}
+	if status.Balances.Stellar, err = bridge.wallet.GetBalances(); err != nil {
+		status.Balances.Errors = append(status.Balances.Errors, "stellar: "+err.Error())
+	}
+	return status, nil
+}
+
+// NodeStatus returns the state of this bridge which is exchanged with the other signers
+func (bridge *Bridge) NodeStatus() multisig.NodeStatus {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	return multisig.NodeStatus{
+		Version:         bridge.config.Version,
+		ProtocolVersion: multisig.ProtocolVersion,
+		Height:          bridge.headHeight,
+		Synced:          bridge.headSynced,
+		StellarCursor:   bridge.wallet.TransactionStorage.Cursor(),
+		DepositFee:      bridge.config.DepositFee.String(),
+		WithdrawFee:     bridge.config.WithdrawFee.String(),
+		Pause:           bridge.pause.State(),
+	}
+}
+
+// alive reports an error if no head was received for too long
+func (bridge *Bridge) alive() error {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	last := bridge.headSeen
+	if last.IsZero() {
+		last = bridge.started
+	}
+	if time.Since(last) > headStallTimeout {
+		return errors.New("no new head received since " + last.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ready reports an error if the bridge can not process deposits and withdrawals
+func (bridge *Bridge) ready() error {
+	if err := bridge.alive(); err != nil {
+		return err
+	}
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	if !bridge.headSynced {
+		return errors.New("ethereum node is not synced")
+	}
+	return nil
+}
+
+// AdminHandler returns the handler of the admin server:
+//
+//	GET /status   the Status of the bridge
+//	GET /healthz  liveness, fails if the bridge stopped receiving heads
+//	GET /readyz   readiness, fails if the bridge is not synced
+//	POST /pause   pauses the bridge, an optional reason query parameter is shown in the status
+//	POST /resume  resumes the bridge
+//
+// Pause and resume need the admin token of the BridgeConfig as a bearer token in the Authorization header,
+// they are refused if no admin token is configured.
+func (bridge *Bridge) AdminHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status, err := bridge.Status()
+		if err != nil {
+			log.Error("failed to get the bridge status", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, status)
+	})
+	mux.HandleFunc("/pause", bridge.adminAction(func(w http.ResponseWriter, r *http.Request) {
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "paused through the admin API"
+		}
+		bridge.pause.Pause(reason)
+		writeJSON(w, bridge.pause.State())
+	}))
+	mux.HandleFunc("/resume", bridge.adminAction(func(w http.ResponseWriter, r *http.Request) {
+		if err := bridge.pause.Resume(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, bridge.pause.State())
+	}))
+	mux.HandleFunc("/healthz", probeHandler(bridge.alive))
+	mux.HandleFunc("/readyz", probeHandler(bridge.ready))
+	return mux
+}
+
+// adminAction only passes POST requests with the admin token to handler
+func (bridge *Bridge) adminAction(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if bridge.config.AdminToken == "" {
+			http.Error(w, "no admin token configured", http.StatusForbidden)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+bridge.config.AdminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func probeHandler(probe func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := probe(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("failed to write response", "err", err)
+	}
+}
+
+// ServeAdmin runs the admin server on addr until the context is cancelled
+func ServeAdmin(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Admin server listening", "address", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
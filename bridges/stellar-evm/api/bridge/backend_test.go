@@ -0,0 +1,157 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+)
+
+var testChainID = params.AllEthashProtocolChanges.ChainID
+
+// testSupply is minted to the deployer of the token contract, the tests fund their accounts from it
+var testSupply = big.NewInt(1_000_000_0000000)
+
+// simulatedChain is an in memory chain with the token contract deployed on it,
+// every transaction is mined in its own block right away.
+type simulatedChain struct {
+	*backends.SimulatedBackend
+	tokenAddress common.Address
+	token        *tokenv1.Token
+	// deployer owns the token contract and holds the supply which is not handed out yet
+	deployer *bind.TransactOpts
+}
+
+// newSimulatedChain creates a chain on which the given accounts are funded,
+// the token contract accepts mints signed by the required amount of signers.
+func newSimulatedChain(t *testing.T, signers []common.Address, required int64, funded ...common.Address) *simulatedChain {
+	deployerKey := newKey(t)
+	deployer, err := bind.NewKeyedTransactorWithChainID(deployerKey, testChainID)
+	require.NoError(t, err)
+	alloc := core.GenesisAlloc{deployer.From: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))}}
+	for _, account := range funded {
+		alloc[account] = core.GenesisAccount{Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))}
+	}
+	backend := backends.NewSimulatedBackend(alloc, 30_000_000)
+	t.Cleanup(func() { backend.Close() })
+	chain := &simulatedChain{SimulatedBackend: backend, deployer: deployer}
+
+	chain.tokenAddress, _, chain.token, err = tokenv1.DeployToken(deployer, chain)
+	require.NoError(t, err)
+	// the deployer mints the supply as only signer before the signers of the test take over
+	_, err = chain.token.SetSigners(deployer, []common.Address{deployer.From}, big.NewInt(1))
+	require.NoError(t, err)
+	_, err = chain.token.MintTokens(deployer, deployer.From, testSupply, "supply", []tokenv1.Signature{signMint(t, deployerKey, deployer.From, testSupply, "supply")})
+	require.NoError(t, err)
+	_, err = chain.token.SetSigners(deployer, signers, big.NewInt(required))
+	require.NoError(t, err)
+	return chain
+}
+
+func (c *simulatedChain) ChainID(ctx context.Context) (*big.Int, error) {
+	return testChainID, nil
+}
+
+func (c *simulatedChain) BlockNumber(ctx context.Context) (uint64, error) {
+	head, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return head.Number.Uint64(), nil
+}
+
+func (c *simulatedChain) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// SendTransaction mines the transaction in a new block
+func (c *simulatedChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.Commit()
+	return nil
+}
+
+// fund transfers tokens from the supply of the deployer to the account
+func (c *simulatedChain) fund(t *testing.T, account common.Address, amount *big.Int) {
+	_, err := c.token.Transfer(c.deployer, account, amount)
+	require.NoError(t, err)
+}
+
+// sendToken signs and sends a call to the token contract with the given key
+func (c *simulatedChain) sendToken(t *testing.T, key *ecdsa.PrivateKey, method string, args ...interface{}) *types.Transaction {
+	opts, err := bind.NewKeyedTransactorWithChainID(key, testChainID)
+	require.NoError(t, err)
+	tx, err := (&tokenv1.TokenRaw{Contract: c.token}).Transact(opts, method, args...)
+	require.NoError(t, err)
+	return tx
+}
+
+// signMint creates the signature of the key for a mint like the signers of the bridge do
+func signMint(t *testing.T, key *ecdsa.PrivateKey, receiver common.Address, amount *big.Int, txID string) tokenv1.Signature {
+	parsed, err := tokenv1.TokenMetaData.GetAbi()
+	require.NoError(t, err)
+	payload, err := parsed.Methods["mintTokens"].Inputs[:3].Pack(receiver, amount, txID)
+	require.NoError(t, err)
+	signature, err := crypto.Sign(crypto.Keccak256([]byte(EthMessagePrefix), crypto.Keccak256(payload)), key)
+	require.NoError(t, err)
+	var sig tokenv1.Signature
+	copy(sig.R[:], signature[:32])
+	copy(sig.S[:], signature[32:64])
+	sig.V = signature[64] + 27
+	return sig
+}
+
+// assertReverted checks that the error is a revert of the token contract with the given custom error
+func assertReverted(t *testing.T, err error, name string) {
+	var dataErr rpc.DataError
+	require.ErrorAs(t, err, &dataErr)
+	data, err := hexutil.Decode(fmt.Sprint(dataErr.ErrorData()))
+	require.NoError(t, err)
+	parsed, err := tokenv1.TokenMetaData.GetAbi()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(data), 4)
+	assert.Equal(t, parsed.Errors[name].ID.Bytes()[:4], data[:4], "the contract should revert with %s", name)
+}
+
+// newTestBridgeContract creates a BridgeContract on the chain which signs with the given key,
+// blocks are confirmed as soon as they are mined
+func newTestBridgeContract(t *testing.T, chain *simulatedChain, key *ecdsa.PrivateKey) *BridgeContract {
+	contract, err := NewBridgeContractWithBackend(&EthConfig{EthNetworkName: "simulated"}, tfeth.NetworkConfiguration{
+		NetworkID:       testChainID.Uint64(),
+		NetworkName:     "simulated",
+		ContractAddress: chain.tokenAddress,
+		Confirmations:   tfeth.ConfirmationPolicy{Mode: tfeth.ConfirmationBlocks},
+	}, chain, keys.NewLocalEthSigner(key))
+	require.NoError(t, err)
+	contract.mints, err = state.NewMintTracker(state.MintsFile(filepath.Join(t.TempDir(), "node.json")))
+	require.NoError(t, err)
+	return contract
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -17,31 +19,65 @@ import (
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/faults"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/limits"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/p2p"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
 )
 
 const (
-	// EthBlockDelay is the amount of blocks to wait before
-	// pushing eth transaction to the stellar network
-	EthBlockDelay = 3
-	// Withdrawing from smartchain to Stellar fee
-	WithdrawFee      = int64(1 * stellar.Precision) //WithdrawFeeof 1 TFT in Stroops
 	BridgeNetwork    = "stellar"
 	EthMessagePrefix = "\x19Ethereum Signed Message:\n32"
 )
 
+var errReorganized = errors.New("withdraw event removed by a chain reorganization")
+
+var errNotMaster = errors.New("the bridge is not the master")
+
+// statusInterval is how often the status of the cosigners is polled
+const statusInterval = time.Minute
+
+// leaseConfig waits out the time bounds of the Stellar transactions of the previous master before taking over
+var leaseConfig = lease.Config{
+	Duration: lease.DefaultConfig.Duration,
+	Renew:    lease.DefaultConfig.Renew,
+	Handover: stellar.TransactionTimeout,
+}
+
 // Bridge is a high lvl structure which listens on contract events and bridge-related
 // stellar transactions, and handles them
 type Bridge struct {
 	bridgeContract   *BridgeContract
 	wallet           *stellar.Wallet
 	blockPersistency *state.ChainPersistency
+	withdrawals      *state.WithdrawalQueue
+	volumes          *state.VolumeTracker
+	heldMints        *state.HeldMints
 	mut              sync.Mutex
 	config           *BridgeConfig
 	synced           bool
 	signersClient    *SignersClient
+	pause            *pause.Switch
+	// decisions is only set in dry-run mode, see BridgeConfig.DryRun
+	decisions *state.DecisionLog
+	// leases and elector are only set with BridgeConfig.Failover
+	leases  *lease.Service
+	elector *lease.Elector
+	// stellarSigners and contractSigners are the signers last seen by watchSigners
+	stellarSigners  signerSet
+	contractSigners signerSet
+
+	// started and the head fields are only used to report the status, see admin.go
+	started    time.Time
+	statusLock sync.RWMutex
+	headHeight uint64
+	headSeen   time.Time
+	headSynced bool
 }
 
 type BridgeConfig struct {
@@ -51,25 +87,68 @@ type BridgeConfig struct {
 	Follower            bool
 	Relay               string
 	Psk                 string
-	// deposit fee in TFT units
-	DepositFee int64
+	// Failover elects the master among the signers of the bridge account instead of Follower
+	Failover bool
+	// DepositFee is charged on deposits from Stellar
+	DepositFee fees.Schedule
+	// WithdrawFee is charged on withdrawals and refunds to Stellar
+	WithdrawFee fees.Schedule
+	// MintLimits and WithdrawLimits bound the amounts before fees
+	MintLimits     limits.Limits
+	WithdrawLimits limits.Limits
+	// AdminAddr is the address the admin http server listens on, empty disables it
+	AdminAddr string
+	// AdminToken authorizes the pause and resume requests on the admin server, empty refuses them
+	AdminToken string
+	// DryRun builds and validates the transactions of the master but records them
+	// in the decision log instead of submitting them.
+	// DryRunSignatures requests the cosigner signatures for them as well.
+	DryRun           bool
+	DryRunSignatures bool
+	// Version is the release of the bridge, it is compared with the one of the cosigners
+	Version string
 }
 
 // NewBridge creates a new Bridge.
 // TODO: context is not used
-func NewBridge(ctx context.Context, wallet *stellar.Wallet, contract *BridgeContract, config *BridgeConfig, host host.Host, router routing.PeerRouting) (bridge *Bridge, err error) {
+func NewBridge(ctx context.Context, wallet *stellar.Wallet, contract *BridgeContract, config *BridgeConfig, host host.Host, router routing.PeerRouting, pauseSwitch *pause.Switch) (bridge *Bridge, err error) {
 	blockPersistency := state.NewChainPersistency(config.PersistencyFile)
 
+	withdrawals, err := state.NewWithdrawalQueue(state.WithdrawalsFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	contract.mints, err = state.NewMintTracker(state.MintsFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := state.NewVolumeTracker(state.VolumeFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	heldMints, err := state.NewHeldMints(state.HeldMintsFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
 	bridge = &Bridge{
 		bridgeContract:   contract,
 		blockPersistency: blockPersistency,
+		withdrawals:      withdrawals,
+		volumes:          volumes,
+		heldMints:        heldMints,
 		wallet:           wallet,
 		config:           config,
+		pause:            pauseSwitch,
+		started:          time.Now(),
 	}
-	// Only create the signer client if the bridge is running in master mode
-	if !config.Follower {
+	// Only create the signer client if the bridge can run in master mode
+	if !config.Follower || config.Failover {
 		relayAddrInfo, addrErr := peer.AddrInfoFromString(config.Relay)
-		if err != nil {
+		if addrErr != nil {
 			return nil, addrErr
 		}
 		cosigners, requiredSignatures, err := wallet.GetSigningRequirements()
@@ -82,9 +161,26 @@ func NewBridge(ctx context.Context, wallet *stellar.Wallet, contract *BridgeCont
 		if err != nil {
 			return nil, err
 		}
-		bridge.signersClient = NewSignersClient(host, router, cosignerPeerIDs, relayAddrInfo)
+		signatures, err := state.NewPartialSignatures(state.SignaturesFile(config.PersistencyFile))
+		if err != nil {
+			return nil, err
+		}
+		bridge.signersClient = NewSignersClient(host, router, cosignerPeerIDs, relayAddrInfo, signatures)
+		bridge.stellarSigners = newSignerSet(cosigners, int64(requiredSignatures))
+
+		if config.Failover {
+			bridge.leases = lease.NewService(host.ID(), leaseConfig)
+			bridge.elector = lease.NewElector(bridge.leases, cosignerPeerIDs, bridge.signersClient.callLease)
+		}
 
 		wallet.SetSignerClient(bridge.signersClient)
+		wallet.SetPauseSwitch(pauseSwitch)
+
+		if config.DryRun {
+			bridge.decisions = state.NewDecisionLog(state.DecisionsFile(config.PersistencyFile))
+			wallet.SetDryRun(bridge.decisions, config.DryRunSignatures)
+			log.Warn("Running in dry-run mode, transactions are recorded instead of submitted", "file", state.DecisionsFile(config.PersistencyFile), "signatures", config.DryRunSignatures)
+		}
 	}
 
 	if config.RescanBridgeAccount {
@@ -111,6 +207,12 @@ func (bridge *Bridge) Close() error {
 }
 
 func (bridge *Bridge) mint(receiver eth.ERC20Address, depositedAmount *big.Int, txID string) (err error) {
+	if bridge.pause.Paused() {
+		return pause.ErrPaused
+	}
+	if !bridge.leading() {
+		return errNotMaster
+	}
 	if !bridge.synced {
 		return errors.New("bridge is not synced, retry later")
 	}
@@ -122,11 +224,14 @@ func (bridge *Bridge) mint(receiver eth.ERC20Address, depositedAmount *big.Int,
 	}
 	if known {
 		log.Info("Skipping known minting transaction", "txID", txID)
+		bridge.bridgeContract.forgetMint(txID)
+		// the bridge might have stopped after the mint and before recording it, a mint is not counted twice
+		bridge.recordMintVolume(txID, depositedAmount)
 		// we already know this withdrawal address, so ignore the transaction
 		return
 	}
 
-	depositFeeBigInt := big.NewInt(stellar.IntToStroops(bridge.config.DepositFee))
+	depositFeeBigInt := big.NewInt(bridge.config.DepositFee.Fee(depositedAmount.Int64()))
 
 	if depositedAmount.Cmp(depositFeeBigInt) <= 0 {
 		log.Error("Deposited amount is <= Fee, should be returned", "amount", depositedAmount, "txID", txID)
@@ -135,21 +240,35 @@ func (bridge *Bridge) mint(receiver eth.ERC20Address, depositedAmount *big.Int,
 	amount := &big.Int{}
 	amount = amount.Sub(depositedAmount, depositFeeBigInt)
 
+	if err = bridge.config.MintLimits.CheckAmount(depositedAmount.Int64()); err != nil {
+		log.Warn("Deposited amount is outside the mint limits, should be returned", "amount", depositedAmount, "txID", txID, "err", err)
+		return faults.ErrDepositOutsideLimits
+	}
+	if err = bridge.config.MintLimits.CheckVolume(depositedAmount.Int64(), bridge.volume(state.VolumeMint)); err != nil {
+		log.Warn("Holding the mint until it fits the volume caps", "amount", depositedAmount, "txID", txID, "err", err)
+		return faults.ErrVolumeCapExceeded
+	}
+
 	requiredSignatureCount, err := bridge.bridgeContract.GetRequiresSignatureCount()
 	if err != nil {
 		return err
 	}
 	log.Debug("required signature count", "count", requiredSignatureCount)
 
-	res, err := bridge.signersClient.SignMint(context.Background(), EthSignRequest{
-		Receiver: common.BytesToAddress(receiver[:]),
-		Amount:   amount.Int64(),
-		TxId:     txID,
-		// subtract 1 from the required signature count, because the master signature is already included
-		RequiredSignatures: requiredSignatureCount.Sub(requiredSignatureCount, big.NewInt(1)).Int64(),
-	})
-	if err != nil {
-		return err
+	var res []EthSignResponse
+	requestSignatures := bridge.decisions == nil || bridge.config.DryRunSignatures
+	if requestSignatures {
+		res, err = bridge.signersClient.SignMint(context.Background(), EthSignRequest{
+			Version:  multisig.ProtocolVersion,
+			Receiver: common.BytesToAddress(receiver[:]),
+			Amount:   amount.Int64(),
+			TxId:     txID,
+			// subtract 1 from the required signature count, because the master signature is already included
+			RequiredSignatures: requiredSignatureCount.Sub(requiredSignatureCount, big.NewInt(1)).Int64(),
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// First create the master signature
@@ -166,18 +285,84 @@ func (bridge *Bridge) mint(receiver eth.ERC20Address, depositedAmount *big.Int,
 		return err
 	}
 
-	orderderedSignatures := make([]tokenv1.Signature, len(signers))
+	orderderedSignatures := orderSignatures(signers, res)
+
+	log.Debug("total signatures count", "count", len(orderderedSignatures))
+
+	// a dry run does not count in the metrics and the volume caps of a later live run
+	if bridge.decisions != nil {
+		return bridge.recordMint(receiver, amount, depositFeeBigInt.Int64(), txID, orderderedSignatures, len(res), requestSignatures)
+	}
+	if err = bridge.bridgeContract.Mint(receiver, amount, txID, orderderedSignatures); err != nil {
+		return err
+	}
+	metrics.Transfer(metrics.TransferMint, amount.Int64())
+	bridge.recordMintVolume(txID, depositedAmount)
+	return nil
+}
+
+// recordMintVolume counts a deposit which is minted in the mint volume
+func (bridge *Bridge) recordMintVolume(txID string, depositedAmount *big.Int) {
+	// a dry run does not count in the volume caps of a later live run
+	if bridge.decisions != nil {
+		return
+	}
+	if err := bridge.volumes.Record(state.VolumeMint, txID, depositedAmount.Int64()); err != nil {
+		log.Error("failed to record the mint volume", "txID", txID, "err", err)
+	}
+}
+
+// orderSignatures puts the signatures in the order of the signers of the contract,
+// the signature of a signer which did not sign is left empty so the contract skips it.
+func orderSignatures(signers []common.Address, responses []EthSignResponse) []tokenv1.Signature {
+	ordered := make([]tokenv1.Signature, len(signers))
 	for i := 0; i < len(signers); i++ {
-		for _, sign := range res {
+		for _, sign := range responses {
 			if sign.Who == signers[i] {
-				orderderedSignatures[i] = sign.Signature
+				ordered[i] = sign.Signature
 			}
 		}
 	}
+	return ordered
+}
 
-	log.Debug("total signatures count", "count", len(orderderedSignatures))
+// recordMint validates a mint and records it in the decision log instead of submitting it
+func (bridge *Bridge) recordMint(receiver eth.ERC20Address, amount *big.Int, fee int64, txID string, signatures []tokenv1.Signature, signatureCount int, complete bool) error {
+	data, err := bridge.bridgeContract.validateMint(receiver, amount, txID, signatures, complete)
+	if err != nil {
+		return err
+	}
+	log.Info("Dry run, recording the mint instead of submitting it", "txID", txID, "receiver", common.Address(receiver), "amount", stellar.StroopsToDecimal(amount.Int64()))
+	return bridge.decisions.Record(state.Decision{
+		Transfer:    metrics.TransferMint,
+		ID:          txID,
+		Destination: common.Address(receiver).Hex(),
+		Amount:      amount.Int64(),
+		Fee:         fee,
+		Signatures:  signatureCount,
+		Transaction: hexutil.Encode(data),
+	})
+}
 
-	return bridge.bridgeContract.Mint(receiver, amount, txID, orderderedSignatures)
+// volume returns the amount transferred in the given direction during the last hour and day
+func (bridge *Bridge) volume(direction string) limits.Volume {
+	return limits.Volume{
+		Hour: bridge.volumes.Volume(direction, time.Hour),
+		Day:  bridge.volumes.Volume(direction, 24*time.Hour),
+	}
+}
+
+// leading reports if the bridge acts as master
+func (bridge *Bridge) leading() bool {
+	if bridge.elector != nil {
+		return bridge.elector.Leading()
+	}
+	return !bridge.config.Follower
+}
+
+// LeaseService returns the service granting the master lease to the other signers, nil without failover
+func (bridge *Bridge) LeaseService() *lease.Service {
+	return bridge.leases
 }
 
 // GetClient returns bridgecontract lightclient
@@ -206,120 +391,59 @@ func (bridge *Bridge) Start(ctx context.Context) error {
 		}
 	}()
 
-	// Channel where withdrawal events are stored
-	// Should only be read from by the master bridge
-	withdrawChan := make(chan WithdrawEvent)
-
 	// Only the bridge running as the master bridge should do the following things:
 	// - Monitor the Bridge Stellar account and initiate Minting transactions accordingly
 	// - Monitor the Contract for Withdrawal events and initiate a Withdrawal transaction accordingly
-	if !bridge.config.Follower {
-		// Scan bridge account for outgoing transactions to avoid double withdraws or refunds
-		if err := bridge.wallet.ScanBridgeAccount(); err != nil {
-			panic(err)
-		}
-
-		// Monitor the bridge wallet for incoming transactions
-		// mint transactions on ERC20 if possible
-		go func() {
-			if err := bridge.wallet.MonitorBridgeAccountAndMint(ctx, bridge.mint, bridge.blockPersistency); err != nil {
-				panic(err)
-			}
-		}()
-
-		// Sync up any withdrawals made if the blockheight is manually set
-		// to a previous value
-		currentBlock, err := bridge.bridgeContract.ethc.BlockNumber(ctx)
+	// With failover every signer queues the withdrawals, so the one taking over knows the withdrawals
+	// which are not paid out yet, the withdrawals and deposits are only processed while it is master.
+	if !bridge.config.Follower || bridge.elector != nil {
+		from, err := bridge.withdrawStartHeight(ctx)
 		if err != nil {
 			return err
 		}
+		go bridge.bridgeContract.FollowWithdraw(ctx, from, bridge.rememberWithdrawal, bridge.blockPersistency.SaveWithdrawCheckpoint)
+	}
+	if bridge.elector != nil {
+		go bridge.elector.Run(ctx, bridge.monitorDeposits)
+	} else if !bridge.config.Follower {
+		bridge.monitorDeposits(ctx)
+	}
 
-		var lastHeight uint64
-		// If the user provides a height to rescan from, use that
-		// Otherwise use the saved height in the persistency file
-		if bridge.config.RescanFromHeight > 0 {
-			lastHeight = uint64(bridge.config.RescanFromHeight) - EthBlockDelay
-		} else {
-			height, err := bridge.blockPersistency.GetHeight()
-			if err != nil {
-				return err
-			}
-			// if the saved height is 0, just use current block
-			if height.LastHeight == 0 {
-				lastHeight = currentBlock
-			}
-			if height.LastHeight > EthBlockDelay {
-				lastHeight = height.LastHeight - EthBlockDelay
-			}
-		}
-
-		if lastHeight < currentBlock {
-			// todo filter logs
-			go func() {
-				if err := bridge.bridgeContract.FilterWithdraw(withdrawChan, lastHeight, currentBlock); err != nil {
-					panic(err)
-				}
-			}()
-		}
-		// TODO bug: currentblock is not taken into account and if it would be passed,
-		// blocks in past don't work anyway so if the chain progressed between getting the current block
-		// and the start of the watching, events are lost if there are any.
-		go func() {
-			err := bridge.bridgeContract.SubscribeWithdraw(withdrawChan, currentBlock)
-			if err != nil {
-				panic(err)
-			}
-		}()
+	if bridge.signersClient != nil {
+		go bridge.signersClient.MonitorCosigners(ctx, statusInterval, bridge.NodeStatus)
+		go bridge.watchSigners(ctx)
+	}
 
+	if open := bridge.withdrawals.Open(); len(open) > 0 {
+		log.Info("Loaded unfinished withdrawals", "count", len(open))
 	}
 
 	go func() {
-		txMap := make(map[string]WithdrawEvent)
 		for {
 			select {
-			// Remember new withdraws
-			// Never happens for cosigners, only for the master since the cosugners are not subscribed to withdraw events
-			case we := <-withdrawChan:
-				if we.network == BridgeNetwork {
-					log.Info("Remembering withdraw event", "txHash", we.TxHash(), "height", we.BlockHeight(), "network", we.network)
-					txMap[we.txHash.String()] = we
-				} else {
-					log.Warn("Ignoring withdrawal, invalid target network", "hash", we.TxHash(), "height", we.BlockHeight(), "network", we.network)
-				}
 			case head := <-heads:
 				bridge.mut.Lock()
 
 				progress, err := bridge.bridgeContract.ethc.SyncProgress(ctx)
 				if err != nil {
 					log.Error(fmt.Sprintf("failed to get sync progress %s", err.Error()))
+					metrics.RPCError(metrics.ServiceEthereum)
 				}
 				if progress == nil {
 					bridge.synced = true
 				}
 
 				log.Info("found new head", "head", head.Number, "synced", bridge.synced)
-
-				if bridge.synced {
-					ids := make([]string, 0, len(txMap))
-					for id := range txMap {
-						ids = append(ids, id)
+				bridge.setHead(head.Number.Uint64(), bridge.synced)
+
+				if bridge.synced && bridge.leading() {
+					confirmed, err := bridge.bridgeContract.ConfirmedHeight(ctx, head)
+					if err != nil {
+						log.Error("failed to get the confirmed height", "head", head.Number, "err", err)
+						metrics.RPCError(metrics.ServiceEthereum)
+					} else {
+						bridge.processWithdrawals(ctx, confirmed)
 					}
-
-					for _, id := range ids {
-						we := txMap[id]
-						if head.Number.Uint64() >= we.blockHeight+EthBlockDelay {
-							log.Info("Starting withdrawal", "txHash", we.TxHash())
-							err := bridge.withdraw(ctx, we)
-							if err != nil {
-								log.Error(fmt.Sprintf("failed to create payment for withdrawal to %s, %s", we.blockchain_address, err.Error()))
-								continue
-							}
-
-							// forget about our tx
-							delete(txMap, id)
-						}
-					}
-
 				}
 
 				err = bridge.blockPersistency.SaveHeight(head.Number.Uint64())
@@ -336,10 +460,151 @@ func (bridge *Bridge) Start(ctx context.Context) error {
 	return nil
 }
 
+// monitorDeposits mints the deposits on the bridge account until the context is done
+func (bridge *Bridge) monitorDeposits(ctx context.Context) {
+	// Scan bridge account for outgoing transactions to avoid double withdraws or refunds
+	if err := bridge.wallet.ScanBridgeAccount(); err != nil {
+		panic(err)
+	}
+
+	// Monitor the bridge wallet for incoming transactions
+	// mint transactions on ERC20 if possible
+	go func() {
+		if err := bridge.wallet.MonitorBridgeAccountAndMint(ctx, bridge.mint, bridge.blockPersistency, bridge.heldMints); err != nil {
+			panic(err)
+		}
+	}()
+}
+
+// processWithdrawals starts the withdrawals in the queue which are included in a confirmed block
+func (bridge *Bridge) processWithdrawals(ctx context.Context, confirmedHeight uint64) {
+	// the withdrawals stay queued while the bridge is paused
+	if bridge.pause.Paused() {
+		log.Debug("Bridge is paused, not processing withdrawals", "confirmed", confirmedHeight)
+		return
+	}
+	for _, w := range bridge.withdrawals.Open() {
+		if w.BlockHeight > confirmedHeight {
+			continue
+		}
+		we := withdrawEventFromWithdrawal(w)
+
+		// Make sure the withdraw event is still part of the canonical chain before paying out
+		canonical, found, err := bridge.bridgeContract.CanonicalWithdraw(ctx, we)
+		if err != nil {
+			log.Error("failed to verify if the withdraw event is canonical", "txHash", we.TxHash(), "err", err)
+			metrics.RPCError(metrics.ServiceEthereum)
+			continue
+		}
+		if !found {
+			log.Warn("Withdraw event is no longer part of the chain, dropping it", "txHash", we.TxHash(), "height", we.BlockHeight(), "blockHash", we.BlockHash())
+			if err := bridge.withdrawals.SetStatus(w.TxHash, state.WithdrawalRemoved, errReorganized); err != nil {
+				log.Error("failed to update withdrawal status", "txHash", we.TxHash(), "err", err)
+			}
+			continue
+		}
+		if canonical.BlockHash() != we.BlockHash() {
+			log.Warn("Withdraw event moved to another block", "txHash", we.TxHash(), "height", canonical.BlockHeight(), "blockHash", canonical.BlockHash())
+			if err := bridge.withdrawals.Relocate(w.TxHash, canonical.BlockHash(), canonical.BlockHeight()); err != nil {
+				log.Error("failed to update withdrawal block", "txHash", we.TxHash(), "err", err)
+			}
+			// wait for enough confirmations on the new block
+			continue
+		}
+
+		if w.Status != state.WithdrawalHeld {
+			log.Info("Starting withdrawal", "txHash", we.TxHash(), "attempt", w.Attempts+1)
+			if w.Attempts > 0 {
+				metrics.Retries.WithLabelValues(metrics.TransferWithdrawal).Inc()
+			}
+		}
+		err = bridge.withdraw(ctx, we)
+		if errors.Is(err, limits.ErrBelowMinimum) {
+			// a withdrawal below the minimum never fits the limits, it is not held forever
+			log.Error("Rejecting withdrawal below the minimum, it is not paid out", "txHash", we.TxHash(), "reason", err)
+			if err := bridge.withdrawals.SetStatus(w.TxHash, state.WithdrawalRejected, err); err != nil {
+				log.Error("failed to update withdrawal status", "txHash", we.TxHash(), "err", err)
+			}
+			continue
+		}
+		if limits.Exceeded(err) {
+			// held withdrawals are checked again on every head, only store a change
+			if w.Status == state.WithdrawalHeld && w.LastError == err.Error() {
+				continue
+			}
+			log.Warn("Holding withdrawal", "txHash", we.TxHash(), "reason", err)
+			if err := bridge.withdrawals.SetStatus(w.TxHash, state.WithdrawalHeld, err); err != nil {
+				log.Error("failed to update withdrawal status", "txHash", we.TxHash(), "err", err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("failed to create payment for withdrawal to %s, %s", we.blockchain_address, err.Error()))
+			if err := bridge.withdrawals.SetStatus(w.TxHash, state.WithdrawalFailed, err); err != nil {
+				log.Error("failed to update withdrawal status", "txHash", we.TxHash(), "err", err)
+			}
+			continue
+		}
+		if err := bridge.withdrawals.SetStatus(w.TxHash, state.WithdrawalDone, nil); err != nil {
+			log.Error("failed to update withdrawal status", "txHash", we.TxHash(), "err", err)
+		}
+	}
+}
+
+// withdrawStartHeight returns the height to start following Withdraw events from.
+func (bridge *Bridge) withdrawStartHeight(ctx context.Context) (uint64, error) {
+	// If the user provides a height to rescan from, use that
+	if bridge.config.RescanFromHeight > 0 {
+		return uint64(bridge.config.RescanFromHeight), nil
+	}
+	height, err := bridge.blockPersistency.GetHeight()
+	if err != nil {
+		return 0, err
+	}
+	if height.WithdrawCheckpoint > 0 {
+		return height.WithdrawCheckpoint + 1, nil
+	}
+	// Persistency files written before checkpoints existed only know the last seen head,
+	// rescan the blocks which might not have been confirmed yet.
+	if height.LastHeight > 0 {
+		confirmationDepth := bridge.bridgeContract.Confirmations().Depth()
+		if height.LastHeight > confirmationDepth {
+			return height.LastHeight - confirmationDepth, nil
+		}
+		return 0, nil
+	}
+	// Nothing saved yet, start at the current block
+	return bridge.bridgeContract.ethc.BlockNumber(ctx)
+}
+
+// rememberWithdrawal stores a Withdraw event in the withdrawal queue.
+// An error is returned if the event could not be stored so it is handed over again.
+func (bridge *Bridge) rememberWithdrawal(we WithdrawEvent) error {
+	if we.network != BridgeNetwork {
+		log.Warn("Ignoring withdrawal, invalid target network", "hash", we.TxHash(), "height", we.BlockHeight(), "network", we.network)
+		return nil
+	}
+	added, err := bridge.withdrawals.Add(we.withdrawal())
+	if err != nil {
+		return fmt.Errorf("failed to store withdraw event %s: %w", we.TxHash(), err)
+	}
+	if added {
+		log.Info("Remembering withdraw event", "txHash", we.TxHash(), "height", we.BlockHeight(), "network", we.network)
+		return nil
+	}
+	// A withdraw event can be included in another block after a chain reorganization
+	w, _ := bridge.withdrawals.Get(we.TxHash())
+	if w.BlockHash == we.BlockHash() || w.Status == state.WithdrawalDone || w.Status == state.WithdrawalSubmitted {
+		return nil
+	}
+	log.Info("Withdraw event included in another block after a chain reorganization", "txHash", we.TxHash(), "height", we.BlockHeight(), "status", w.Status)
+	return bridge.withdrawals.Relocate(we.TxHash(), we.BlockHash(), we.BlockHeight())
+}
+
 func (bridge *Bridge) withdraw(ctx context.Context, we WithdrawEvent) (err error) {
 	// if a withdraw was made to the bridge fee wallet or the bridge address, soak the funds and return
 	//TODO: Should these adresses be fetched through the wallet?
-	if we.blockchain_address == bridge.wallet.Config.StellarFeeWallet || we.blockchain_address == bridge.wallet.GetAddress() {
+	if we.blockchain_address == bridge.wallet.Config.StellarFeeWallet || we.blockchain_address == bridge.wallet.BridgeAccount() {
 		log.Warn("Received a withdrawal with destination which is either the fee wallet or the bridge wallet, skipping...")
 		return nil
 	}
@@ -352,16 +617,40 @@ func (bridge *Bridge) withdraw(ctx context.Context, we WithdrawEvent) (err error
 		return
 	}
 
-	if amount <= uint64(WithdrawFee) {
+	fee := bridge.config.WithdrawFee.Fee(int64(amount))
+	if amount <= uint64(fee) {
 		log.Warn("Withdrawn amount is less than the withdraw fee, skip it", "amount", stellar.StroopsToDecimal(int64(amount)), "ethTx", hash)
 		return
 	}
 
+	withdrawn := int64(amount)
+	if err = bridge.config.WithdrawLimits.CheckAmount(withdrawn); err != nil {
+		return
+	}
+	if err = bridge.config.WithdrawLimits.CheckVolume(withdrawn, bridge.volume(state.VolumeWithdraw)); err != nil {
+		return
+	}
+
+	// the payment is created from here on, this counts as an attempt
+	if err = bridge.withdrawals.SetStatus(hash, state.WithdrawalSubmitted, nil); err != nil {
+		return
+	}
+
 	log.Info("Creating a withdraw tx", "ethTx", hash, "destination", we.blockchain_address, "amount", stellar.StroopsToDecimal(int64(amount)))
 
-	amount -= uint64(WithdrawFee)
+	amount -= uint64(fee)
 	//TODO: Should this adress be fetched through the wallet?
-	includeWithdrawFee := bridge.wallet.Config.StellarFeeWallet != ""
-	err = bridge.wallet.CreateAndSubmitPayment(ctx, we.blockchain_address, amount, we.receiver, we.blockHeight, hash, "", includeWithdrawFee)
+	if bridge.wallet.Config.StellarFeeWallet == "" {
+		fee = 0
+	}
+	if err = bridge.wallet.CreateAndSubmitPayment(ctx, we.blockchain_address, amount, we.receiver, we.blockHeight, hash, fee); err != nil {
+		return
+	}
+	if bridge.decisions != nil {
+		return
+	}
+	if err := bridge.volumes.Record(state.VolumeWithdraw, hash.Hex(), withdrawn); err != nil {
+		log.Error("failed to record the withdraw volume", "ethTx", hash, "err", err)
+	}
 	return
 }
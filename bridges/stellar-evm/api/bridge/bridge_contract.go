@@ -8,16 +8,20 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
 	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
 )
 
 const (
@@ -26,7 +30,13 @@ const (
 	// backOffMin is the minimum backoff time when retrying opening subscriptions
 	// TODO: wrong docstring
 	backOffMax = time.Second * 5
-	gasLimit   = 210000
+
+	// defaultLogRange is the default maximum amount of blocks requested in a single eth_getLogs call
+	defaultLogRange = 2000
+	// followPollInterval is the time to wait for new blocks when following the chain
+	followPollInterval = time.Second * 10
+	// followRetryDelay is the delay to retry a failed call while following the chain
+	followRetryDelay = time.Second * 5
 )
 
 // BridgeContract exposes a higher lvl api for specific contract bindings. In case of proxy contracts,
@@ -34,11 +44,17 @@ const (
 type BridgeContract struct {
 	networkConfig tfeth.NetworkConfiguration // Ethereum network
 	networkName   string
+	logRange      uint64 // maximum amount of blocks to request logs for at once
+	fees          FeeConfig
 
 	ethc *EthClient
 
 	tftContract *Contract
 
+	// mints tracks the nonces and transactions of pending mints, only loaded for the master bridge
+	mints    *state.MintTracker
+	mintLock sync.Mutex
+
 	// cache some stats in case they might be usefull
 	head    *types.Header // Current head header of the bridge
 	balance *big.Int      // The current balance of the bridge (note: ethers only!)
@@ -62,30 +78,52 @@ func (bridge *BridgeContract) GetContractAdress() common.Address {
 	return bridge.networkConfig.ContractAddress
 }
 
-// NewBridgeContract creates a new wrapper for an allready deployed contract
-func NewBridgeContract(ethConfig *EthConfig) (*BridgeContract, error) {
-	// load correct network config
-	networkConfig, err := tfeth.GetEthNetworkConfiguration(ethConfig.EthNetworkName)
+// NewBridgeContract creates a new wrapper for an allready deployed contract, the mint transactions are signed with signer
+func NewBridgeContract(ethConfig *EthConfig, signer keys.EthSigner) (*BridgeContract, error) {
+	networkConfig, err := ethConfig.Network()
 	if err != nil {
 		return nil, err
 	}
-	// override contract address if it's provided
 	if ethConfig.ContractAddress != "" {
 		log.Info("Overriding default token contract", "address", ethConfig.ContractAddress)
-		networkConfig.ContractAddress = common.HexToAddress(ethConfig.ContractAddress)
 	}
+	log.Info("Confirmation policy", "network", ethConfig.EthNetworkName, "confirmations", networkConfig.Confirmations)
 
-	ethc, err := NewEthClient(LightClientConfig{
-		NetworkName:   networkConfig.NetworkName,
-		EthUrl:        ethConfig.EthUrl,
-		NetworkID:     networkConfig.NetworkID,
-		EthPrivateKey: ethConfig.EthPrivateKey,
-	})
+	urls := networkConfig.RPCEndpoints
+	if ethConfig.EthUrl != "" {
+		urls = []string{ethConfig.EthUrl}
+	}
+	if len(urls) == 0 {
+		urls = []string{DefaultEthUrl}
+	}
+	var ethc *EthClient
+	for _, url := range urls {
+		ethc, err = NewEthClient(LightClientConfig{
+			NetworkName: networkConfig.NetworkName,
+			EthUrl:      url,
+			NetworkID:   networkConfig.NetworkID,
+			Signer:      signer,
+		})
+		if err == nil {
+			break
+		}
+		log.Warn("Failed to connect to the ethereum node", "url", url, "err", err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	tftContract, err := createTft20Contract(networkConfig, ethc.Client)
+	return newBridgeContract(ethConfig, networkConfig, ethc)
+}
+
+// NewBridgeContractWithBackend creates a wrapper for a contract which is deployed on the chain of the given backend,
+// the network configuration is used as is. The network name, log range and fees are taken from the EthConfig.
+func NewBridgeContractWithBackend(ethConfig *EthConfig, networkConfig tfeth.NetworkConfiguration, backend Backend, signer keys.EthSigner) (*BridgeContract, error) {
+	return newBridgeContract(ethConfig, networkConfig, NewEthClientWithBackend(backend, signer))
+}
+
+func newBridgeContract(ethConfig *EthConfig, networkConfig tfeth.NetworkConfiguration, ethc *EthClient) (*BridgeContract, error) {
+	tftContract, err := createTft20Contract(networkConfig, ethc.Backend)
 	if err != nil {
 		return nil, err
 	}
@@ -93,13 +131,15 @@ func NewBridgeContract(ethConfig *EthConfig) (*BridgeContract, error) {
 	return &BridgeContract{
 		networkName:   ethConfig.EthNetworkName,
 		networkConfig: networkConfig,
+		logRange:      ethConfig.LogRange,
+		fees:          ethConfig.Fees,
 		ethc:          ethc,
 		tftContract:   tftContract,
 	}, nil
 }
 
 // TODO: better to just pass the contractaddress instead of the entire configuration
-func createTft20Contract(networkConfig tfeth.NetworkConfiguration, client *ethclient.Client) (*Contract, error) {
+func createTft20Contract(networkConfig tfeth.NetworkConfiguration, client bind.ContractBackend) (*Contract, error) {
 	log.Info("Creating token contract binding", "address", networkConfig.ContractAddress)
 	filter, err := tokenv1.NewTokenFilterer(networkConfig.ContractAddress, client)
 	if err != nil {
@@ -135,6 +175,35 @@ func (bridge *BridgeContract) AccountAddress() (common.Address, error) {
 	return bridge.ethc.AccountAddress()
 }
 
+// Confirmations returns the confirmation policy of the network
+func (bridge *BridgeContract) Confirmations() tfeth.ConfirmationPolicy {
+	return bridge.networkConfig.Confirmations
+}
+
+// ConfirmedHeight returns the height of the highest block which is confirmed
+// according to the confirmation policy of the network, given the current head.
+func (bridge *BridgeContract) ConfirmedHeight(ctx context.Context, head *types.Header) (uint64, error) {
+	policy := bridge.networkConfig.Confirmations
+	var tag rpc.BlockNumber
+	switch policy.Mode {
+	case tfeth.ConfirmationSafe:
+		tag = rpc.SafeBlockNumber
+	case tfeth.ConfirmationFinalized:
+		tag = rpc.FinalizedBlockNumber
+	default:
+		height := head.Number.Uint64()
+		if height < policy.Blocks {
+			return 0, nil
+		}
+		return height - policy.Blocks, nil
+	}
+	header, err := bridge.ethc.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
 // EthClient returns the EthClient driving this bridge contract
 func (bridge *BridgeContract) EthClient() *EthClient {
 	return bridge.ethc
@@ -186,6 +255,7 @@ func (bridge *BridgeContract) Loop(ch chan<- *types.Header) {
 		sub, err := bridge.ethc.SubscribeNewHead(context.Background(), heads)
 		if err != nil {
 			log.Error("Failed to subscribe to head events", "err", err)
+			metrics.RPCError(metrics.ServiceEthereum)
 			return nil, err
 		}
 		return sub, nil
@@ -299,125 +369,215 @@ func (w WithdrawEvent) BlockHeight() uint64 {
 	return w.blockHeight
 }
 
-// SubscribeWithdraw subscribes to new Withdraw events on the given contract. This call blocks
-// and prints out info about any withdraw as it happened
-func (bridge *BridgeContract) SubscribeWithdraw(wc chan<- WithdrawEvent, startHeight uint64) error {
-	log.Info("Subscribing to withdraw events", "start height", startHeight)
-	sink := make(chan *tokenv1.TokenWithdraw)
-	//TODO: bug: startHeight is not taken into account but a test in another program shows it does not work anyway
-	watchOpts := &bind.WatchOpts{Context: context.TODO(), Start: nil}
-
-	sub := event.Resubscribe(backOffMax, func(ctx context.Context) (event.Subscription, error) {
-		sub, err := bridge.WatchWithdraw(watchOpts, sink, nil)
-		if err != nil {
-			log.Error("Subscribing to withdraw events failed", "err", err)
-			return nil, err
-		}
-		return sub, nil
-	})
-
-	defer sub.Unsubscribe()
-	for {
-		select {
-		case err := <-sub.Err():
-			return err
-		case withdraw := <-sink:
-			if withdraw.Raw.Removed {
-				// ignore removed events
-				continue
-			}
-			log.Debug("Noticed withdraw event", "receiver", withdraw.Receiver, "amount", withdraw.Tokens)
-			wc <- WithdrawEvent{
-				receiver:           withdraw.Receiver,
-				amount:             withdraw.Tokens,
-				txHash:             withdraw.Raw.TxHash,
-				blockHash:          withdraw.Raw.BlockHash,
-				blockHeight:        withdraw.Raw.BlockNumber,
-				blockchain_address: withdraw.BlockchainAddress,
-				network:            withdraw.Network,
-				raw:                withdraw.Raw.Data,
-			}
-		}
+// withdrawal converts the event to a withdrawal to store in the WithdrawalQueue
+func (w WithdrawEvent) withdrawal() state.Withdrawal {
+	return state.Withdrawal{
+		TxHash:            w.txHash,
+		BlockHash:         w.blockHash,
+		BlockHeight:       w.blockHeight,
+		Receiver:          w.receiver,
+		Amount:            w.amount,
+		BlockchainAddress: w.blockchain_address,
+		Network:           w.network,
+		Raw:               w.raw,
 	}
 }
 
-// WatchWithdraw is a free log subscription operation binding the contract event 0x884edad9ce6fa2440d8a54cc123490eb96d2768479d49ff9c7366125a9424364.
-//
-// Solidity: e Withdraw(receiver indexed address, tokens uint256)
-//
-// This method is copied from the generated bindings and slightly modified, so we can add logic to stay backwards compatible with the old withdraw event signature
-// TODO: is the copy still needed or can we simply use the generated bindings?
-func (bridge *BridgeContract) WatchWithdraw(opts *bind.WatchOpts, sink chan<- *tokenv1.TokenWithdraw, receiver []common.Address) (event.Subscription, error) {
+// withdrawEventFromWithdrawal restores a WithdrawEvent from the WithdrawalQueue
+func withdrawEventFromWithdrawal(w state.Withdrawal) WithdrawEvent {
+	return WithdrawEvent{
+		receiver:           w.Receiver,
+		amount:             w.Amount,
+		blockchain_address: w.BlockchainAddress,
+		network:            w.Network,
+		txHash:             w.TxHash,
+		blockHash:          w.BlockHash,
+		blockHeight:        w.BlockHeight,
+		raw:                w.Raw,
+	}
+}
 
-	var receiverRule []interface{}
-	for _, receiverItem := range receiver {
-		receiverRule = append(receiverRule, receiverItem)
+// CanonicalWithdraw checks if the given withdraw event is still part of the canonical chain.
+// If the block of the event got reorganized away but the transaction was included in another block,
+// the event as it is present in the canonical chain is returned.
+// If the event is no longer present in the canonical chain, found is false.
+func (bridge *BridgeContract) CanonicalWithdraw(ctx context.Context, we WithdrawEvent) (canonical WithdrawEvent, found bool, err error) {
+	header, err := bridge.ethc.HeaderByNumber(ctx, new(big.Int).SetUint64(we.blockHeight))
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return
+	}
+	if err == nil && header.Hash() == we.blockHash {
+		return we, true, nil
 	}
 
-	logs, sub, err := bridge.tftContract.contract.WatchLogs(opts, "Withdraw", receiverRule)
+	receipt, err := bridge.ethc.TransactionReceipt(ctx, we.txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return WithdrawEvent{}, false, nil
+	}
 	if err != nil {
-		return nil, err
+		return
 	}
-	return event.NewSubscription(func(quit <-chan struct{}) error {
-		defer sub.Unsubscribe()
-		for {
-			select {
-			case log := <-logs:
-				// New log arrived, parse the event and forward to the user
-				event := new(tokenv1.TokenWithdraw)
-				if err := bridge.tftContract.contract.UnpackLog(event, "Withdraw", log); err != nil {
-					return err
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return WithdrawEvent{}, false, nil
+	}
+	withdrawEventID := bridge.tftContract.abi.Events["Withdraw"].ID
+	for _, l := range receipt.Logs {
+		if l.Address != bridge.networkConfig.ContractAddress || len(l.Topics) == 0 || l.Topics[0] != withdrawEventID {
+			continue
+		}
+		event := new(tokenv1.TokenWithdraw)
+		if err = bridge.tftContract.contract.UnpackLog(event, "Withdraw", *l); err != nil {
+			return
+		}
+		if event.Receiver != we.receiver || event.Tokens.Cmp(we.amount) != 0 {
+			continue
+		}
+		canonical = we
+		canonical.blockHash = l.BlockHash
+		canonical.blockHeight = l.BlockNumber
+		return canonical, true, nil
+	}
+	return WithdrawEvent{}, false, nil
+}
+
+// FollowWithdraw follows the Withdraw events of the token contract, starting at the given height.
+// The blocks are requested in pages of at most logRange blocks, the page size is lowered when the
+// ethereum node refuses a page, for example because of a block range limit of the provider.
+// Every event is passed to the handler, only when all events of a page are handled successfully,
+// checkpoint is called with the height up to which all confirmed blocks are processed.
+// Blocks which are not confirmed yet are requested again on the next iteration so events moved
+// by a chain reorganization are not missed.
+// This call blocks until the context is cancelled.
+func (bridge *BridgeContract) FollowWithdraw(ctx context.Context, from uint64, handler func(WithdrawEvent) error, checkpoint func(height uint64) error) {
+	log.Info("Following withdraw events", "start height", from)
+	maxRange := bridge.logRange
+	if maxRange == 0 {
+		maxRange = defaultLogRange
+	}
+	pageSize := maxRange
+	next := from
+
+	wait := func(d time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
+
+	for ctx.Err() == nil {
+		head, err := bridge.ethc.HeaderByNumber(ctx, nil)
+		if err != nil {
+			log.Error("failed to get the chain head", "err", err)
+			metrics.RPCError(metrics.ServiceEthereum)
+			wait(followRetryDelay)
+			continue
+		}
+		confirmed, err := bridge.ConfirmedHeight(ctx, head)
+		if err != nil {
+			log.Error("failed to get the confirmed height", "err", err)
+			metrics.RPCError(metrics.ServiceEthereum)
+			wait(followRetryDelay)
+			continue
+		}
+		if next > head.Number.Uint64() {
+			wait(followPollInterval)
+			continue
+		}
+
+		start := next
+		for start <= head.Number.Uint64() && ctx.Err() == nil {
+			end := start + pageSize - 1
+			if end > head.Number.Uint64() {
+				end = head.Number.Uint64()
+			}
+			events, err := bridge.withdrawEvents(ctx, start, end)
+			if err != nil {
+				if pageSize > 1 {
+					pageSize /= 2
 				}
-				event.Raw = log
-
-				select {
-				case sink <- event:
-				case err := <-sub.Err():
-					return err
-				case <-quit:
-					return nil
+				log.Warn("failed to get withdraw logs, lowering the block range", "from", start, "to", end, "range", pageSize, "err", err)
+				metrics.RPCError(metrics.ServiceEthereum)
+				metrics.Retries.WithLabelValues(metrics.RetryWithdrawEvents).Inc()
+				if !wait(followRetryDelay) {
+					return
 				}
-			case err := <-sub.Err():
-				return err
-			case <-quit:
-				return nil
+				continue
+			}
+			if err = handleAll(events, handler); err != nil {
+				log.Error("failed to handle withdraw event, retrying", "from", start, "to", end, "err", err)
+				metrics.Retries.WithLabelValues(metrics.RetryWithdrawEvents).Inc()
+				if !wait(followRetryDelay) {
+					return
+				}
+				continue
 			}
+			// Only move the checkpoint forward over confirmed blocks
+			if start <= confirmed {
+				processed := end
+				if processed > confirmed {
+					processed = confirmed
+				}
+				if err = checkpoint(processed); err != nil {
+					log.Error("failed to save the withdraw checkpoint", "height", processed, "err", err)
+				}
+				next = processed + 1
+			}
+			start = end + 1
+			// slowly return to the maximum range after it was lowered
+			if pageSize < maxRange {
+				pageSize *= 2
+				if pageSize > maxRange {
+					pageSize = maxRange
+				}
+			}
+		}
+		if next <= head.Number.Uint64() {
+			metrics.HeadLag.Set(float64(head.Number.Uint64() - next + 1))
+		} else {
+			metrics.HeadLag.Set(0)
 		}
-	}), nil
+		wait(followPollInterval)
+	}
 }
 
-// FilterWithdraw filters Withdraw events on the given contract. This call blocks
-// and prints out info about any withdraw as it happened
-func (bridge *BridgeContract) FilterWithdraw(wc chan<- WithdrawEvent, startHeight uint64, endHeight uint64) error {
-	log.Info("Filtering to withdraw events", "start height", startHeight, "end height", endHeight)
-	filterOpts := bind.FilterOpts{
-		Start: startHeight,
-		End:   &endHeight,
+func handleAll(events []WithdrawEvent, handler func(WithdrawEvent) error) error {
+	for _, we := range events {
+		if err := handler(we); err != nil {
+			return err
+		}
 	}
-	withdrawEvent, err := bridge.tftContract.filter.FilterWithdraw(&filterOpts, nil)
+	return nil
+}
+
+// withdrawEvents returns the Withdraw events of the token contract in the given (inclusive) block range
+func (bridge *BridgeContract) withdrawEvents(ctx context.Context, from uint64, to uint64) ([]WithdrawEvent, error) {
+	log.Debug("Filtering withdraw events", "from", from, "to", to)
+	it, err := bridge.tftContract.filter.FilterWithdraw(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil)
 	if err != nil {
-		log.Error("filtering withdraw events failed", "err", err)
-		return err
+		return nil, err
 	}
+	defer it.Close()
 
-	for withdrawEvent.Next() {
-		if withdrawEvent.Event == nil {
-			break
-		}
-
-		log.Info("Withdraw event found", "event", withdrawEvent)
-		wc <- WithdrawEvent{
-			receiver:           withdrawEvent.Event.Receiver,
-			amount:             withdrawEvent.Event.Tokens,
-			txHash:             withdrawEvent.Event.Raw.TxHash,
-			blockHash:          withdrawEvent.Event.Raw.BlockHash,
-			blockHeight:        withdrawEvent.Event.Raw.BlockNumber,
-			blockchain_address: withdrawEvent.Event.BlockchainAddress,
-			network:            withdrawEvent.Event.Network,
-			raw:                withdrawEvent.Event.Raw.Data,
+	var events []WithdrawEvent
+	for it.Next() {
+		if it.Event.Raw.Removed {
+			continue
 		}
-	}
-	return nil
+		log.Info("Withdraw event found", "txHash", it.Event.Raw.TxHash, "height", it.Event.Raw.BlockNumber)
+		events = append(events, WithdrawEvent{
+			receiver:           it.Event.Receiver,
+			amount:             it.Event.Tokens,
+			txHash:             it.Event.Raw.TxHash,
+			blockHash:          it.Event.Raw.BlockHash,
+			blockHeight:        it.Event.Raw.BlockNumber,
+			blockchain_address: it.Event.BlockchainAddress,
+			network:            it.Event.Network,
+			raw:                it.Event.Raw.Data,
+		})
+	}
+	return events, it.Error()
 }
 
 func (bridge *BridgeContract) Mint(receiver tfeth.ERC20Address, amount *big.Int, txID string, signatures []tokenv1.Signature) error {
@@ -430,47 +590,6 @@ func (bridge *BridgeContract) Mint(receiver tfeth.ERC20Address, amount *big.Int,
 	return err
 }
 
-func (bridge *BridgeContract) mint(receiver tfeth.ERC20Address, amount *big.Int, txID string, signatures []tokenv1.Signature) error {
-	log.Info("Calling mint function in contract")
-	if amount == nil {
-		return errors.New("invalid amount")
-	}
-
-	accountAddress, err := bridge.ethc.AccountAddress()
-	if err != nil {
-		return err
-	}
-
-	gas, err := bridge.ethc.SuggestGasPrice(context.TODO())
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute*6)
-	defer cancel()
-	opts := &bind.TransactOpts{
-		Context: ctx, From: accountAddress,
-		Signer: bridge.getSignerFunc(),
-		Value:  nil, Nonce: nil, GasLimit: gasLimit, GasPrice: gas,
-	}
-
-	log.Info("Submitting transaction to token contract", "tokenaddress", bridge.networkConfig.ContractAddress)
-	tx, err := bridge.tftContract.transactor.MintTokens(opts, common.Address(receiver), amount, txID, signatures)
-	if err != nil {
-		return err
-	}
-
-	// Wait for the transaction to be mined
-	r, e := bind.WaitMined(ctx, bridge.ethc, tx)
-	if e != nil {
-		return e
-	}
-
-	log.Debug("Transaction mined", "tx", tx.Hash().Hex(), "block", r.BlockNumber, "gas", r.GasUsed, "status", r.Status)
-
-	return nil
-}
-
 func (bridge *BridgeContract) IsMintTxID(txID string) (bool, error) {
 	res, err := bridge.isMintTxID(txID)
 	for IsNoPeerErr(err) {
@@ -506,20 +625,6 @@ func (bridge *BridgeContract) isMintTxID(txID string) (bool, error) {
 	return bridge.tftContract.caller.IsMintID(opts, txID)
 }
 
-func (bridge *BridgeContract) getSignerFunc() bind.SignerFn {
-	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-		accountAddress, err := bridge.ethc.AccountAddress()
-		if err != nil {
-			return nil, err
-		}
-		if address != accountAddress {
-			return nil, errors.New("not authorized to sign this account")
-		}
-		networkID := int64(bridge.networkConfig.NetworkID)
-		return bridge.ethc.SignTx(tx, big.NewInt(networkID))
-	}
-}
-
 func (bridge *BridgeContract) CreateTokenSignature(receiver common.Address, amount int64, txid string) (tokenv1.Signature, error) {
 	bytes, err := AbiEncodeArgs(receiver, big.NewInt(amount), txid)
 	if err != nil {
@@ -549,7 +654,7 @@ func (bridge *BridgeContract) CreateTokenSignature(receiver common.Address, amou
 
 // bindTTFT20 binds a generic wrapper to an already deployed contract.
 //
-// This method is copied from the generated bindings as a convenient way to get a *bind.Contract, as this is needed to implement the WatchWithdraw function ourselves
+// This method is copied from the generated bindings as a convenient way to get a *bind.Contract, as this is needed to unpack logs of receipts ourselves
 func bindTTFT20(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, abi.ABI, error) {
 	parsed, err := abi.JSON(strings.NewReader(tokenv1.TokenABI))
 	if err != nil {
@@ -0,0 +1,131 @@
+package bridge
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+)
+
+func TestBridgeContractMint(t *testing.T) {
+	masterKey, cosignerKey, absentKey := newKey(t), newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	cosigner := crypto.PubkeyToAddress(cosignerKey.PublicKey)
+	absent := crypto.PubkeyToAddress(absentKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{cosigner, absent, master}, 2, master)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	cosignerContract := newTestBridgeContract(t, chain, cosignerKey)
+
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	amount := big.NewInt(50_0000000)
+	masterSignature, err := contract.CreateTokenSignature(receiver, amount.Int64(), "deposit")
+	require.NoError(t, err)
+	assert.Contains(t, []uint8{27, 28}, masterSignature.V)
+	cosignerSignature, err := cosignerContract.CreateTokenSignature(receiver, amount.Int64(), "deposit")
+	require.NoError(t, err)
+
+	signers, err := contract.GetSigners()
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{cosigner, absent, master}, signers)
+	required, err := contract.GetRequiresSignatureCount()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), required.Int64())
+
+	// the responses arrive in any order, the contract wants them in the order of its signers
+	ordered := orderSignatures(signers, []EthSignResponse{
+		{Who: master, Signature: masterSignature},
+		{Who: cosigner, Signature: cosignerSignature},
+	})
+	assert.Equal(t, []tokenv1.Signature{cosignerSignature, {}, masterSignature}, ordered)
+
+	swapped := []tokenv1.Signature{masterSignature, {}, cosignerSignature}
+	assertReverted(t, contract.Mint(tfeth.ERC20Address(receiver), amount, "deposit", swapped), "InvalidSignature")
+	assertReverted(t, contract.Mint(tfeth.ERC20Address(receiver), amount, "deposit", []tokenv1.Signature{{}, {}, masterSignature}), "InsufficientSignatures")
+
+	known, err := contract.IsMintTxID("deposit")
+	require.NoError(t, err)
+	assert.False(t, known)
+
+	require.NoError(t, contract.Mint(tfeth.ERC20Address(receiver), amount, "deposit", ordered))
+	known, err = contract.IsMintTxID("deposit")
+	require.NoError(t, err)
+	assert.True(t, known)
+	balance, err := contract.tftContract.caller.BalanceOf(&bind.CallOpts{Context: context.Background()}, receiver)
+	require.NoError(t, err)
+	assert.Equal(t, amount, balance)
+	_, found := contract.mints.Get("deposit")
+	assert.False(t, found, "the pending mint should be removed once it is mined")
+
+	assert.ErrorContains(t, contract.Mint(tfeth.ERC20Address(receiver), amount, "deposit", ordered), "already known")
+}
+
+func TestBridgeContractWithdrawEvents(t *testing.T) {
+	masterKey, userKey := newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	user := crypto.PubkeyToAddress(userKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master, user)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	chain.fund(t, user, big.NewInt(100_0000000))
+
+	first := chain.sendToken(t, userKey, "withdraw", big.NewInt(10_0000000), "GDESTINATION", BridgeNetwork)
+	second := chain.sendToken(t, userKey, "withdraw", big.NewInt(20_0000000), "0xsomewhere", "solana")
+	head, err := chain.BlockNumber(context.Background())
+	require.NoError(t, err)
+
+	events, err := contract.withdrawEvents(context.Background(), 0, head)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, first.Hash(), events[0].TxHash())
+	assert.Equal(t, user, events[0].Receiver())
+	assert.Equal(t, int64(10_0000000), events[0].Amount().Int64())
+	assert.Equal(t, "GDESTINATION", events[0].BlockchainAddress())
+	assert.Equal(t, BridgeNetwork, events[0].Network())
+	assert.Equal(t, second.Hash(), events[1].TxHash())
+	assert.Equal(t, "solana", events[1].Network())
+
+	events, err = contract.withdrawEvents(context.Background(), events[1].BlockHeight(), head)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	canonical, found, err := contract.CanonicalWithdraw(context.Background(), events[0])
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, events[0], canonical)
+
+	// an event of which the block is no longer known is looked up through the receipt
+	moved := events[0]
+	moved.blockHash = common.Hash{1}
+	canonical, found, err = contract.CanonicalWithdraw(context.Background(), moved)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, events[0].BlockHash(), canonical.BlockHash())
+
+	gone := events[0]
+	gone.blockHash, gone.txHash = common.Hash{1}, common.Hash{2}
+	_, found, err = contract.CanonicalWithdraw(context.Background(), gone)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestEthConfigNetwork(t *testing.T) {
+	cfg := EthConfig{EthNetworkName: "eth-mainnet", ContractAddress: "0x2000000000000000000000000000000000000002", Confirmations: "finalized"}
+	network, err := cfg.Network()
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(cfg.ContractAddress), network.ContractAddress)
+	assert.Equal(t, tfeth.ConfirmationFinalized, network.Confirmations.Mode)
+
+	_, err = (&EthConfig{EthNetworkName: "unknown"}).Network()
+	assert.Error(t, err)
+	_, err = (&EthConfig{EthNetworkName: "eth-mainnet", ContractAddress: "not an address"}).Network()
+	assert.Error(t, err)
+	_, err = (&EthConfig{EthNetworkName: "eth-mainnet", Confirmations: "soon"}).Network()
+	assert.Error(t, err)
+}
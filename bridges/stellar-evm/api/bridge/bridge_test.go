@@ -0,0 +1,175 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/faults"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/limits"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
+)
+
+// newTestBridge creates a synced master bridge without cosigners for the contract,
+// the stellar wallet is not connected to a horizon server.
+func newTestBridge(t *testing.T, contract *BridgeContract, config BridgeConfig) *Bridge {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	wallet, err := stellar.NewWallet(&stellar.StellarConfig{
+		StellarNetwork:   "testnet",
+		StellarFeeWallet: keypair.MustRandom().Address(),
+	}, keys.NewLocalEd25519Signer(edKey), config.DepositFee, config.WithdrawFee, nil)
+	require.NoError(t, err)
+
+	config.PersistencyFile = filepath.Join(t.TempDir(), "node.json")
+	config.Follower = true
+	bridge, err := NewBridge(context.Background(), wallet, contract, &config, nil, nil, pause.NewSwitch(""))
+	require.NoError(t, err)
+	// created as follower to skip connecting to the cosigners, it acts as master
+	config.Follower = false
+	bridge.signersClient = &SignersClient{lastSeen: make(map[peer.ID]time.Time)}
+	bridge.synced = true
+	return bridge
+}
+
+func TestBridgeMint(t *testing.T) {
+	masterKey, otherKey := newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{other, master}, 1, master)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	bridge := newTestBridge(t, contract, BridgeConfig{DepositFee: fees.NewFlat(1_0000000)})
+
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	require.NoError(t, bridge.mint(tfeth.ERC20Address(receiver), big.NewInt(5_0000000), "deposit"))
+	balance, err := contract.tftContract.caller.BalanceOf(&bind.CallOpts{Context: context.Background()}, receiver)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4_0000000), balance.Int64(), "the deposit fee should be subtracted")
+
+	nonce, err := chain.NonceAt(context.Background(), master, nil)
+	require.NoError(t, err)
+	require.NoError(t, bridge.mint(tfeth.ERC20Address(receiver), big.NewInt(5_0000000), "deposit"))
+	after, err := chain.NonceAt(context.Background(), master, nil)
+	require.NoError(t, err)
+	assert.Equal(t, nonce, after, "an already minted txid should not be sent again")
+
+	// a mint which was made but not recorded in the volume, for example after a crash, is counted when it is skipped
+	restarted := newTestBridge(t, contract, BridgeConfig{DepositFee: fees.NewFlat(1_0000000)})
+	require.NoError(t, restarted.mint(tfeth.ERC20Address(receiver), big.NewInt(5_0000000), "deposit"))
+	assert.Equal(t, limits.Volume{Hour: 5_0000000, Day: 5_0000000}, restarted.volume(state.VolumeMint))
+	require.NoError(t, bridge.mint(tfeth.ERC20Address(receiver), big.NewInt(5_0000000), "deposit"))
+	assert.Equal(t, limits.Volume{Hour: 5_0000000, Day: 5_0000000}, bridge.volume(state.VolumeMint), "a mint is not counted twice")
+
+	assert.ErrorIs(t, bridge.mint(tfeth.ERC20Address(receiver), big.NewInt(1_0000000), "small"), faults.ErrInsufficientDepositAmount)
+	known, err := contract.IsMintTxID("small")
+	require.NoError(t, err)
+	assert.False(t, known)
+}
+
+func TestBridgeMintDryRun(t *testing.T) {
+	masterKey := newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	bridge := newTestBridge(t, contract, BridgeConfig{MintLimits: limits.Limits{Daily: 10_0000000}})
+	bridge.decisions = state.NewDecisionLog(state.DecisionsFile(bridge.config.PersistencyFile))
+
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	require.NoError(t, bridge.mint(tfeth.ERC20Address(receiver), big.NewInt(8_0000000), "deposit"))
+	decisions, err := bridge.decisions.Decisions()
+	require.NoError(t, err)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, limits.Volume{}, bridge.volume(state.VolumeMint), "a dry run does not count against the volume caps")
+	known, err := contract.IsMintTxID("deposit")
+	require.NoError(t, err)
+	assert.False(t, known)
+}
+
+func TestBridgeWithdraw(t *testing.T) {
+	masterKey, userKey := newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	user := crypto.PubkeyToAddress(userKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master, user)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	bridge := newTestBridge(t, contract, BridgeConfig{
+		WithdrawFee:    fees.NewFlat(1_0000000),
+		WithdrawLimits: limits.Limits{Min: 5_0000000, Max: 100_0000000},
+	})
+	require.NoError(t, bridge.mint(tfeth.ERC20Address(user), big.NewInt(1000_0000000), "deposit"))
+
+	toFeeWallet := chain.sendToken(t, userKey, "withdraw", big.NewInt(10_0000000), bridge.wallet.Config.StellarFeeWallet, BridgeNetwork)
+	onlyFee := chain.sendToken(t, userKey, "withdraw", big.NewInt(1_0000000), keypair.MustRandom().Address(), BridgeNetwork)
+	aboveLimit := chain.sendToken(t, userKey, "withdraw", big.NewInt(200_0000000), keypair.MustRandom().Address(), BridgeNetwork)
+	belowLimit := chain.sendToken(t, userKey, "withdraw", big.NewInt(3_0000000), keypair.MustRandom().Address(), BridgeNetwork)
+	otherNetwork := chain.sendToken(t, userKey, "withdraw", big.NewInt(10_0000000), "somewhere", "solana")
+	head, err := chain.BlockNumber(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var checkpoint uint64
+	bridge.bridgeContract.FollowWithdraw(ctx, 0, bridge.rememberWithdrawal, func(height uint64) error {
+		checkpoint = height
+		if height >= head {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, head, checkpoint)
+	_, found := bridge.withdrawals.Get(otherNetwork.Hash())
+	assert.False(t, found, "withdrawals to other networks should be ignored")
+	assert.Len(t, bridge.withdrawals.Open(), 4)
+
+	bridge.processWithdrawals(context.Background(), head)
+	for tx, status := range map[common.Hash]state.WithdrawalStatus{
+		toFeeWallet.Hash(): state.WithdrawalDone,
+		onlyFee.Hash():     state.WithdrawalDone,
+		aboveLimit.Hash():  state.WithdrawalHeld,
+		belowLimit.Hash():  state.WithdrawalRejected,
+	} {
+		w, found := bridge.withdrawals.Get(tx)
+		require.True(t, found)
+		assert.Equal(t, status, w.Status, tx.Hex())
+	}
+	assert.Len(t, bridge.withdrawals.Open(), 1, "only the held withdrawal is retried")
+}
+
+func TestAdminPauseNeedsToken(t *testing.T) {
+	bridge := &Bridge{config: &BridgeConfig{}, pause: pause.NewSwitch("")}
+	pauseWith := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/pause", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		bridge.AdminHandler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusForbidden, pauseWith("secret"), "pausing is refused without an admin token")
+
+	bridge.config.AdminToken = "secret"
+	assert.Equal(t, http.StatusUnauthorized, pauseWith(""))
+	assert.Equal(t, http.StatusUnauthorized, pauseWith("wrong"))
+	assert.False(t, bridge.pause.Paused())
+	assert.Equal(t, http.StatusOK, pauseWith("secret"))
+	assert.True(t, bridge.pause.Paused())
+}
@@ -2,13 +2,14 @@ package bridge
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/light"
 	"github.com/ethereum/go-ethereum/log"
@@ -18,31 +19,87 @@ import (
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+)
+
+const (
+	// DefaultEthUrl is used if no url is given and the network has no rpc endpoints
+	DefaultEthUrl = "ws://localhost:8551"
+	// chainIDTimeout bounds the chain id check when connecting to a node
+	chainIDTimeout = 30 * time.Second
 )
 
+// Backend is the connection to the Ethereum chain used by the bridge.
+// It is implemented by *ethclient.Client, tests use a simulated chain instead.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.ChainStateReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error)
+}
+
 // EthClient creates a light client that can be used to interact with the Ethereum network,
 type EthClient struct {
-	*ethclient.Client // Client connection to the Ethereum chain
-	privateKey        *ecdsa.PrivateKey
-	address           common.Address
+	Backend // Client connection to the Ethereum chain
+	signer  keys.EthSigner
+	address common.Address
 }
 
 // EthConfig combines all configuration required for creating and configuring a EthClient.
 type EthConfig struct {
-	EthNetworkName  string
-	EthUrl          string
+	EthNetworkName string
+	// NetworksFile is a yaml file with network definitions next to the built-in ones, see eth.LoadNetworks
+	NetworksFile string
+	// EthUrl is the url of the ethereum node, the rpc endpoints of the network are used if it is empty
+	EthUrl string
+	// EthPrivateKey is the key specification of the ethereum account, see the keys package
 	EthPrivateKey   string
 	ContractAddress string
+	// Confirmations overrides the confirmation policy of the network if not empty
+	Confirmations string
+	// LogRange is the maximum amount of blocks to request logs for in a single call
+	LogRange uint64
+	// Fees limits the fees of the mint transactions
+	Fees FeeConfig
+}
+
+// Network returns the configuration of the ethereum network with the contract address and confirmation policy of the config,
+// it fails if the network is not known or the overrides are invalid.
+func (c *EthConfig) Network() (tfeth.NetworkConfiguration, error) {
+	networks, err := tfeth.LoadNetworks(c.NetworksFile)
+	if err != nil {
+		return tfeth.NetworkConfiguration{}, err
+	}
+	networkConfig, err := networks.Get(c.EthNetworkName)
+	if err != nil {
+		return networkConfig, err
+	}
+	if c.ContractAddress != "" {
+		if !common.IsHexAddress(c.ContractAddress) {
+			return networkConfig, fmt.Errorf("invalid token contract address %s", c.ContractAddress)
+		}
+		networkConfig.ContractAddress = common.HexToAddress(c.ContractAddress)
+	}
+	if c.Confirmations != "" {
+		networkConfig.Confirmations, err = tfeth.ParseConfirmationPolicy(c.Confirmations)
+	}
+	return networkConfig, err
 }
 
 // LightClientConfig combines all configuration required for
 // creating and configuring a EthClient.
 type LightClientConfig struct {
-	NetworkName   string
-	EthUrl        string
-	NetworkID     uint64
-	EthPrivateKey string
-	GenesisBlock  *core.Genesis
+	NetworkName  string
+	EthUrl       string
+	NetworkID    uint64
+	Signer       keys.EthSigner
+	GenesisBlock *core.Genesis
 }
 
 // TODO: better move this to eth package
@@ -62,8 +119,8 @@ func (lccfg *LightClientConfig) validate() error {
 	if lccfg.NetworkID == 0 {
 		return errors.New("invalid LightClientConfig: no network ID defined")
 	}
-	if lccfg.EthPrivateKey == "" {
-		return errors.New("invalid LightClientConfig: no private key defined")
+	if lccfg.Signer == nil {
+		return errors.New("invalid LightClientConfig: no signer defined")
 	}
 	return nil
 }
@@ -77,40 +134,52 @@ func NewEthClient(lccfg LightClientConfig) (*EthClient, error) {
 		return nil, err
 	}
 
-	log.Debug("private key", "k", strings.Trim(lccfg.EthPrivateKey, "0x"))
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(lccfg.EthPrivateKey, "0x"))
-	if err != nil {
-		return nil, err
-	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-
-	addr := crypto.PubkeyToAddress(*publicKeyECDSA)
+	addr := lccfg.Signer.Address()
 	log.Debug("eth client loaded with address", "addr", addr.String())
 
 	cl, err := ethclient.Dial(lccfg.EthUrl)
 	if err != nil {
 		return nil, err
 	}
+
+	// make sure the node is on the chain the transactions are signed for
+	ctx, cancel := context.WithTimeout(context.Background(), chainIDTimeout)
+	defer cancel()
+	chainID, err := cl.ChainID(ctx)
+	if err != nil {
+		cl.Close()
+		return nil, fmt.Errorf("failed to get the chain id from %s: %w", lccfg.EthUrl, err)
+	}
+	if chainID.Cmp(new(big.Int).SetUint64(lccfg.NetworkID)) != 0 {
+		cl.Close()
+		return nil, fmt.Errorf("the ethereum node at %s is on chain %s, network %s has chain id %d", lccfg.EthUrl, chainID, lccfg.NetworkName, lccfg.NetworkID)
+	}
 	// return created light client
+	return NewEthClientWithBackend(cl, lccfg.Signer), nil
+}
+
+// NewEthClientWithBackend creates a client for an existing connection to the chain,
+// the transactions are signed with the given signer.
+func NewEthClientWithBackend(backend Backend, signer keys.EthSigner) *EthClient {
 	return &EthClient{
-		Client:     cl,
-		privateKey: privateKey,
-		address:    addr,
-	}, nil
+		Backend: backend,
+		signer:  signer,
+		address: signer.Address(),
+	}
 }
 
-func (c *EthClient) GetAddress() (common.Address, error) {
-	publicKey := c.privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return common.Address{}, errors.New("error casting public key to ECDSA")
+// Close closes the connection to the chain if the backend supports it
+func (c *EthClient) Close() {
+	switch backend := c.Backend.(type) {
+	case interface{ Close() }:
+		backend.Close()
+	case interface{ Close() error }:
+		backend.Close()
 	}
+}
 
-	return crypto.PubkeyToAddress(*publicKeyECDSA), nil
+func (c *EthClient) GetAddress() (common.Address, error) {
+	return c.signer.Address(), nil
 }
 
 var (
@@ -126,13 +195,18 @@ func (c *EthClient) AccountBalanceAt(ctx context.Context, blockNumber *big.Int)
 
 // SignTx signs a given traction with the loaded account, returning the signed transaction and no error on success.
 func (c *EthClient) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
-	return types.SignTx(tx, types.NewEIP155Signer(chainID), c.privateKey)
+	signer := types.LatestSignerForChainID(chainID)
+	signature, err := c.signer.SignHash(signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, signature)
 }
 
 // Sign signs the given data and prepends the Ethereum message prefix.
 func (c *EthClient) Sign(data []byte) ([]byte, error) {
 	msg := fmt.Sprintf("%s%s", EthMessagePrefix, data)
-	return crypto.Sign(crypto.Keccak256Hash([]byte(msg)).Bytes(), c.privateKey)
+	return c.signer.SignHash(crypto.Keccak256Hash([]byte(msg)).Bytes())
 }
 
 // AbiEncodeArgs encodes the arguments for the mint function
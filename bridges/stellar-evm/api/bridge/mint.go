@@ -0,0 +1,430 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+)
+
+const (
+	// gasLimitBuffer is the percentage added to the estimated gas of a mint transaction
+	gasLimitBuffer = 20
+	// minFeeBumpPercent is the minimal fee increase ethereum nodes accept for a replacement transaction
+	minFeeBumpPercent = 10
+	// mintTimeout is the time a single mint call waits for the transaction to be mined
+	mintTimeout = time.Minute * 6
+	// receiptPollInterval is the interval to check if a pending mint transaction is mined
+	receiptPollInterval = time.Second * 5
+	// defaultMintReplaceAfter is the time after which a pending mint transaction is replaced with higher fees
+	defaultMintReplaceAfter = time.Minute * 2
+)
+
+// errMintNotMined is returned when a mint transaction is still pending after the mintTimeout,
+// the next mint call for the same transaction id keeps waiting for it.
+var errMintNotMined = errors.New("mint transaction is not mined yet")
+
+// FeeConfig limits the fees paid for the transactions sent by the bridge
+type FeeConfig struct {
+	// MaxFeePerGas is the maximum fee per gas in gwei, 0 means no limit
+	MaxFeePerGas uint64
+	// MaxPriorityFeePerGas is the maximum priority fee per gas in gwei, 0 means no limit
+	MaxPriorityFeePerGas uint64
+	// BumpPercent is the percentage the fees are increased with when replacing a pending transaction
+	BumpPercent uint64
+	// ReplaceAfter is the time after which a pending transaction is replaced with higher fees
+	ReplaceAfter time.Duration
+}
+
+func (c FeeConfig) maxFeePerGas() *big.Int {
+	return gweiToWei(c.MaxFeePerGas)
+}
+
+func (c FeeConfig) maxPriorityFeePerGas() *big.Int {
+	return gweiToWei(c.MaxPriorityFeePerGas)
+}
+
+func (c FeeConfig) replaceAfter() time.Duration {
+	if c.ReplaceAfter == 0 {
+		return defaultMintReplaceAfter
+	}
+	return c.ReplaceAfter
+}
+
+// bump increases a fee for a replacement transaction
+func (c FeeConfig) bump(fee *big.Int) *big.Int {
+	percent := c.BumpPercent
+	if percent < minFeeBumpPercent {
+		percent = minFeeBumpPercent
+	}
+	return minimalBump(fee, percent)
+}
+
+func gweiToWei(gwei uint64) *big.Int {
+	if gwei == 0 {
+		return nil
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(gwei), big.NewInt(params.GWei))
+}
+
+// minimalBump returns fee increased with the given percentage, rounded up
+func minimalBump(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// capFee returns fee, lowered to limit if limit is not nil
+func capFee(fee *big.Int, limit *big.Int) *big.Int {
+	if limit != nil && fee.Cmp(limit) > 0 {
+		return new(big.Int).Set(limit)
+	}
+	return fee
+}
+
+func maxFee(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// txFees are the fees of a transaction, tipCap is nil for a legacy transaction.
+// For a legacy transaction feeCap is the gas price.
+type txFees struct {
+	feeCap *big.Int
+	tipCap *big.Int
+}
+
+func (f txFees) dynamic() bool {
+	return f.tipCap != nil
+}
+
+func feesOf(tx *types.Transaction) txFees {
+	if tx.Type() == types.LegacyTxType {
+		return txFees{feeCap: tx.GasPrice()}
+	}
+	return txFees{feeCap: tx.GasFeeCap(), tipCap: tx.GasTipCap()}
+}
+
+func (bridge *BridgeContract) mint(receiver tfeth.ERC20Address, amount *big.Int, txID string, signatures []tokenv1.Signature) error {
+	log.Info("Calling mint function in contract")
+	if amount == nil {
+		return errors.New("invalid amount")
+	}
+	if bridge.mints == nil {
+		return errors.New("no mint tracker loaded")
+	}
+	// Only one mint is processed at a time so nonces are handed out in order
+	bridge.mintLock.Lock()
+	defer bridge.mintLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), mintTimeout)
+	defer cancel()
+
+	pending, found := bridge.mints.Get(txID)
+	if found {
+		// A transaction for this mint is already sent, do not create a new one with another nonce
+		tx, err := lastTransaction(pending)
+		if err != nil {
+			return err
+		}
+		log.Info("Resuming pending mint transaction", "txID", txID, "tx", tx.Hash(), "nonce", pending.Nonce)
+		if err = bridge.broadcast(ctx, tx); err != nil {
+			log.Warn("failed to rebroadcast pending mint transaction", "tx", tx.Hash(), "err", err)
+			metrics.RPCError(metrics.ServiceEthereum)
+		}
+	} else {
+		if err := bridge.sendMint(ctx, receiver, amount, txID, signatures); err != nil {
+			return err
+		}
+	}
+
+	return bridge.waitMint(ctx, txID)
+}
+
+// sendMint creates, stores and broadcasts a new mint transaction
+func (bridge *BridgeContract) sendMint(ctx context.Context, receiver tfeth.ERC20Address, amount *big.Int, txID string, signatures []tokenv1.Signature) error {
+	accountAddress, err := bridge.ethc.AccountAddress()
+	if err != nil {
+		return err
+	}
+	data, err := bridge.tftContract.abi.Pack("mintTokens", common.Address(receiver), amount, txID, signatures)
+	if err != nil {
+		return err
+	}
+	to := bridge.networkConfig.ContractAddress
+	gas, err := bridge.ethc.EstimateGas(ctx, ethereum.CallMsg{From: accountAddress, To: &to, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to estimate gas for mint: %w", err)
+	}
+	gas += gas * gasLimitBuffer / 100
+
+	fees, err := bridge.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+
+	chainNonce, err := bridge.ethc.PendingNonceAt(ctx, accountAddress)
+	if err != nil {
+		return err
+	}
+	nonce := bridge.mints.NextNonce(chainNonce)
+
+	tx, err := bridge.signMintTx(txID, nonce, gas, data, fees)
+	if err != nil {
+		return err
+	}
+	log.Info("Submitting transaction to token contract", "tokenaddress", bridge.networkConfig.ContractAddress, "tx", tx.Hash(), "nonce", nonce, "gas", gas, "feeCap", fees.feeCap, "tipCap", fees.tipCap)
+	return bridge.broadcast(ctx, tx)
+}
+
+// validateMint packs the mint call without sending it, if the signatures are complete
+// the gas is estimated as well so the contract checks them
+func (bridge *BridgeContract) validateMint(receiver tfeth.ERC20Address, amount *big.Int, txID string, signatures []tokenv1.Signature, complete bool) ([]byte, error) {
+	data, err := bridge.tftContract.abi.Pack("mintTokens", common.Address(receiver), amount, txID, signatures)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		return data, nil
+	}
+	accountAddress, err := bridge.ethc.AccountAddress()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mintTimeout)
+	defer cancel()
+	to := bridge.networkConfig.ContractAddress
+	if _, err = bridge.ethc.EstimateGas(ctx, ethereum.CallMsg{From: accountAddress, To: &to, Data: data}); err != nil {
+		return nil, fmt.Errorf("failed to estimate gas for mint: %w", err)
+	}
+	return data, nil
+}
+
+// signMintTx signs a mint transaction and stores it in the mint tracker, it is not broadcasted yet
+func (bridge *BridgeContract) signMintTx(txID string, nonce uint64, gas uint64, data []byte, fees txFees) (*types.Transaction, error) {
+	to := bridge.networkConfig.ContractAddress
+	var unsigned *types.Transaction
+	if fees.dynamic() {
+		unsigned = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   new(big.Int).SetUint64(bridge.networkConfig.NetworkID),
+			Nonce:     nonce,
+			GasTipCap: fees.tipCap,
+			GasFeeCap: fees.feeCap,
+			Gas:       gas,
+			To:        &to,
+			Data:      data,
+		})
+	} else {
+		unsigned = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.feeCap,
+			Gas:      gas,
+			To:       &to,
+			Data:     data,
+		})
+	}
+	tx, err := bridge.ethc.SignTx(unsigned, new(big.Int).SetUint64(bridge.networkConfig.NetworkID))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	// Store the transaction before it is broadcasted so it is never lost
+	if err = bridge.mints.AddTransaction(txID, nonce, raw); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// suggestFees returns the fees for a new transaction within the configured limits.
+// Legacy fees are used if the network does not support EIP-1559.
+func (bridge *BridgeContract) suggestFees(ctx context.Context) (txFees, error) {
+	head, err := bridge.ethc.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return txFees{}, err
+	}
+	if head.BaseFee == nil {
+		price, err := bridge.ethc.SuggestGasPrice(ctx)
+		if err != nil {
+			return txFees{}, err
+		}
+		return txFees{feeCap: capFee(price, bridge.fees.maxFeePerGas())}, nil
+	}
+
+	tip, err := bridge.ethc.SuggestGasTipCap(ctx)
+	if err != nil {
+		return txFees{}, err
+	}
+	tip = capFee(tip, bridge.fees.maxPriorityFeePerGas())
+	// leave room for the base fee to double before the transaction is no longer includable
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	feeCap = capFee(feeCap, bridge.fees.maxFeePerGas())
+	if feeCap.Cmp(head.BaseFee) < 0 {
+		log.Warn("Base fee is above the maximum fee per gas, the transaction will not be mined until it drops", "baseFee", head.BaseFee, "maxFee", feeCap)
+	}
+	tip = capFee(tip, feeCap)
+	return txFees{feeCap: feeCap, tipCap: tip}, nil
+}
+
+// waitMint waits until one of the transactions of a pending mint is mined.
+// The latest transaction is replaced with one with higher fees if it takes too long.
+func (bridge *BridgeContract) waitMint(ctx context.Context, txID string) error {
+	var lastReplace time.Time
+	for {
+		pending, found := bridge.mints.Get(txID)
+		if !found {
+			return fmt.Errorf("no pending mint for %s", txID)
+		}
+		receipt, err := bridge.mintReceipt(ctx, pending)
+		if err != nil {
+			return err
+		}
+		if receipt != nil {
+			if err = bridge.mints.Remove(txID); err != nil {
+				log.Error("failed to remove the pending mint", "txID", txID, "err", err)
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return fmt.Errorf("mint transaction %s failed", receipt.TxHash)
+			}
+			log.Debug("Transaction mined", "tx", receipt.TxHash.Hex(), "block", receipt.BlockNumber, "gas", receipt.GasUsed, "status", receipt.Status)
+			return nil
+		}
+
+		if lastReplace.Before(pending.UpdatedAt) {
+			lastReplace = pending.UpdatedAt
+		}
+		if time.Since(lastReplace) >= bridge.fees.replaceAfter() {
+			lastReplace = time.Now()
+			if err = bridge.replaceMint(ctx, pending); err != nil {
+				log.Warn("failed to replace pending mint transaction", "txID", txID, "err", err)
+				metrics.RPCError(metrics.ServiceEthereum)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: txID %s, nonce %d", errMintNotMined, txID, pending.Nonce)
+		case <-time.After(receiptPollInterval):
+		}
+	}
+}
+
+// mintReceipt returns the receipt of the transaction of a pending mint that got mined, if any.
+func (bridge *BridgeContract) mintReceipt(ctx context.Context, pending state.PendingMint) (*types.Receipt, error) {
+	for _, raw := range pending.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		receipt, err := bridge.ethc.TransactionReceipt(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return receipt, nil
+	}
+
+	accountAddress, err := bridge.ethc.AccountAddress()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := bridge.ethc.NonceAt(ctx, accountAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	if nonce > pending.Nonce {
+		// The nonce is used but none of our transactions is mined, the next call creates a new transaction
+		if err = bridge.mints.Remove(pending.TxID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("nonce %d of the pending mint for %s is used by another transaction", pending.Nonce, pending.TxID)
+	}
+	return nil, nil
+}
+
+// replaceMint sends the latest transaction of a pending mint again with higher fees and the same nonce
+func (bridge *BridgeContract) replaceMint(ctx context.Context, pending state.PendingMint) error {
+	latest, err := lastTransaction(pending)
+	if err != nil {
+		return err
+	}
+	suggested, err := bridge.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+	old := feesOf(latest)
+	// nodes only accept a replacement with a minimal increase of all fees
+	required := txFees{feeCap: minimalBump(old.feeCap, minFeeBumpPercent)}
+	fees := txFees{feeCap: capFee(maxFee(bridge.fees.bump(old.feeCap), suggested.feeCap), bridge.fees.maxFeePerGas())}
+	if old.dynamic() {
+		if !suggested.dynamic() {
+			return errors.New("network no longer supports dynamic fee transactions")
+		}
+		required.tipCap = minimalBump(old.tipCap, minFeeBumpPercent)
+		fees.tipCap = capFee(maxFee(bridge.fees.bump(old.tipCap), suggested.tipCap), bridge.fees.maxPriorityFeePerGas())
+		fees.tipCap = capFee(fees.tipCap, fees.feeCap)
+		if fees.tipCap.Cmp(required.tipCap) < 0 {
+			return fmt.Errorf("maximum priority fee per gas reached, can not replace transaction %s", latest.Hash())
+		}
+	}
+	if fees.feeCap.Cmp(required.feeCap) < 0 {
+		return fmt.Errorf("maximum fee per gas reached, can not replace transaction %s", latest.Hash())
+	}
+
+	tx, err := bridge.signMintTx(pending.TxID, pending.Nonce, latest.Gas(), latest.Data(), fees)
+	if err != nil {
+		return err
+	}
+	log.Info("Replacing pending mint transaction", "txID", pending.TxID, "old", latest.Hash(), "new", tx.Hash(), "nonce", pending.Nonce, "feeCap", fees.feeCap, "tipCap", fees.tipCap)
+	return bridge.broadcast(ctx, tx)
+}
+
+// forgetMint removes the pending mint transactions for a mint which is known to the contract
+func (bridge *BridgeContract) forgetMint(txID string) {
+	if bridge.mints == nil {
+		return
+	}
+	if err := bridge.mints.Remove(txID); err != nil {
+		log.Error("failed to remove the pending mint", "txID", txID, "err", err)
+	}
+}
+
+// broadcast sends a signed transaction, a transaction which is already known by the node is not an error
+func (bridge *BridgeContract) broadcast(ctx context.Context, tx *types.Transaction) error {
+	err := bridge.ethc.SendTransaction(ctx, tx)
+	if err != nil && (strings.Contains(err.Error(), "already known") || strings.Contains(err.Error(), "nonce too low")) {
+		// the transaction or one of its replacements is in the pool or mined already
+		return nil
+	}
+	return err
+}
+
+func lastTransaction(pending state.PendingMint) (*types.Transaction, error) {
+	if len(pending.Transactions) == 0 {
+		return nil, fmt.Errorf("pending mint for %s has no transactions", pending.TxID)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(pending.Transactions[len(pending.Transactions)-1]); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,123 @@
+package bridge
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
+	tfeth "github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/reconcile"
+)
+
+// MintKey returns the id a Mint event carries for a Stellar deposit,
+// the txid of the event is indexed so it holds the hash of the deposit hash.
+func MintKey(depositHash string) string {
+	return crypto.Keccak256Hash([]byte(depositHash)).Hex()
+}
+
+// ContractTransfers returns the Mint events and the Withdraw events to Stellar of the token contract
+// in the given (inclusive) block range. If to is 0, the events up to the latest block are returned.
+// No ethereum key is needed, only the network, url and contract settings of the EthConfig are used.
+func ContractTransfers(ctx context.Context, ethConfig *EthConfig, from uint64, to uint64) (mints []reconcile.Mint, withdrawals []reconcile.Withdrawal, err error) {
+	networks, err := tfeth.LoadNetworks(ethConfig.NetworksFile)
+	if err != nil {
+		return
+	}
+	networkConfig, err := networks.Get(ethConfig.EthNetworkName)
+	if err != nil {
+		return
+	}
+	if ethConfig.ContractAddress != "" {
+		networkConfig.ContractAddress = common.HexToAddress(ethConfig.ContractAddress)
+	}
+	urls := networkConfig.RPCEndpoints
+	if ethConfig.EthUrl != "" {
+		urls = []string{ethConfig.EthUrl}
+	}
+	if len(urls) == 0 {
+		urls = []string{DefaultEthUrl}
+	}
+	var client *ethclient.Client
+	for _, url := range urls {
+		client, err = ethclient.DialContext(ctx, url)
+		if err == nil {
+			break
+		}
+		log.Warn("Failed to connect to the ethereum node", "url", url, "err", err)
+	}
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	filter, err := tokenv1.NewTokenFilterer(networkConfig.ContractAddress, client)
+	if err != nil {
+		return
+	}
+	if to == 0 {
+		if to, err = client.BlockNumber(ctx); err != nil {
+			return
+		}
+	}
+	logRange := ethConfig.LogRange
+	if logRange == 0 {
+		logRange = defaultLogRange
+	}
+
+	for start := from; start <= to; start += logRange {
+		end := start + logRange - 1
+		if end > to {
+			end = to
+		}
+		log.Debug("Filtering mint and withdraw events", "from", start, "to", end)
+		opts := &bind.FilterOpts{Start: start, End: &end, Context: ctx}
+
+		mintIt, err := filter.FilterMint(opts, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for mintIt.Next() {
+			mints = append(mints, reconcile.Mint{
+				DepositID: mintIt.Event.Txid.Hex(),
+				TxHash:    mintIt.Event.Raw.TxHash.Hex(),
+				Block:     mintIt.Event.Raw.BlockNumber,
+				Receiver:  mintIt.Event.Receiver.Hex(),
+				Amount:    mintIt.Event.Tokens.Int64(),
+			})
+		}
+		mintIt.Close()
+		if err = mintIt.Error(); err != nil {
+			return nil, nil, err
+		}
+
+		withdrawIt, err := filter.FilterWithdraw(opts, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for withdrawIt.Next() {
+			if withdrawIt.Event.Network != BridgeNetwork {
+				continue
+			}
+			txHash := withdrawIt.Event.Raw.TxHash
+			withdrawals = append(withdrawals, reconcile.Withdrawal{
+				// the payment of a withdrawal carries the transaction hash as memo hash
+				ID:          hex.EncodeToString(txHash.Bytes()),
+				TxHash:      txHash.Hex(),
+				Block:       withdrawIt.Event.Raw.BlockNumber,
+				Destination: withdrawIt.Event.BlockchainAddress,
+				Amount:      withdrawIt.Event.Tokens.Int64(),
+			})
+		}
+		withdrawIt.Close()
+		if err = withdrawIt.Error(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return mints, withdrawals, nil
+}
@@ -2,23 +2,30 @@ package bridge
 
 import (
 	"context"
-	"crypto/ed25519"
 	"encoding/hex"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	gorpc "github.com/libp2p/go-libp2p-gorpc"
-	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/routing"
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
-	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/quorum"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
 	"github.com/threefoldtech/libp2p-relay/client"
 )
 
@@ -43,26 +50,12 @@ func (c *SignerConfig) Valid() error {
 	return nil
 }
 
-func NewHost(ctx context.Context, secret, relay string, psk string) (host.Host, routing.PeerRouting, error) {
-	seed, err := strkey.Decode(strkey.VersionByteSeed, secret)
+func NewHost(ctx context.Context, identity keys.Ed25519Signer, relay string, psk string) (host.Host, routing.PeerRouting, error) {
+	privKey, err := keys.Libp2pKey(identity)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if len(seed) != ed25519.SeedSize {
-		return nil, nil, fmt.Errorf("invalid seed size '%d' expecting '%d'", len(seed), ed25519.SeedSize)
-	}
-
-	var privKey crypto.PrivKey
-	if secret != "" {
-		privKey, err = crypto.UnmarshalEd25519PrivateKey(
-			ed25519.NewKeyFromSeed(seed),
-		)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
 	key, err := hex.DecodeString(psk)
 	if err != nil {
 		return nil, nil, err
@@ -94,183 +87,388 @@ func NewHost(ctx context.Context, secret, relay string, psk string) (host.Host,
 }
 
 type SignersClient struct {
-	peers  []peer.ID
 	host   host.Host
 	router routing.PeerRouting
 	client *gorpc.Client
 	relay  *peer.AddrInfo
+	// signatures are the signatures collected for requests which did not get the required signatures yet
+	signatures *state.PartialSignatures
+
+	// peers are the cosigners, they are replaced when the signers of the bridge account change
+	peers     []peer.ID
+	peersLock sync.Mutex
+
+	// lastSeen is the time of the last valid reply of every cosigner
+	lastSeen     map[peer.ID]time.Time
+	lastSeenLock sync.Mutex
+
+	// versions is the signing protocol version of every cosigner, exchanged the first time the bridge connects to it
+	versions     map[peer.ID]int
+	versionsLock sync.Mutex
+
+	// nodes is the outcome of the last status poll of every cosigner
+	nodes     map[peer.ID]nodeStatus
+	nodesLock sync.Mutex
+}
+
+// nodeStatus is the outcome of the status poll of a cosigner
+type nodeStatus struct {
+	// status is the last status the cosigner answered, it is kept if a later poll fails
+	status     *multisig.NodeStatus
+	polled     time.Time
+	err        error
+	mismatches []string
 }
 
-type response struct {
-	answer *multisig.StellarSignResponse
-	peer   peer.ID
-	err    error
+// CosignerStatus is the last time a cosigner replied to a sign request
+type CosignerStatus struct {
+	PeerID string `json:"peerId"`
+	// LastSeen is nil if the cosigner did not reply since the bridge started
+	LastSeen *time.Time `json:"lastSeen"`
+	// ProtocolVersion is the signing protocol version used with the cosigner, 0 if it is not known yet
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
+	// Node is the last status the cosigner answered to the status poll, Polled when it was last polled
+	// and PollError why it did not answer then
+	Node      *multisig.NodeStatus `json:"node,omitempty"`
+	Polled    *time.Time           `json:"polled,omitempty"`
+	PollError string               `json:"pollError,omitempty"`
+	// Mismatches are the settings of the cosigner which differ from the ones of this bridge
+	Mismatches []string `json:"mismatches,omitempty"`
 }
 
-type ethResponse struct {
-	answer *EthSignResponse
-	peer   peer.ID
-	err    error
+// errStatusUnsupported is the poll error of a cosigner which does not answer the status poll yet
+var errStatusUnsupported = errors.New("the cosigner runs a version without the status poll")
+
+// signingRound asks a cosigner again if it can not be reached, a cosigner which refuses a request is not asked again in the same round
+var signingRound = quorum.Config{
+	Timeout:  quorum.DefaultConfig.Timeout,
+	Attempts: quorum.DefaultConfig.Attempts,
+	Backoff:  quorum.DefaultConfig.Backoff,
+	Retry: func(err error) bool {
+		return !gorpc.IsServerError(err)
+	},
 }
 
-// NewSignersClient creates a signer client to ask cosigners to sign
-func NewSignersClient(host host.Host, router routing.PeerRouting, cosigners []peer.ID, relay *peer.AddrInfo) *SignersClient {
+// NewSignersClient creates a signer client to ask cosigners to sign.
+// The signatures collected for a request are kept in signatures, if it is not nil.
+func NewSignersClient(host host.Host, router routing.PeerRouting, cosigners []peer.ID, relay *peer.AddrInfo, signatures *state.PartialSignatures) *SignersClient {
 
 	return &SignersClient{
-		client: gorpc.NewClient(host, Protocol),
-		host:   host,
-		router: router,
-		peers:  cosigners,
-		relay:  relay,
+		client:     gorpc.NewClient(host, Protocol),
+		host:       host,
+		router:     router,
+		peers:      cosigners,
+		relay:      relay,
+		signatures: signatures,
+
+		lastSeen: make(map[peer.ID]time.Time),
+		versions: make(map[peer.ID]int),
+		nodes:    make(map[peer.ID]nodeStatus),
 	}
 }
 
-func (s *SignersClient) Sign(ctx context.Context, signRequest multisig.StellarSignRequest) ([]multisig.StellarSignResponse, error) {
-	// cancel context after 30 seconds
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	responseChannels := make([]chan response, 0, len(s.peers))
-	for _, addr := range s.peers {
-		respCh := make(chan response, 1)
-		responseChannels = append(responseChannels, respCh)
-		go func(peerID peer.ID, ch chan response) {
-			defer close(ch)
-			answer, err := s.sign(ctxWithTimeout, peerID, signRequest)
-
-			select {
-			case <-ctxWithTimeout.Done():
-			case ch <- response{answer: answer, peer: peerID, err: err}:
-			}
-		}(addr, respCh)
+// SetPeers replaces the cosigners, the signatures collected earlier from the cosigners which are kept are still used
+func (s *SignersClient) SetPeers(cosigners []peer.ID) {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+	s.peers = cosigners
+}
 
+// store returns where the signatures are kept between rounds, nil if they are not kept
+func (s *SignersClient) store() quorum.Store {
+	if s.signatures == nil {
+		return nil
 	}
+	return s.signatures
+}
 
-	var results []multisig.StellarSignResponse
+func (s *SignersClient) currentPeers() []peer.ID {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+	return s.peers
+}
+
+func (s *SignersClient) seen(id peer.ID) {
+	s.lastSeenLock.Lock()
+	defer s.lastSeenLock.Unlock()
+	s.lastSeen[id] = time.Now()
+}
 
-	for len(responseChannels) > 0 {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
+// report logs the outcome of a signing round for every cosigner
+func (s *SignersClient) report(kind string, outcomes []quorum.Outcome) {
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.Stored:
+			log.Info("signature of an earlier round", "peerID", outcome.Peer, "kind", kind)
+		case outcome.Answered:
+			log.Info("got a valid reply", "peerID", outcome.Peer, "kind", kind, "attempts", outcome.Attempts)
+		case outcome.Failed():
+			log.Error("failed to get signature", "peerID", outcome.Peer, "kind", kind, "attempts", outcome.Attempts, "err", outcome.Err)
+			metrics.SigningFailures.WithLabelValues(kind, outcome.Peer.String()).Inc()
 		}
-		receivedFrom := -1
-	responsechannelsLoop:
-		for i, responseChannel := range responseChannels {
-			select {
-			case reply := <-responseChannel:
-				receivedFrom = i
-				if reply.err != nil {
-					log.Error("failed to get signature", "peerID", reply.peer, "err", reply.err.Error())
-				} else {
-					if reply.answer != nil {
-						log.Info("got a valid reply", "peerID", reply.peer)
-						results = append(results, *reply.answer)
-					}
-				}
-				break responsechannelsLoop
-			default: //don't block
-			}
+		if outcome.StoreErr != nil {
+			log.Warn("failed to keep the signature for a later round", "peerID", outcome.Peer, "kind", kind, "err", outcome.StoreErr)
 		}
-		if receivedFrom >= 0 {
-			//Remove the channel from the list
-			responseChannels[receivedFrom] = responseChannels[len(responseChannels)-1]
-			responseChannels = responseChannels[:len(responseChannels)-1]
-			//check if we have enough signatures
-			if len(results) == signRequest.RequiredSignatures {
-				break
+	}
+}
+
+// Cosigners returns the status of the cosigners
+func (s *SignersClient) Cosigners() []CosignerStatus {
+	s.lastSeenLock.Lock()
+	defer s.lastSeenLock.Unlock()
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	s.nodesLock.Lock()
+	defer s.nodesLock.Unlock()
+	peers := s.currentPeers()
+	cosigners := make([]CosignerStatus, 0, len(peers))
+	for _, id := range peers {
+		status := CosignerStatus{PeerID: id.String(), ProtocolVersion: s.versions[id]}
+		if lastSeen, found := s.lastSeen[id]; found {
+			status.LastSeen = &lastSeen
+		}
+		if node, found := s.nodes[id]; found {
+			status.Node = node.status
+			status.Polled = &node.polled
+			status.Mismatches = node.mismatches
+			if node.err != nil {
+				status.PollError = node.err.Error()
 			}
-		} else {
-			time.Sleep(time.Millisecond * 100)
 		}
+		cosigners = append(cosigners, status)
+	}
+	return cosigners
+}
 
+// version returns the signing protocol version to use with a cosigner, it is exchanged with a Hello the first time.
+// A cosigner which does not know Hello yet speaks version 1.
+func (s *SignersClient) version(ctx context.Context, id peer.ID) (int, error) {
+	s.versionsLock.Lock()
+	version, found := s.versions[id]
+	s.versionsLock.Unlock()
+	if found {
+		return version, nil
 	}
 
-	if len(results) != signRequest.RequiredSignatures {
-		return nil, fmt.Errorf("required number of signatures is not met")
+	var response multisig.HelloResponse
+	err := s.client.CallContext(ctx, id, "SignerService", "Hello", &multisig.HelloRequest{Version: multisig.ProtocolVersion}, &response)
+	switch {
+	case err == nil:
+		// a newer cosigner still signs the requests of the versions it supports
+		version = min(response.Version, multisig.ProtocolVersion)
+	case unknownMethod(err):
+		version = 1
+	default:
+		return 0, err
+	}
+	if err = multisig.CheckVersion(version); err != nil {
+		return 0, err
+	}
+	log.Info("signing protocol version of cosigner", "peerID", id, "version", version)
+
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	s.versions[id] = version
+	return version, nil
+}
+
+// forgetVersion makes the protocol version be exchanged again on the next request, after a cosigner was downgraded
+func (s *SignersClient) forgetVersion(id peer.ID) {
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	delete(s.versions, id)
+}
+
+// unknownMethod checks if a call failed because the cosigner does not have the method
+func unknownMethod(err error) bool {
+	return gorpc.IsServerError(err) && strings.Contains(err.Error(), "can't find method")
+}
+
+// Sign asks the cosigners to sign a Stellar transaction until the required signatures are collected.
+// The signatures are kept per kind and memo of the transaction together with the transaction itself,
+// so signing the same transaction again only asks the missing cosigners. Signing another transaction
+// for the same kind and memo drops the signatures collected before.
+func (s *SignersClient) Sign(ctx context.Context, signRequest multisig.SignRequest) ([]multisig.StellarSignResponse, error) {
+	defer metrics.ObserveSince(metrics.SigningDuration.WithLabelValues(metrics.SignStellar), time.Now())
+	memo, err := transactionMemo(signRequest.TxnXDR)
+	if err != nil {
+		return nil, err
+	}
+	request := stellarRequest(signRequest.Kind, memo)
+	if s.signatures != nil {
+		if err = s.signatures.SetPayload(request, signRequest.TxnXDR); err != nil {
+			log.Warn("failed to keep the transaction for a later round", "kind", signRequest.Kind, "memo", memo, "err", err)
+		}
+	}
+
+	signatures, outcomes, err := quorum.Collect(ctx, signingRound, s.store(), request, s.currentPeers(), signRequest.RequiredSignatures, func(ctx context.Context, id peer.ID) (multisig.StellarSignResponse, error) {
+		response, err := s.sign(ctx, id, signRequest)
+		if err != nil {
+			return multisig.StellarSignResponse{}, err
+		}
+		return *response, nil
+	})
+	s.report(metrics.SignStellar, outcomes)
+	return signatures, err
+}
+
+// Transaction returns the Stellar transaction the signatures for kind and memo are collected for,
+// an empty string if there is none.
+func (s *SignersClient) Transaction(kind multisig.RequestKind, memo string) string {
+	if s.signatures == nil {
+		return ""
 	}
+	return s.signatures.Payload(stellarRequest(kind, memo))
+}
 
-	return results, nil
+// stellarRequest is the id under which the signatures for a Stellar transaction are kept
+func stellarRequest(kind multisig.RequestKind, memo string) string {
+	return fmt.Sprintf("stellar/%s/%s", kind, memo)
 }
 
-func (s *SignersClient) sign(ctx context.Context, id peer.ID, signRequest multisig.StellarSignRequest) (*multisig.StellarSignResponse, error) {
+// transactionMemo returns the hex encoded memo of a Stellar transaction
+func transactionMemo(txnXDR string) (string, error) {
+	generic, err := txnbuild.TransactionFromXDR(txnXDR)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode the transaction")
+	}
+	tx, ok := generic.Transaction()
+	if !ok {
+		return "", errors.New("not a transaction")
+	}
+	return stellar.ExtractMemoFromTx(tx)
+}
+
+func (s *SignersClient) sign(ctx context.Context, id peer.ID, signRequest multisig.SignRequest) (*multisig.StellarSignResponse, error) {
 	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the signature proves private key ownership")
 	arHost := s.host.(*autorelay.AutoRelayHost)
 
 	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
 		return nil, errors.Wrapf(err, "failed to connect to host id '%s'", id)
 	}
+	version, err := s.version(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to exchange the protocol version with host id '%s'", id)
+	}
 
 	var response multisig.StellarSignResponse
-	if err := s.client.CallContext(ctx, id, "SignerService", "Sign", &signRequest, &response); err != nil {
+	if version < 2 {
+		legacy := signRequest.Legacy()
+		err = s.client.CallContext(ctx, id, "SignerService", "Sign", &legacy, &response)
+	} else {
+		err = s.client.CallContext(ctx, id, "SignerService", "SignTransaction", &signRequest, &response)
+	}
+	if err != nil {
+		if unknownMethod(err) {
+			s.forgetVersion(id)
+		}
 		return nil, err
 	}
+	s.seen(id)
 
 	return &response, nil
 }
 
-func (s *SignersClient) SignMint(ctx context.Context, signRequest EthSignRequest) ([]EthSignResponse, error) {
-	// cancel context after 30 seconds
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+// MonitorCosigners polls the status of the cosigners every interval until the context is done.
+// The settings of the cosigners which differ from the ones in the status of this bridge are logged and reported in Cosigners.
+func (s *SignersClient) MonitorCosigners(ctx context.Context, interval time.Duration, own func() multisig.NodeStatus) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.pollStatus(ctx, own(), interval/2)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollStatus asks all cosigners for their status and compares it with own
+func (s *SignersClient) pollStatus(ctx context.Context, own multisig.NodeStatus, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
+	var wg sync.WaitGroup
+	for _, id := range s.currentPeers() {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			status, err := s.nodeStatus(ctx, id)
+			s.updateNode(id, own, status, err)
+		}(id)
+	}
+	wg.Wait()
+}
 
-	responseChannels := make([]chan ethResponse, 0, len(s.peers))
-	for _, addr := range s.peers {
-		respCh := make(chan ethResponse, 1)
-		responseChannels = append(responseChannels, respCh)
-		go func(peerID peer.ID, ch chan ethResponse) {
-			defer close(ch)
-			answer, err := s.signMint(ctxWithTimeout, peerID, signRequest)
-
-			select {
-			case <-ctxWithTimeout.Done():
-			case ch <- ethResponse{answer: answer, peer: peerID, err: err}:
-			}
-		}(addr, respCh)
+func (s *SignersClient) nodeStatus(ctx context.Context, id peer.ID) (status multisig.NodeStatus, err error) {
+	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the status is public")
+	arHost := s.host.(*autorelay.AutoRelayHost)
 
+	if err = client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
+		return status, errors.Wrapf(err, "failed to connect to host id '%s'", id)
 	}
+	err = s.client.CallContext(ctx, id, "SignerService", "Status", &multisig.StatusRequest{}, &status)
+	if unknownMethod(err) {
+		err = errStatusUnsupported
+	}
+	return status, err
+}
 
-	var results []EthSignResponse
-
-	for len(responseChannels) > 0 {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-		receivedFrom := -1
-	responsechannelsLoop:
-		for i, responseChannel := range responseChannels {
-			select {
-			case reply := <-responseChannel:
-				receivedFrom = i
-				if reply.err != nil {
-					log.Error("failed to get signature", "peerID", reply.peer, "err", reply.err.Error())
-				} else {
-					if reply.answer != nil {
-						log.Info("got a valid reply from a signer")
-						results = append(results, *reply.answer)
-					}
-				}
-				break responsechannelsLoop
-			default: //don't block
-			}
-		}
-		if receivedFrom >= 0 {
-			//Remove the channel from the list
-			responseChannels[receivedFrom] = responseChannels[len(responseChannels)-1]
-			responseChannels = responseChannels[:len(responseChannels)-1]
-			//check if we have enough signatures
-			if len(results) == int(signRequest.RequiredSignatures) {
-				break
-			}
-		} else {
-			time.Sleep(time.Millisecond * 100)
+// updateNode keeps the outcome of the status poll of a cosigner and logs what changed
+func (s *SignersClient) updateNode(id peer.ID, own multisig.NodeStatus, status multisig.NodeStatus, err error) {
+	s.nodesLock.Lock()
+	defer s.nodesLock.Unlock()
+	previous, polled := s.nodes[id]
+	node := nodeStatus{status: previous.status, polled: time.Now(), err: err, mismatches: previous.mismatches}
+	if err != nil {
+		if !polled || previous.err == nil {
+			log.Warn("cosigner did not answer the status poll", "peerID", id, "err", err)
 		}
+		s.nodes[id] = node
+		return
+	}
 
+	node.status = &status
+	node.mismatches = own.Mismatches(status)
+	log.Info("cosigner status", "peerID", id, "version", status.Version, "height", status.Height, "synced", status.Synced, "stellarCursor", status.StellarCursor, "paused", status.Pause.Paused)
+	if previous.err != nil {
+		log.Info("cosigner answers the status poll again", "peerID", id)
+	}
+	switch {
+	case len(node.mismatches) > 0 && !slices.Equal(node.mismatches, previous.mismatches):
+		log.Warn("cosigner settings differ from this bridge, it might refuse to sign", "peerID", id, "mismatches", strings.Join(node.mismatches, "; "))
+	case len(node.mismatches) == 0 && len(previous.mismatches) > 0:
+		log.Info("cosigner settings match this bridge again", "peerID", id)
 	}
+	s.nodes[id] = node
+}
+
+// callLease calls a method of the lease service of a signer
+func (s *SignersClient) callLease(ctx context.Context, id peer.ID, method string, request *lease.Request, response *lease.Grant) error {
+	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the lease is granted to the peer id")
+	arHost := s.host.(*autorelay.AutoRelayHost)
 
-	if len(results) != int(signRequest.RequiredSignatures) {
-		return nil, fmt.Errorf("required number of signatures is not met")
+	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
+		return errors.Wrapf(err, "failed to connect to host id '%s'", id)
 	}
+	return s.client.CallContext(ctx, id, lease.ServiceName, method, request, response)
+}
 
-	return results, nil
+// SignMint asks the cosigners to sign a mint until the required signatures are collected.
+// The signatures are kept per mint, so a retry only asks the missing cosigners.
+func (s *SignersClient) SignMint(ctx context.Context, signRequest EthSignRequest) ([]EthSignResponse, error) {
+	defer metrics.ObserveSince(metrics.SigningDuration.WithLabelValues(metrics.SignMint), time.Now())
+	request := fmt.Sprintf("mint/%s/%s/%d", signRequest.TxId, signRequest.Receiver.Hex(), signRequest.Amount)
+
+	signatures, outcomes, err := quorum.Collect(ctx, signingRound, s.store(), request, s.currentPeers(), int(signRequest.RequiredSignatures), func(ctx context.Context, id peer.ID) (EthSignResponse, error) {
+		response, err := s.signMint(ctx, id, signRequest)
+		if err != nil {
+			return EthSignResponse{}, err
+		}
+		return *response, nil
+	})
+	s.report(metrics.SignMint, outcomes)
+	return signatures, err
 }
 
 func (s *SignersClient) signMint(ctx context.Context, id peer.ID, signRequest EthSignRequest) (*EthSignResponse, error) {
@@ -280,11 +478,15 @@ func (s *SignersClient) signMint(ctx context.Context, id peer.ID, signRequest Et
 	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
 		return nil, errors.Wrapf(err, "failed to connect to host id '%s'", id)
 	}
+	if _, err := s.version(ctx, id); err != nil {
+		return nil, errors.Wrapf(err, "failed to exchange the protocol version with host id '%s'", id)
+	}
 
 	var response EthSignResponse
 	if err := s.client.CallContext(ctx, id, "SignerService", "SignMint", &signRequest, &response); err != nil {
 		return nil, err
 	}
+	s.seen(id)
 
 	return &response, nil
 }
@@ -3,9 +3,12 @@ package bridge
 import (
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -16,12 +19,19 @@ import (
 	"github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"github.com/stellar/go/amount"
 	"github.com/stellar/go/protocols/horizon/effects"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/contracts/tokenv1"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/policy"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
 )
 
@@ -34,9 +44,12 @@ var (
 	ErrTransactionAlreadyExists = errors.Wrap(ErrInvalidTransaction, "transaction already exists")
 	ErrAlreadyRefunded          = errors.Wrap(ErrInvalidTransaction, "The deposit was already refunded")
 	ErrInvalidFeePayment        = errors.Wrap(ErrInvalidTransaction, "Invalid fee payment")
+	ErrNotMaster                = errors.New("the request is not from the master")
 )
 
 type EthSignRequest struct {
+	// Version is the protocol version of the master, 0 for a master from before the version was sent
+	Version            int
 	Receiver           common.Address
 	Amount             int64
 	TxId               string
@@ -52,10 +65,26 @@ type SignerService struct {
 	bridgeContract      *BridgeContract
 	stellarWallet       *stellar.Wallet
 	bridgeMasterAddress string
-	depositFee          int64 // deposit fee in TFT units TODO: maybe just pass part of the comfig
+	depositFee          fees.Schedule
+	withdrawFee         fees.Schedule
+	pause               *pause.Switch
+	// policy bounds the mints and withdrawals signed, signed keeps the volume counted against it
+	policy     policy.Policy
+	signed     *state.VolumeTracker
+	policyLock sync.Mutex
+	// audit records every request which is signed
+	audit *state.AuditLog
+	// leases is only set with failover, only requests of the signer holding the lease are signed
+	leases *lease.Service
+	// status returns the state of this bridge answered to the status poll
+	status func() multisig.NodeStatus
 }
 
-func NewSignerServer(host host.Host, bridgeMasterAddress string, bridgeContract *BridgeContract, stellarWallet *stellar.Wallet, depositFee int64) error {
+// NewSignerServer registers the signer service on the host.
+// The volume signed under the signing policy and the audit log are kept next to the persistency file.
+// If leases is not nil, it is served as well and only the requests of the signer holding the lease are signed.
+// status is answered to the status poll of the master.
+func NewSignerServer(host host.Host, bridgeMasterAddress string, bridgeContract *BridgeContract, stellarWallet *stellar.Wallet, depositFee, withdrawFee fees.Schedule, pauseSwitch *pause.Switch, signingPolicy policy.Policy, persistencyFile string, leases *lease.Service, status func() multisig.NodeStatus) error {
 	log.Info("server started", "identity", host.ID())
 	partialMA, err := multiaddr.NewMultiaddr(fmt.Sprintf("/p2p/%s", host.ID()))
 	if err != nil {
@@ -67,6 +96,15 @@ func NewSignerServer(host host.Host, bridgeMasterAddress string, bridgeContract
 		log.Info("p2p node address", "address", full.String())
 	}
 
+	signed, err := state.NewVolumeTracker(state.SignedVolumeFile(persistencyFile))
+	if err != nil {
+		return errors.Wrap(err, "failed to load the signed volume")
+	}
+	audit, err := state.NewAuditLog(state.AuditFile(persistencyFile))
+	if err != nil {
+		return err
+	}
+
 	server := gorpc.NewServer(host, Protocol)
 
 	signerService := SignerService{
@@ -74,13 +112,56 @@ func NewSignerServer(host host.Host, bridgeMasterAddress string, bridgeContract
 		stellarWallet:       stellarWallet,
 		bridgeMasterAddress: bridgeMasterAddress,
 		depositFee:          depositFee,
+		withdrawFee:         withdrawFee,
+		pause:               pauseSwitch,
+		policy:              signingPolicy,
+		signed:              signed,
+		audit:               audit,
+		leases:              leases,
+		status:              status,
+	}
+
+	if leases != nil {
+		if err = server.RegisterName(lease.ServiceName, leases); err != nil {
+			return err
+		}
 	}
-
 	return server.Register(&signerService)
 }
 
+// Hello tells the master which protocol version and request kinds this cosigner supports
+func (s *SignerService) Hello(ctx context.Context, request multisig.HelloRequest, response *multisig.HelloResponse) error {
+	log.Info("master connected", "peer", requestSender(ctx), "version", request.Version)
+	if err := multisig.CheckVersion(request.Version); err != nil {
+		log.Warn("Refusing master with an unsupported protocol version", "peer", requestSender(ctx), "err", err)
+		return err
+	}
+	response.Version = multisig.ProtocolVersion
+	response.Kinds = multisig.Kinds
+	return nil
+}
+
+// Status returns the state of this cosigner so the master can spot differences before a signing round fails
+// This is calable on the libp2p network with RPC
+func (s *SignerService) Status(ctx context.Context, request multisig.StatusRequest, response *multisig.NodeStatus) error {
+	*response = s.status()
+	return nil
+}
+
 func (s *SignerService) SignMint(ctx context.Context, request EthSignRequest, response *EthSignResponse) error {
 	log.Info("sign mint request", "request txid", request.TxId)
+	if err := multisig.CheckVersion(request.Version); err != nil {
+		log.Warn("Refusing unsupported mint request", "request txid", request.TxId, "err", err)
+		return err
+	}
+	if s.pause.Paused() {
+		log.Warn("Refusing to sign while paused", "request txid", request.TxId)
+		return pause.ErrPaused
+	}
+	if err := s.checkMaster(ctx); err != nil {
+		log.Warn("Refusing to sign for a signer without the master lease", "request txid", request.TxId, "err", err)
+		return err
+	}
 
 	// Check in transaction storage if the deposit transaction exists
 	tx, err := s.stellarWallet.TransactionStorage.GetTransactionWithId(request.TxId)
@@ -96,7 +177,7 @@ func (s *SignerService) SignMint(ctx context.Context, request EthSignRequest, re
 	}
 	log.Debug("validating amount for sign tx", "amount", depositedAmount, "request amount", request.Amount)
 
-	depositFeeBigInt := big.NewInt(stellar.IntToStroops(s.depositFee))
+	depositFeeBigInt := big.NewInt(s.depositFee.Fee(depositedAmount))
 
 	amount := &big.Int{}
 	amount = amount.Sub(big.NewInt(depositedAmount), depositFeeBigInt)
@@ -116,20 +197,61 @@ func (s *SignerService) SignMint(ctx context.Context, request EthSignRequest, re
 		return fmt.Errorf("deposit addresses do not match")
 	}
 
+	if err = s.checkPolicy(state.VolumeMint, request.TxId, depositedAmount, request.Receiver.Hex()); err != nil {
+		log.Warn("Mint refused by the signing policy", "request txid", request.TxId, "err", err)
+		return err
+	}
+
 	signature, err := s.bridgeContract.CreateTokenSignature(request.Receiver, request.Amount, request.TxId)
 	if err != nil {
 		return err
 	}
 
+	err = s.audit.Record(state.AuditEntry{
+		Request:     metrics.TransferMint,
+		ID:          request.TxId,
+		Destination: request.Receiver.Hex(),
+		Amount:      request.Amount,
+		Fee:         depositFeeBigInt.Int64(),
+		Peer:        requestSender(ctx),
+		Signature:   hex.EncodeToString(append(append(signature.R[:], signature.S[:]...), signature.V)),
+	})
+	if err != nil {
+		log.Error("Failed to write the audit log, not handing out the signature", "request txid", request.TxId, "err", err)
+		return errors.New("Error") //Internal errors should not be exposed externally
+	}
+
 	response.Signature = signature
 	response.Who = s.bridgeContract.ethc.address
 
 	return nil
 }
 
-// Sign signs a stellar sign request
+// Sign signs a stellar sign request of protocol version 1, what is signed is derived from the fields which are set.
 // This is calable on the libp2p network with RPC
 func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRequest, response *multisig.StellarSignResponse) error {
+	return s.signTransaction(ctx, request.Typed(), response)
+}
+
+// SignTransaction signs a typed stellar sign request
+// This is calable on the libp2p network with RPC
+func (s *SignerService) SignTransaction(ctx context.Context, request multisig.SignRequest, response *multisig.StellarSignResponse) error {
+	if err := request.Validate(); err != nil {
+		log.Warn("Refusing unsupported sign request", "kind", request.Kind, "version", request.Version, "err", err)
+		return err
+	}
+	return s.signTransaction(ctx, request, response)
+}
+
+func (s *SignerService) signTransaction(ctx context.Context, request multisig.SignRequest, response *multisig.StellarSignResponse) error {
+	if s.pause.Paused() {
+		log.Warn("Refusing to sign while paused", "kind", request.Kind)
+		return pause.ErrPaused
+	}
+	if err := s.checkMaster(ctx); err != nil {
+		log.Warn("Refusing to sign for a signer without the master lease", "kind", request.Kind, "err", err)
+		return err
+	}
 	loaded, err := txnbuild.TransactionFromXDR(request.TxnXDR)
 	if err != nil {
 		return err
@@ -140,21 +262,24 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 		return fmt.Errorf("provided transaction is of wrong type")
 	}
 
-	if request.Block != 0 {
+	var transfer string
+	switch request.Kind {
+	case multisig.KindWithdrawal:
+		transfer = metrics.TransferWithdrawal
 		log.Info("Validating withdrawal signing request")
-		err := s.validateWithdrawal(request, txn)
+		err := s.validateWithdrawal(request.Withdrawal, txn)
 		if err != nil {
-			if errors.Is(err, ErrInvalidTransaction) {
+			if errors.Is(err, ErrInvalidTransaction) || policy.Violated(err) {
 				log.Warn("Withdrawal validation error", "err", err)
 				return err
 			}
 			log.Error("An error occurred while validating a withdrawal signing request", "err", err)
 			return errors.New("Error") //Internal errors should not be exposed externally
 		}
-	} else if request.Message != "" {
-		// If the signrequest has a message attached we know it's a refund transaction
-		log.Info("Validating refund signing request", "deposit", request.Message)
-		err := s.validateRefundTransaction(request, txn)
+	case multisig.KindRefund:
+		transfer = metrics.TransferRefund
+		log.Info("Validating refund signing request", "deposit", request.Refund.Deposit)
+		err := s.validateRefundTransaction(request.Refund.Deposit, txn)
 		if err != nil {
 			if errors.Is(err, ErrInvalidTransaction) {
 				log.Warn("Refund validation error", "err", err)
@@ -163,11 +288,10 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 			log.Error("An error occurred while validating a refund signing request", "err", err)
 			return errors.New("Error") //Internal errors should not be exposed externally
 		}
-	} else {
-		// If the signrequest is not a withdrawal request and a refund request
-		// then it's most likely a transfer to fee wallet transaction from a deposit
-		log.Info("Validating fee transfer signing request")
-		err := s.validateDepositFeeTransfer(request, txn)
+	case multisig.KindFeeTransfer:
+		transfer = metrics.TransferFee
+		log.Info("Validating fee transfer signing request", "deposit", request.FeeTransfer.Deposit)
+		err := s.validateDepositFeeTransfer(request.FeeTransfer.Deposit, txn)
 		if err != nil {
 			if errors.Is(err, ErrInvalidTransaction) {
 				log.Info("Fee transfer validation error", "err", err)
@@ -176,6 +300,8 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 			log.Error("An error occurred while validating a deposit fee transfer signing request", "err", err)
 			return errors.New("Error") //Internal errors should not be exposed externally
 		}
+	default:
+		return errors.Wrapf(multisig.ErrUnsupportedRequest, "unknown kind %q", request.Kind)
 	}
 
 	log.Info("Signing valid signing request")
@@ -190,13 +316,77 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 		return fmt.Errorf("invalid number of signatures on the transaction")
 	}
 
+	signature := base64.StdEncoding.EncodeToString(signatures[0].Signature)
+	if err = s.auditTransaction(ctx, transfer, txn, signature); err != nil {
+		log.Error("Failed to write the audit log, not handing out the signature", "err", err)
+		return errors.New("Error") //Internal errors should not be exposed externally
+	}
+
 	response.Address = s.stellarWallet.GetAddress()
-	response.Signature = base64.StdEncoding.EncodeToString(signatures[0].Signature)
+	response.Signature = signature
+	return nil
+}
+
+// auditTransaction records a signed Stellar transaction in the audit log
+func (s *SignerService) auditTransaction(ctx context.Context, transfer string, txn *txnbuild.Transaction, signature string) error {
+	entry := state.AuditEntry{
+		Request:   transfer,
+		Peer:      requestSender(ctx),
+		Signature: signature,
+	}
+	var err error
+	if entry.ID, err = stellar.ExtractMemoFromTx(txn); err != nil {
+		return err
+	}
+	if entry.Transaction, err = txn.Base64(); err != nil {
+		return err
+	}
+	for _, op := range txn.Operations() {
+		payment, ok := op.(*txnbuild.Payment)
+		if !ok {
+			continue
+		}
+		paid, err := amount.ParseInt64(payment.Amount)
+		if err != nil {
+			return err
+		}
+		if payment.Destination == s.stellarWallet.Config.StellarFeeWallet && transfer != metrics.TransferFee {
+			entry.Fee += paid
+			continue
+		}
+		entry.Destination = payment.Destination
+		entry.Amount += paid
+	}
+	return s.audit.Record(entry)
+}
+
+// checkMaster checks with failover that the request is sent by the signer this cosigner granted the master lease to.
+// A cosigner which did not grant the lease, or whose grant expired, signs nothing.
+func (s *SignerService) checkMaster(ctx context.Context) error {
+	if s.leases == nil {
+		return nil
+	}
+	holder := s.leases.Holder()
+	if holder == "" {
+		return errors.Wrap(ErrNotMaster, "the lease is not granted")
+	}
+	if requestSender(ctx) != holder.String() {
+		return errors.Wrapf(ErrNotMaster, "the lease is held by %s", holder)
+	}
 	return nil
 }
 
-func (s *SignerService) validateWithdrawal(request multisig.StellarSignRequest, txn *txnbuild.Transaction) error {
-	withdraw, err := s.bridgeContract.tftContract.filter.FilterWithdraw(&bind.FilterOpts{Start: request.Block}, []common.Address{request.Receiver})
+// requestSender returns the peer which sent the rpc request, empty if it is not known
+func requestSender(ctx context.Context) string {
+	sender, err := gorpc.GetRequestSender(ctx)
+	if err != nil {
+		return ""
+	}
+	return sender.String()
+}
+
+func (s *SignerService) validateWithdrawal(request *multisig.WithdrawalRequest, txn *txnbuild.Transaction) error {
+	withdraw, err := s.bridgeContract.tftContract.filter.FilterWithdraw(&bind.FilterOpts{Start: request.Block}, []common.Address{request.Sender})
 	if err != nil {
 		return err
 	}
@@ -225,9 +415,16 @@ func (s *SignerService) validateWithdrawal(request multisig.StellarSignRequest,
 		return errors.Wrap(ErrInvalidTransaction, "Withdrawal already executed")
 	}
 
-	amount -= WithdrawFee
-	if len(txn.Operations()) != 2 {
-		return errors.Wrap(ErrInvalidTransaction, "a withdraw tx needs to contain 2 payment operations")
+	fee := s.withdrawFee.Fee(amount)
+	amount -= fee
+	// the master only adds a fee payment if there is a fee and a fee wallet to pay it to
+	feePaymentExpected := fee > 0 && s.stellarWallet.Config.StellarFeeWallet != ""
+	expectedOperations := 1
+	if feePaymentExpected {
+		expectedOperations = 2
+	}
+	if len(txn.Operations()) != expectedOperations {
+		return errors.Wrapf(ErrInvalidTransaction, "a withdraw tx needs to contain %d payment operations", expectedOperations)
 	}
 	feePaymentPresent := false
 	for _, op := range txn.Operations() {
@@ -247,8 +444,8 @@ func (s *SignerService) validateWithdrawal(request multisig.StellarSignRequest,
 
 		acc := paymentOperation.Destination.ToAccountId()
 
-		if acc.Address() == s.stellarWallet.Config.StellarFeeWallet {
-			if int64(paymentOperation.Amount) != WithdrawFee {
+		if feePaymentExpected && acc.Address() == s.stellarWallet.Config.StellarFeeWallet {
+			if int64(paymentOperation.Amount) != fee {
 				return errors.Wrap(ErrInvalidTransaction, "the withdraw fee is incorrect")
 			}
 			feePaymentPresent = true
@@ -263,14 +460,30 @@ func (s *SignerService) validateWithdrawal(request multisig.StellarSignRequest,
 			return fmt.Errorf("amount is not correct, received %d, need %d", paymentOperation.Amount, xdr.Int64(withdraw.Event.Tokens.Int64()))
 		}
 	}
-	if !feePaymentPresent {
+	if feePaymentExpected && !feePaymentPresent {
 		return errors.Wrap(ErrInvalidTransaction, "No withdraw fee payment")
 	}
 
-	return nil
+	// the policy is checked last so only valid withdrawals are counted in the signed volume
+	return s.checkPolicy(state.VolumeWithdraw, memo, withdraw.Event.Tokens.Int64(), withdraw.Event.BlockchainAddress)
+}
+
+// checkPolicy checks a mint or withdrawal against the signing policy and counts it in the signed volume.
+// A transfer which is signed again, for example when the master retries, is not checked or counted twice.
+func (s *SignerService) checkPolicy(direction string, id string, amount int64, destination string) error {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+
+	if s.signed.Known(direction, id) {
+		return nil
+	}
+	if err := s.policy.Check(amount, destination, s.signed.Volume(direction, 24*time.Hour), time.Now()); err != nil {
+		return err
+	}
+	return s.signed.Record(direction, id, amount)
 }
 
-func (s *SignerService) validateRefundTransaction(request multisig.StellarSignRequest, txn *txnbuild.Transaction) error {
+func (s *SignerService) validateRefundTransaction(deposit string, txn *txnbuild.Transaction) error {
 
 	// check if a refund already happened
 	memo, err := stellar.ExtractMemoFromTx(txn)
@@ -278,8 +491,8 @@ func (s *SignerService) validateRefundTransaction(request multisig.StellarSignRe
 		log.Warn("Failed to extract memo", "err", err)
 		return ErrInvalidTransaction
 	}
-	if memo != request.Message {
-		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the signrequest message do not match")
+	if memo != deposit {
+		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the refunded deposit do not match")
 	}
 	alreadyRefunded, err := s.stellarWallet.TransactionStorage.TransactionWithMemoExists(memo)
 	if err != nil {
@@ -292,6 +505,7 @@ func (s *SignerService) validateRefundTransaction(request multisig.StellarSignRe
 	var destinationAccount string
 	var refundAmountWithoutPenalty int64
 	var penaltyPayment bool
+	var penalty int64
 	// There are 2 payment operations, 1 to the feewallet and 1 to the account that made the deposit
 	if len(txn.Operations()) > 2 {
 		return errors.Wrap(ErrInvalidTransaction, "The refund transaction has too many operations")
@@ -318,9 +532,7 @@ func (s *SignerService) validateRefundTransaction(request multisig.StellarSignRe
 				return errors.Wrap(ErrInvalidTransaction, "Multiple payments to the feewallet")
 			}
 			penaltyPayment = true
-			if paymentOperation.Amount != xdr.Int64(WithdrawFee) {
-				return errors.Wrapf(ErrInvalidFeePayment, "fee amount should be %d, but got %d", WithdrawFee, paymentOperation.Amount)
-			}
+			penalty = int64(paymentOperation.Amount)
 			continue
 		}
 		destinationAccount = operationDestinationAccount
@@ -356,14 +568,20 @@ func (s *SignerService) validateRefundTransaction(request multisig.StellarSignRe
 		}
 	}
 
-	if stellar.DecimalToStroops(depositedAmount) != (refundAmountWithoutPenalty + WithdrawFee) {
+	fee := s.withdrawFee.Fee(stellar.DecimalToStroops(depositedAmount))
+	if penalty != fee {
+		return errors.Wrapf(ErrInvalidFeePayment, "fee amount should be %d, but got %d", fee, penalty)
+	}
+	if stellar.DecimalToStroops(depositedAmount) != (refundAmountWithoutPenalty + fee) {
 		return errors.Wrapf(ErrInvalidTransaction, "The refunded amount %s does not match the deposit %s minus the penalty", stellar.StroopsToDecimal(refundAmountWithoutPenalty), depositedAmount)
 	}
 
 	return nil
 }
 
-func (s *SignerService) validateDepositFeeTransfer(request multisig.StellarSignRequest, txn *txnbuild.Transaction) (err error) {
+// validateDepositFeeTransfer validates the transfer of the fee of a deposit,
+// deposit is empty for a request of protocol version 1 where it is only in the memo.
+func (s *SignerService) validateDepositFeeTransfer(deposit string, txn *txnbuild.Transaction) (err error) {
 
 	// Check if a fee transfer for this already happened
 	memo, err := stellar.ExtractMemoFromTx(txn)
@@ -371,6 +589,9 @@ func (s *SignerService) validateDepositFeeTransfer(request multisig.StellarSignR
 		log.Warn("Failed to extract memo", "err", err)
 		return ErrInvalidTransaction
 	}
+	if deposit != "" && memo != deposit {
+		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the deposit do not match")
+	}
 	alreadyExists, err := s.stellarWallet.TransactionStorage.TransactionWithMemoExists(memo)
 	if err != nil {
 		return
@@ -405,16 +626,17 @@ func (s *SignerService) validateDepositFeeTransfer(request multisig.StellarSignR
 		return errors.Wrapf(ErrInvalidTransaction, "destination is not correct, got %s, need fee wallet %s", acc.Address(), s.stellarWallet.Config.StellarFeeWallet)
 	}
 
-	if int64(paymentOperation.Amount) != stellar.IntToStroops(s.depositFee) {
-		return errors.Wrapf(ErrInvalidTransaction, "amount is not correct, received %d, need %d", stellar.StroopsToDecimal(int64(paymentOperation.Amount)), s.depositFee)
-	}
 	//Validate the deposit transaction that triggered this deposit fee transfer
 	depositedAmount, _, err := s.stellarWallet.GetDepositAmountAndSender(memo, s.bridgeMasterAddress)
 	if err != nil {
 		return
 	}
-	if depositedAmount <= s.depositFee {
+	fee := s.depositFee.Fee(depositedAmount)
+	if depositedAmount <= fee {
 		return errors.Wrap(ErrInvalidFeePayment, "The amount of the deposit is smaller than the deposit fee")
 	}
+	if int64(paymentOperation.Amount) != fee {
+		return errors.Wrapf(ErrInvalidTransaction, "amount is not correct, received %s, need %s", stellar.StroopsToDecimal(int64(paymentOperation.Amount)), stellar.StroopsToDecimal(fee))
+	}
 	return
 }
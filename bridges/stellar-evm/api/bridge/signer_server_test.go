@@ -0,0 +1,419 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	gorpc "github.com/libp2p/go-libp2p-gorpc"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/policy"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar/horizontest"
+)
+
+// localSigners hands the sign requests of a wallet to signer services in the same process
+type localSigners []*SignerService
+
+func (s localSigners) Sign(ctx context.Context, request multisig.SignRequest) ([]multisig.StellarSignResponse, error) {
+	responses := make([]multisig.StellarSignResponse, len(s))
+	for i, signer := range s {
+		if err := signer.SignTransaction(ctx, request, &responses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// Transaction returns nothing, the signer services sign every request in one round
+func (s localSigners) Transaction(kind multisig.RequestKind, memo string) string {
+	return ""
+}
+
+func newStellarKey(t *testing.T) (string, ed25519.PrivateKey) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	address, err := strkey.Encode(strkey.VersionByteAccountID, pub)
+	require.NoError(t, err)
+	return address, key
+}
+
+// newTestVault creates a vault with 1000 TFT on a fake horizon server which needs the signatures of the master and a cosigner.
+// The master wallet gets its signatures from the returned cosigner service.
+func newTestVault(t *testing.T, contract *BridgeContract, depositFee, withdrawFee fees.Schedule) (*stellar.Wallet, *SignerService, *horizontest.Server) {
+	server := horizontest.NewServer(keypair.MustRandom().Address())
+	t.Cleanup(server.Close)
+	stellar.SetNetwork("horizontest", stellar.Network{HorizonURL: server.URL, Passphrase: horizontest.Passphrase, Asset: server.Asset()})
+
+	vault, masterKey := newStellarKey(t)
+	cosignerAddress, cosignerKey := newStellarKey(t)
+	config := &stellar.StellarConfig{StellarNetwork: "horizontest", StellarFeeWallet: keypair.MustRandom().Address()}
+	server.CreateAccount(vault, 1000_0000000)
+	server.CreateAccount(config.StellarFeeWallet, 0)
+	server.SetSigners(vault, map[string]int32{vault: 1, cosignerAddress: 1}, 2)
+
+	cosignerWallet, err := stellar.NewWallet(config, keys.NewLocalEd25519Signer(cosignerKey), depositFee, withdrawFee, stellar.NewTransactionStorage(config.StellarNetwork, vault))
+	require.NoError(t, err)
+	cosignerWallet.SetHorizonClient(server.Client())
+	persistency := filepath.Join(t.TempDir(), "node.json")
+	signed, err := state.NewVolumeTracker(state.SignedVolumeFile(persistency))
+	require.NoError(t, err)
+	audit, err := state.NewAuditLog(state.AuditFile(persistency))
+	require.NoError(t, err)
+	cosigner := &SignerService{
+		bridgeContract:      contract,
+		stellarWallet:       cosignerWallet,
+		bridgeMasterAddress: vault,
+		depositFee:          depositFee,
+		withdrawFee:         withdrawFee,
+		pause:               pause.NewSwitch(""),
+		signed:              signed,
+		audit:               audit,
+	}
+
+	master, err := stellar.NewWallet(config, keys.NewLocalEd25519Signer(masterKey), depositFee, withdrawFee, stellar.NewTransactionStorage(config.StellarNetwork, vault))
+	require.NoError(t, err)
+	master.SetHorizonClient(server.Client())
+	master.SetSignerClient(localSigners{cosigner})
+	master.SetRequiredSignatures(2)
+	return master, cosigner, server
+}
+
+type payment struct {
+	destination string
+	stroops     int64
+}
+
+// paymentXDR builds an unsigned transaction paying TFT from the vault
+func paymentXDR(t *testing.T, vault string, memo txnbuild.Memo, payments ...payment) string {
+	code, issuer, err := stellar.GetTFTAsset("horizontest")
+	require.NoError(t, err)
+	var operations []txnbuild.Operation
+	for _, p := range payments {
+		operations = append(operations, &txnbuild.Payment{
+			Destination:   p.destination,
+			Amount:        amount.StringFromInt64(p.stroops),
+			Asset:         txnbuild.CreditAsset{Code: code, Issuer: issuer},
+			SourceAccount: vault,
+		})
+	}
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: vault, Sequence: 1},
+		IncrementSequenceNum: true,
+		Operations:           operations,
+		Memo:                 memo,
+		BaseFee:              stellar.Precision,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	})
+	require.NoError(t, err)
+	xdr, err := tx.Base64()
+	require.NoError(t, err)
+	return xdr
+}
+
+func TestSignerServiceWithdrawal(t *testing.T) {
+	masterKey, userKey := newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	user := crypto.PubkeyToAddress(userKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master, user)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	chain.fund(t, user, big.NewInt(100_0000000))
+	wallet, cosigner, server := newTestVault(t, contract, fees.NewFlat(0), fees.NewFlat(1_0000000))
+	vault, feeWallet := wallet.GetAddress(), wallet.Config.StellarFeeWallet
+	destination := keypair.MustRandom().Address()
+	server.CreateAccount(destination, 0)
+
+	blockOf := func(hash common.Hash) uint64 {
+		receipt, err := chain.TransactionReceipt(context.Background(), hash)
+		require.NoError(t, err)
+		return receipt.BlockNumber.Uint64()
+	}
+	paid := chain.sendToken(t, userKey, "withdraw", big.NewInt(10_0000000), destination, BridgeNetwork)
+	require.NoError(t, wallet.CreateAndSubmitPayment(context.Background(), destination, 9_0000000, user, blockOf(paid.Hash()), paid.Hash(), 1_0000000))
+	assert.Equal(t, int64(9_0000000), server.Balance(destination))
+	assert.Equal(t, int64(1_0000000), server.Balance(feeWallet))
+	entries, err := cosigner.audit.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "withdrawal", entries[0].Request)
+	assert.Equal(t, hex.EncodeToString(paid.Hash().Bytes()), entries[0].ID)
+	assert.Equal(t, destination, entries[0].Destination)
+	assert.Equal(t, int64(9_0000000), entries[0].Amount)
+	assert.Equal(t, int64(1_0000000), entries[0].Fee)
+	assert.NotEmpty(t, entries[0].Signature)
+	assert.NotEmpty(t, entries[0].Transaction)
+
+	open := chain.sendToken(t, userKey, "withdraw", big.NewInt(20_0000000), destination, BridgeNetwork)
+	for name, test := range map[string]struct {
+		withdrawal common.Hash
+		memo       common.Hash
+		payments   []payment
+	}{
+		"already executed": {paid.Hash(), paid.Hash(), []payment{{destination, 9_0000000}, {feeWallet, 1_0000000}}},
+		"other memo":       {open.Hash(), paid.Hash(), []payment{{destination, 19_0000000}, {feeWallet, 1_0000000}}},
+		"no fee":           {open.Hash(), open.Hash(), []payment{{destination, 19_0000000}}},
+		"wrong fee":        {open.Hash(), open.Hash(), []payment{{feeWallet, 2_0000000}, {destination, 18_0000000}}},
+		"other receiver":   {open.Hash(), open.Hash(), []payment{{keypair.MustRandom().Address(), 19_0000000}, {feeWallet, 1_0000000}}},
+	} {
+		request := multisig.StellarSignRequest{
+			TxnXDR:   paymentXDR(t, vault, txnbuild.MemoHash(test.memo), test.payments...),
+			Receiver: user,
+			Block:    blockOf(test.withdrawal),
+		}
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.Sign(context.Background(), request, &response), ErrInvalidTransaction, name)
+	}
+}
+
+func TestSignerServiceRefund(t *testing.T) {
+	wallet, cosigner, server := newTestVault(t, nil, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	vault, feeWallet := wallet.GetAddress(), wallet.Config.StellarFeeWallet
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+
+	refunded, err := server.Pay(user, vault, 5_0000000, txnbuild.MemoText("no address"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.CreateAndSubmitRefund(context.Background(), user, 4_0000000, refunded, 1_0000000))
+	assert.Equal(t, int64(99_0000000), server.Balance(user))
+	assert.Equal(t, int64(1_0000000), server.Balance(feeWallet))
+
+	open, err := server.Pay(user, vault, 5_0000000, txnbuild.MemoText("no address"))
+	require.NoError(t, err)
+	memoOf := func(deposit string) txnbuild.Memo {
+		hash, err := hex.DecodeString(deposit)
+		require.NoError(t, err)
+		return txnbuild.MemoReturn([32]byte(hash))
+	}
+	for name, test := range map[string]struct {
+		deposit  string
+		memo     txnbuild.Memo
+		payments []payment
+		err      error
+	}{
+		"already refunded": {refunded, memoOf(refunded), []payment{{user, 4_0000000}, {feeWallet, 1_0000000}}, ErrAlreadyRefunded},
+		"other memo":       {open, memoOf(refunded), []payment{{user, 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"no fee":           {open, memoOf(open), []payment{{user, 5_0000000}}, ErrInvalidFeePayment},
+		"too much":         {open, memoOf(open), []payment{{user, 5_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"other account":    {open, memoOf(open), []payment{{keypair.MustRandom().Address(), 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"two fees":         {open, memoOf(open), []payment{{feeWallet, 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+	} {
+		request := multisig.SignRequest{
+			Version: multisig.ProtocolVersion,
+			Kind:    multisig.KindRefund,
+			TxnXDR:  paymentXDR(t, vault, test.memo, test.payments...),
+			Refund:  &multisig.RefundRequest{Deposit: test.deposit},
+		}
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), test.err, name)
+	}
+}
+
+func TestSignerServiceDepositFeeTransfer(t *testing.T) {
+	wallet, cosigner, server := newTestVault(t, nil, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	vault, feeWallet := wallet.GetAddress(), wallet.Config.StellarFeeWallet
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+	receiver := base64.StdEncoding.EncodeToString(common.HexToAddress("0x2000000000000000000000000000000000000002").Bytes())
+
+	hashOf := func(deposit string) common.Hash {
+		hash, err := hex.DecodeString(deposit)
+		require.NoError(t, err)
+		return common.BytesToHash(hash)
+	}
+	transferred, err := server.Pay(user, vault, 10_0000000, txnbuild.MemoText(receiver))
+	require.NoError(t, err)
+	require.NoError(t, wallet.CreateAndSubmitFeepayment(context.Background(), 1_0000000, hashOf(transferred)))
+	assert.Equal(t, int64(1_0000000), server.Balance(feeWallet))
+
+	open, err := server.Pay(user, vault, 10_0000000, txnbuild.MemoText(receiver))
+	require.NoError(t, err)
+	small, err := server.Pay(user, vault, 1_0000000, txnbuild.MemoText(receiver))
+	require.NoError(t, err)
+	for name, test := range map[string]struct {
+		deposit  string
+		payments []payment
+		err      error
+	}{
+		"already transferred": {transferred, []payment{{feeWallet, 1_0000000}}, ErrTransactionAlreadyExists},
+		"wrong amount":        {open, []payment{{feeWallet, 2_0000000}}, ErrInvalidTransaction},
+		"other account":       {open, []payment{{user, 1_0000000}}, ErrInvalidTransaction},
+		"two payments":        {open, []payment{{feeWallet, 1_0000000}, {user, 1_0000000}}, ErrInvalidTransaction},
+		"deposit below fee":   {small, []payment{{feeWallet, 1_0000000}}, ErrInvalidFeePayment},
+	} {
+		request := multisig.SignRequest{
+			Version:     multisig.ProtocolVersion,
+			Kind:        multisig.KindFeeTransfer,
+			TxnXDR:      paymentXDR(t, vault, txnbuild.MemoHash(hashOf(test.deposit)), test.payments...),
+			FeeTransfer: &multisig.FeeTransferRequest{Deposit: test.deposit},
+		}
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), test.err, name)
+	}
+
+	request := multisig.SignRequest{
+		Version:     multisig.ProtocolVersion,
+		Kind:        multisig.KindFeeTransfer,
+		TxnXDR:      paymentXDR(t, vault, txnbuild.MemoHash(hashOf(open)), payment{feeWallet, 1_0000000}),
+		FeeTransfer: &multisig.FeeTransferRequest{Deposit: small},
+	}
+	var response multisig.StellarSignResponse
+	assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), ErrInvalidTransaction, "other deposit")
+
+	request.FeeTransfer.Deposit = open
+	cosigner.pause.Pause("test")
+	assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), pause.ErrPaused)
+	require.NoError(t, cosigner.pause.Resume())
+	require.NoError(t, cosigner.SignTransaction(context.Background(), request, &response))
+	assert.Equal(t, cosigner.stellarWallet.GetAddress(), response.Address)
+
+	// a master of protocol version 1 does not send the deposit
+	require.NoError(t, cosigner.Sign(context.Background(), multisig.StellarSignRequest{TxnXDR: request.TxnXDR}, &response))
+}
+
+func TestSignerServiceProtocol(t *testing.T) {
+	_, cosigner, _ := newTestVault(t, nil, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+
+	var hello multisig.HelloResponse
+	require.NoError(t, cosigner.Hello(context.Background(), multisig.HelloRequest{Version: multisig.ProtocolVersion}, &hello))
+	assert.Equal(t, multisig.ProtocolVersion, hello.Version)
+	assert.Equal(t, multisig.Kinds, hello.Kinds)
+	assert.ErrorIs(t, cosigner.Hello(context.Background(), multisig.HelloRequest{Version: multisig.ProtocolVersion + 1}, &hello), multisig.ErrUnsupportedRequest)
+
+	deposit := &multisig.FeeTransferRequest{Deposit: "00"}
+	for name, request := range map[string]multisig.SignRequest{
+		"unknown kind":     {Version: multisig.ProtocolVersion, Kind: "burn", FeeTransfer: deposit},
+		"mint":             {Version: multisig.ProtocolVersion, Kind: multisig.KindMint, FeeTransfer: deposit},
+		"no payload":       {Version: multisig.ProtocolVersion, Kind: multisig.KindFeeTransfer},
+		"other payload":    {Version: multisig.ProtocolVersion, Kind: multisig.KindRefund, FeeTransfer: deposit},
+		"two payloads":     {Version: multisig.ProtocolVersion, Kind: multisig.KindRefund, Refund: &multisig.RefundRequest{Deposit: "00"}, FeeTransfer: deposit},
+		"too new":          {Version: multisig.ProtocolVersion + 1, Kind: multisig.KindFeeTransfer, FeeTransfer: deposit},
+		"negative version": {Version: -1, Kind: multisig.KindFeeTransfer, FeeTransfer: deposit},
+	} {
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), multisig.ErrUnsupportedRequest, name)
+	}
+
+	var response EthSignResponse
+	assert.ErrorIs(t, cosigner.SignMint(context.Background(), EthSignRequest{Version: multisig.ProtocolVersion + 1}, &response), multisig.ErrUnsupportedRequest)
+}
+
+func TestSignerServiceSignMint(t *testing.T) {
+	masterKey := newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	wallet, cosigner, server := newTestVault(t, contract, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	deposit, err := server.Pay(user, wallet.GetAddress(), 10_0000000, txnbuild.MemoText(base64.StdEncoding.EncodeToString(receiver.Bytes())))
+	require.NoError(t, err)
+	var response EthSignResponse
+	require.NoError(t, cosigner.SignMint(context.Background(), EthSignRequest{Receiver: receiver, Amount: 9_0000000, TxId: deposit}, &response))
+	assert.Equal(t, master, response.Who)
+	entries, err := cosigner.audit.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, state.AuditEntry{
+		Sequence:    1,
+		At:          entries[0].At,
+		Request:     "mint",
+		ID:          deposit,
+		Destination: receiver.Hex(),
+		Amount:      9_0000000,
+		Fee:         1_0000000,
+		Signature:   hex.EncodeToString(append(append(response.Signature.R[:], response.Signature.S[:]...), response.Signature.V)),
+		Hash:        entries[0].Hash,
+	}, entries[0])
+
+	assert.Error(t, cosigner.SignMint(context.Background(), EthSignRequest{Receiver: receiver, Amount: 10_0000000, TxId: deposit}, &response))
+	assert.Error(t, cosigner.SignMint(context.Background(), EthSignRequest{Receiver: common.Address{1}, Amount: 9_0000000, TxId: deposit}, &response))
+	assert.ErrorIs(t, cosigner.SignMint(context.Background(), EthSignRequest{Receiver: receiver, Amount: 9_0000000, TxId: hex.EncodeToString(common.Hash{1}.Bytes())}, &response), stellar.ErrTransactionNotFound)
+}
+
+func TestSignerServicePolicy(t *testing.T) {
+	masterKey, userKey := newKey(t), newKey(t)
+	master := crypto.PubkeyToAddress(masterKey.PublicKey)
+	user := crypto.PubkeyToAddress(userKey.PublicKey)
+	chain := newSimulatedChain(t, []common.Address{master}, 1, master, user)
+	contract := newTestBridgeContract(t, chain, masterKey)
+	chain.fund(t, user, big.NewInt(1000_0000000))
+	wallet, cosigner, server := newTestVault(t, contract, fees.NewFlat(0), fees.NewFlat(0))
+	destination, blocked := keypair.MustRandom().Address(), keypair.MustRandom().Address()
+	server.CreateAccount(destination, 0)
+	server.CreateAccount(blocked, 0)
+	cosigner.policy = policy.Policy{MaxTransfer: 100_0000000, DailyVolume: 150_0000000, Blocked: map[string]bool{blocked: true}}
+
+	withdraw := func(amount int64, to string) error {
+		tx := chain.sendToken(t, userKey, "withdraw", big.NewInt(amount), to, BridgeNetwork)
+		receipt, err := chain.TransactionReceipt(context.Background(), tx.Hash())
+		require.NoError(t, err)
+		return wallet.CreateAndSubmitPayment(context.Background(), to, uint64(amount), user, receipt.BlockNumber.Uint64(), tx.Hash(), 0)
+	}
+	assert.ErrorIs(t, withdraw(101_0000000, destination), policy.ErrAboveMaximum)
+	assert.ErrorIs(t, withdraw(10_0000000, blocked), policy.ErrBlockedDestination)
+	require.NoError(t, withdraw(100_0000000, destination))
+	assert.ErrorIs(t, withdraw(60_0000000, destination), policy.ErrDailyVolumeExceeded)
+	require.NoError(t, withdraw(50_0000000, destination))
+	assert.Equal(t, int64(150_0000000), server.Balance(destination))
+
+	// the volume is kept per direction
+	assert.Equal(t, int64(150_0000000), cosigner.signed.Volume(state.VolumeWithdraw, 24*time.Hour))
+	assert.Zero(t, cosigner.signed.Volume(state.VolumeMint, 24*time.Hour))
+
+	user2 := keypair.MustRandom().Address()
+	server.CreateAccount(user2, 1000_0000000)
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	deposit, err := server.Pay(user2, wallet.GetAddress(), 120_0000000, txnbuild.MemoText(base64.StdEncoding.EncodeToString(receiver.Bytes())))
+	require.NoError(t, err)
+	var response EthSignResponse
+	assert.ErrorIs(t, cosigner.SignMint(context.Background(), EthSignRequest{Receiver: receiver, Amount: 120_0000000, TxId: deposit}, &response), policy.ErrAboveMaximum)
+}
+
+func TestSignerServiceMasterLease(t *testing.T) {
+	cfg := lease.Config{Duration: 100 * time.Millisecond}
+	leases := lease.NewService("cosigner", cfg)
+	signer := &SignerService{leases: leases}
+	from := func(sender peer.ID) context.Context {
+		return context.WithValue(context.Background(), gorpc.ContextKeyRequestSender, sender)
+	}
+	master, other := peer.ID("master"), peer.ID("other")
+
+	// nothing is signed while the lease is not granted
+	assert.ErrorIs(t, signer.checkMaster(from(master)), ErrNotMaster)
+
+	// a restarted signer grants the lease once an earlier grant expired
+	time.Sleep(cfg.Duration)
+	var grant lease.Grant
+	require.NoError(t, leases.Acquire(from(master), lease.Request{}, &grant))
+	require.True(t, grant.Granted)
+	assert.NoError(t, signer.checkMaster(from(master)))
+	assert.ErrorIs(t, signer.checkMaster(from(other)), ErrNotMaster)
+
+	// an expired lease is not granted to anyone, the previous master is refused as well
+	time.Sleep(cfg.Duration)
+	assert.ErrorIs(t, signer.checkMaster(from(master)), ErrNotMaster)
+	assert.ErrorIs(t, signer.checkMaster(from(other)), ErrNotMaster)
+}
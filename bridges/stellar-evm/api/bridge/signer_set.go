@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/p2p"
+)
+
+// signersInterval is how often the signers of the bridge account and the token contract are checked for changes
+const signersInterval = time.Minute
+
+// signerSet are the signers of an account or contract and the number of signatures it requires
+type signerSet struct {
+	signers  []string
+	required int64
+}
+
+func newSignerSet(signers []string, required int64) signerSet {
+	sorted := slices.Clone(signers)
+	slices.Sort(sorted)
+	return signerSet{signers: sorted, required: required}
+}
+
+// diff returns the signers which are added and removed compared to previous
+func (s signerSet) diff(previous signerSet) (added, removed []string) {
+	for _, signer := range s.signers {
+		if !slices.Contains(previous.signers, signer) {
+			added = append(added, signer)
+		}
+	}
+	for _, signer := range previous.signers {
+		if !slices.Contains(s.signers, signer) {
+			removed = append(removed, signer)
+		}
+	}
+	return
+}
+
+// changed checks if the signers or the required signatures differ from previous
+func (s signerSet) changed(previous signerSet) bool {
+	return s.required != previous.required || !slices.Equal(s.signers, previous.signers)
+}
+
+// watchSigners checks the signers of the bridge account and the token contract until the context is done.
+// The cosigners and the required Stellar signatures follow the signers of the bridge account,
+// the signers of the contract are read for every mint so their changes are only logged.
+func (bridge *Bridge) watchSigners(ctx context.Context) {
+	ticker := time.NewTicker(signersInterval)
+	defer ticker.Stop()
+	for {
+		if err := bridge.refreshStellarSigners(); err != nil {
+			log.Warn("failed to check the signers of the bridge account", "err", err)
+		}
+		if err := bridge.refreshContractSigners(); err != nil {
+			log.Warn("failed to check the signers of the token contract", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshStellarSigners updates the cosigners, the lease peers and the required signatures if the signers of the bridge account changed
+func (bridge *Bridge) refreshStellarSigners() error {
+	cosigners, requiredSignatures, err := bridge.wallet.GetSigningRequirements()
+	if err != nil {
+		return err
+	}
+	current := newSignerSet(cosigners, int64(requiredSignatures))
+	if !current.changed(bridge.stellarSigners) {
+		return nil
+	}
+	cosignerPeerIDs, err := p2p.GetPeerIDsFromStellarAddresses(cosigners)
+	if err != nil {
+		return err
+	}
+
+	added, removed := current.diff(bridge.stellarSigners)
+	log.Warn("The signers of the bridge account changed", "added", added, "removed", removed, "signatures", requiredSignatures, "previous", bridge.stellarSigners.required)
+	bridge.wallet.SetRequiredSignatures(requiredSignatures)
+	bridge.signersClient.SetPeers(cosignerPeerIDs)
+	if bridge.elector != nil {
+		bridge.elector.SetPeers(cosignerPeerIDs)
+	}
+	bridge.stellarSigners = current
+	return nil
+}
+
+// refreshContractSigners logs the changes of the signers of the token contract, for instance after a setSigners call
+func (bridge *Bridge) refreshContractSigners() error {
+	addresses, err := bridge.bridgeContract.GetSigners()
+	if err != nil {
+		return err
+	}
+	requiredSignatures, err := bridge.bridgeContract.GetRequiresSignatureCount()
+	if err != nil {
+		return err
+	}
+	signers := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		signers = append(signers, address.Hex())
+	}
+	current := newSignerSet(signers, requiredSignatures.Int64())
+	if !current.changed(bridge.contractSigners) {
+		return nil
+	}
+
+	// the signers of the contract are not known before the first check
+	if bridge.contractSigners.signers == nil {
+		log.Info("Signers of the token contract", "signers", current.signers, "signatures", current.required)
+	} else {
+		added, removed := current.diff(bridge.contractSigners)
+		log.Warn("The signers of the token contract changed", "added", added, "removed", removed, "signatures", current.required, "previous", bridge.contractSigners.required)
+	}
+	if !slices.Contains(addresses, bridge.GetClient().address) {
+		log.Error("This bridge is not a signer of the token contract, its mints will be refused", "address", bridge.GetClient().address.Hex())
+	}
+	bridge.contractSigners = current
+	return nil
+}
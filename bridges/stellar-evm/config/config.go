@@ -0,0 +1,152 @@
+// Package config loads the settings of the bridge from a config file and environment variables
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// FileFlag is the name of the flag holding the config file
+const FileFlag = "config"
+
+// fileSuffix marks a setting whose value is read from a file, for secrets
+const fileSuffix = "_file"
+
+// EnvName returns the environment variable for a flag, for example
+// BRIDGE_KEYSTORE_PASSWORD_FILE for keystore-password-file and BRIDGE_DEPOSIT_FEE for depositFee.
+func EnvName(prefix, flagName string) string {
+	var name strings.Builder
+	name.WriteString(prefix)
+	name.WriteByte('_')
+	var previous rune
+	for _, r := range flagName {
+		switch {
+		case r == '-':
+			r = '_'
+		case unicode.IsUpper(r) && unicode.IsLower(previous):
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+		previous = r
+	}
+	return name.String()
+}
+
+// Load sets the flags which are not given on the command line.
+// A flag is looked up, in order, in:
+//
+//   - the environment variable as returned by EnvName
+//   - the file named in the environment variable with a _FILE suffix, like BRIDGE_SECRET_FILE
+//   - the config file under the flag name, or read from the file under the flag name with a _file suffix, like secret_file
+//
+// The config file is a yaml mapping of flag names to values, it is passed with the config flag
+// or its environment variable. Load reports all invalid settings at once.
+func Load(fs *flag.FlagSet, prefix string) error {
+	settings, err := readFile(fs, prefix)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	known := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		known[f.Name] = true
+		known[f.Name+fileSuffix] = true
+		if f.Name == FileFlag || f.Changed {
+			return
+		}
+		value, source, found, err := lookup(f.Name, prefix, settings)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s from %s: %w", f.Name, source, err))
+			return
+		}
+		if !found {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s from %s: %w", f.Name, source, err))
+		}
+	})
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("unknown setting %s in the config file", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookup returns the value of a flag from the environment or the config file
+func lookup(flagName, prefix string, settings map[string]string) (value, source string, found bool, err error) {
+	env := EnvName(prefix, flagName)
+	if value, found = os.LookupEnv(env); found {
+		return value, env, true, nil
+	}
+	if file, ok := os.LookupEnv(env + "_FILE"); ok {
+		value, err = readSecret(file)
+		return value, env + "_FILE", true, err
+	}
+	if value, found = settings[flagName]; found {
+		return value, "the config file", true, nil
+	}
+	if file, ok := settings[flagName+fileSuffix]; ok {
+		value, err = readSecret(file)
+		return value, "the config file", true, err
+	}
+	return "", "", false, nil
+}
+
+// readSecret reads a value from a file, a trailing newline is ignored
+func readSecret(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readFile reads the config file, if any, as flag names and values
+func readFile(fs *flag.FlagSet, prefix string) (map[string]string, error) {
+	var file string
+	if f := fs.Lookup(FileFlag); f != nil {
+		file = f.Value.String()
+		if env, ok := os.LookupEnv(EnvName(prefix, FileFlag)); ok && !f.Changed {
+			file = env
+		}
+	}
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var parsed map[string]interface{}
+	if err = yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+	}
+	var errs []error
+	settings := make(map[string]string, len(parsed))
+	for name, value := range parsed {
+		switch value := value.(type) {
+		case map[string]interface{}, []interface{}:
+			errs = append(errs, fmt.Errorf("setting %s in %s should be a single value", name, file))
+		case nil:
+			settings[name] = ""
+		default:
+			settings[name] = fmt.Sprint(value)
+		}
+	}
+	return settings, errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "BRIDGE_KEYSTORE_PASSWORD_FILE", EnvName("BRIDGE", "keystore-password-file"))
+	assert.Equal(t, "BRIDGE_DEPOSIT_FEE", EnvName("BRIDGE", "depositFee"))
+	assert.Equal(t, "BRIDGE_ETHURL", EnvName("BRIDGE", "ethurl"))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "psk")
+	require.NoError(t, os.WriteFile(secretFile, []byte("supersecret\n"), 0600))
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+network: production
+relay: /ip4/127.0.0.1/tcp/4001
+psk_file: `+secretFile+`
+follower: true
+maxfee: 100
+`), 0644))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(FileFlag, "", "")
+	network := fs.String("network", "testnet", "")
+	relay := fs.String("relay", "", "")
+	psk := fs.String("psk", "", "")
+	follower := fs.Bool("follower", false, "")
+	maxFee := fs.Uint64("maxfee", 300, "")
+	admin := fs.String("admin-addr", "", "")
+	require.NoError(t, fs.Parse([]string{"--config", configFile, "--relay", "/ip4/10.0.0.1/tcp/4001"}))
+
+	t.Setenv("TEST_ADMIN_ADDR", ":9090")
+	t.Setenv("TEST_MAXFEE", "200")
+	require.NoError(t, Load(fs, "TEST"))
+
+	assert.Equal(t, "production", *network)
+	assert.Equal(t, "/ip4/10.0.0.1/tcp/4001", *relay, "the command line takes precedence")
+	assert.Equal(t, "supersecret", *psk)
+	assert.True(t, *follower)
+	assert.Equal(t, uint64(200), *maxFee, "the environment takes precedence over the config file")
+	assert.Equal(t, ":9090", *admin)
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("maxfee: lots\nunknown: 1\n"), 0644))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(FileFlag, "", "")
+	fs.Uint64("maxfee", 300, "")
+	fs.String("secret", "", "")
+	require.NoError(t, fs.Parse([]string{"--config", configFile}))
+
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	err := Load(fs, "TEST")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maxfee")
+	assert.Contains(t, err.Error(), "unknown setting unknown")
+	assert.Contains(t, err.Error(), "TEST_SECRET_FILE")
+}
@@ -1,3 +1,6 @@
 package tokenv1
 
-//go:generate abigen --abi ../../../../solidity/abi/contracts/tokenV1.sol/TFT.json --pkg tokenv1 --type Token --out token.go
+// The bytecode is taken from the hardhat artifacts, run `npx hardhat compile` in the solidity folder first.
+//go:generate sh -c "jq -r .bytecode ../../../../solidity/artifacts/contracts/tokenV1.sol/TFT.json > token.bin"
+//go:generate abigen --abi ../../../../solidity/abi/contracts/tokenV1.sol/TFT.json --bin token.bin --pkg tokenv1 --type Token --out token.go
+//go:generate rm token.bin
@@ -39,12 +39,34 @@ type Signature struct {
 // TokenMetaData contains all meta data concerning the Token contract.
 var TokenMetaData = &bind.MetaData{
 	ABI: "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"numberOfSignatures\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"requiredSignatures\",\"type\":\"uint256\"}],\"name\":\"InsufficientSignatures\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"InvalidSignature\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"AddedOwner\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"tokenOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"string\",\"name\":\"txid\",\"type\":\"string\"}],\"name\":\"Mint\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"removedOwner\",\"type\":\"address\"}],\"name\":\"RemovedOwner\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"string\",\"name\":\"version\",\"type\":\"string\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"implementation\",\"type\":\"address\"}],\"name\":\"Upgraded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"blockchain_address\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"network\",\"type\":\"string\"}],\"name\":\"Withdraw\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"GetSignaturesRequired\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_newOwner\",\"type\":\"address\"}],\"name\":\"addOwner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"tokenOwner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"remaining\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"success\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"tokenOwner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"balance\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"decimals\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getSigners\",\"outputs\":[{\"internalType\":\"address[]\",\"name\":\"\",\"type\":\"address[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"implementation\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_txid\",\"type\":\"string\"}],\"name\":\"isMintID\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"is_owner\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"txid\",\"type\":\"string\"},{\"components\":[{\"internalType\":\"uint8\",\"name\":\"v\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"r\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"s\",\"type\":\"bytes32\"}],\"internalType\":\"structSignature[]\",\"name\":\"_signatures\",\"type\":\"tuple[]\"}],\"name\":\"mintTokens\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owners_list\",\"outputs\":[{\"internalType\":\"address[]\",\"name\":\"\",\"type\":\"address[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_toRemove\",\"type\":\"address\"}],\"name\":\"removeOwner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address[]\",\"name\":\"newSigners\",\"type\":\"address[]\"},{\"internalType\":\"uint256\",\"name\":\"signaturesRequired\",\"type\":\"uint256\"}],\"name\":\"setSigners\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"success\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"success\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_version\",\"type\":\"string\"},{\"internalType\":\"address\",\"name\":\"_implementation\",\"type\":\"address\"}],\"name\":\"upgradeTo\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"version\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokens\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"blockchain_address\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"network\",\"type\":\"string\"}],\"name\":\"withdraw\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"success\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]",
+	Bin: "0x60806040523480156200001157600080fd5b50620000586040518060400160405280600381526020017f5446540000000000000000000000000000000000000000000000000000000000815250620000df60201b60201c565b6200009e6040518060400160405280600f81526020017f544654206f6e20457468657265756d00000000000000000000000000000000008152506200011a60201b60201c565b600060079050620000b5816200015560201b60201c565b620000c760006200019360201b60201c565b50620000d933620001ce60201b60201c565b62000aac565b62000117604051602001620000f4906200049a565b60405160208183030381529060405280519060200120826200029460201b60201c565b50565b620001526040516020016200012f906200050c565b60405160208183030381529060405280519060200120826200029460201b60201c565b50565b620001906040516020016200016a906200057e565b604051602081830303815290604052805190602001208260ff16620002bb60201b60201c565b50565b620001cb604051602001620001a890620005f0565b6040516020818303038152906040528051906020012082620002bb60201b60201c565b50565b6000620001e0620002d660201b60201c565b905080829080600181540180825580915050600190039060005260206000200160009091909190916101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555062000255816200031460201b60201c565b62000290826040516020016200026c9190620006a7565b6040516020818303038152906040528051906020012060016200034f60201b60201c565b5050565b80600160008481526020019081526020016000209081620002b6919062000953565b505050565b80600080848152602001908152602001600020819055505050565b60006200030f604051602001620002ed9062000a8a565b604051602081830303815290604052805190602001206200037e60201b60201c565b905090565b6200034c604051602001620003299062000a8a565b60405160208183030381529060405280519060200120826200039a60201b60201c565b50565b806005600084815260200190815260200160002060006101000a81548160ff0219169083151502179055505050565b6000600360008381526020019081526020016000209050919050565b8060036000848152602001908152602001600020908054620003be929190620003c3565b505050565b828054828255906000526020600020908101928215620004075760005260206000209182015b8281111562000406578254825591600101919060010190620003e9565b5b5090506200041691906200041a565b5090565b5b80821115620004355760008160009055506001016200041b565b5090565b600082825260208201905092915050565b7f73796d626f6c0000000000000000000000000000000000000000000000000000600082015250565b60006200048260068362000439565b91506200048f826200044a565b602082019050919050565b60006020820190508181036000830152620004b58162000473565b9050919050565b7f6e616d6500000000000000000000000000000000000000000000000000000000600082015250565b6000620004f460048362000439565b91506200050182620004bc565b602082019050919050565b600060208201905081810360008301526200052781620004e5565b9050919050565b7f646563696d616c73000000000000000000000000000000000000000000000000600082015250565b60006200056660088362000439565b915062000573826200052e565b602082019050919050565b60006020820190508181036000830152620005998162000557565b9050919050565b7f746f74616c537570706c79000000000000000000000000000000000000000000600082015250565b6000620005d8600b8362000439565b9150620005e582620005a0565b602082019050919050565b600060208201905081810360008301526200060b81620005c9565b9050919050565b7f6f776e6572000000000000000000000000000000000000000000000000000000600082015250565b60006200064a60058362000439565b9150620006578262000612565b602082019050919050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b60006200068f8262000662565b9050919050565b620006a18162000682565b82525050565b60006040820190508181036000830152620006c2816200063b565b9050620006d3602083018462000696565b92915050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806200075b57607f821691505b60208210810362000771576200077062000713565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b600060088302620007db7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff826200079c565b620007e786836200079c565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b6000620008346200082e6200082884620007ff565b62000809565b620007ff565b9050919050565b6000819050919050565b620008508362000813565b620008686200085f826200083b565b848454620007a9565b825550505050565b600090565b6200087f62000870565b6200088c81848462000845565b505050565b5b81811015620008b457620008a860008262000875565b60018101905062000892565b5050565b601f8211156200090357620008cd8162000777565b620008d8846200078c565b81016020851015620008e8578190505b62000900620008f7856200078c565b83018262000891565b50505b505050565b600082821c905092915050565b6000620009286000198460080262000908565b1980831691505092915050565b600062000943838362000915565b9150826002028217905092915050565b6200095e82620006d9565b67ffffffffffffffff8111156200097a5762000979620006e4565b5b62000986825462000742565b62000993828285620008b8565b600060209050601f831160018114620009cb5760008415620009b6578287015190505b620009c2858262000935565b86555062000a32565b601f198416620009db8662000777565b60005b8281101562000a0557848901518255600182019150602085019450602081019050620009de565b8683101562000a25578489015162000a21601f89168262000915565b8355505b6001600288020188555050505b505050505050565b7f6f776e6572730000000000000000000000000000000000000000000000000000600082015250565b600062000a7260068362000439565b915062000a7f8262000a3a565b602082019050919050565b6000602082019050818103600083015262000aa58162000a63565b9050919050565b61371a8062000abc6000396000f3fe6080604052600436106101395760003560e01c806370a08231116100ab578063b41a88c01161006f578063b41a88c01461045b578063b436890414610486578063bc3962b5146104af578063dd62ed3e146104da578063dd6ad77e14610517578063f5d124de1461055457610140565b806370a082311461034e57806394cf795e1461038b57806395d89b41146103b65780639a493e75146103e1578063a9059cbb1461041e57610140565b806323b872dd116100fd57806323b872dd1461023e578063313ce5671461027b57806354fd4d50146102a65780635a8b1a9f146102d15780635c60da1b146102fa5780637065cb481461032557610140565b806306fdde03146101455780630776076f14610170578063095ea7b3146101ad578063173825d9146101ea57806318160ddd1461021357610140565b3661014057005b600080fd5b34801561015157600080fd5b5061015a61057d565b6040516101679190611e8e565b60405180910390f35b34801561017c57600080fd5b5061019760048036038101906101929190611f22565b61058c565b6040516101a49190611f6a565b60405180910390f35b3480156101b957600080fd5b506101d460048036038101906101cf9190611fbb565b61059e565b6040516101e19190611f6a565b60405180910390f35b3480156101f657600080fd5b50610211600480360381019061020c9190611f22565b61061a565b005b34801561021f57600080fd5b506102286106f2565b604051610235919061200a565b60405180910390f35b34801561024a57600080fd5b5061026560048036038101906102609190612025565b61071c565b6040516102729190611f6a565b60405180910390f35b34801561028757600080fd5b506102906107fc565b60405161029d9190612094565b60405180910390f35b3480156102b257600080fd5b506102bb61080b565b6040516102c89190611e8e565b60405180910390f35b3480156102dd57600080fd5b506102f860048036038101906102f391906121e4565b61081a565b005b34801561030657600080fd5b5061030f6108da565b60405161031c919061224f565b60405180910390f35b34801561033157600080fd5b5061034c60048036038101906103479190611f22565b6108e9565b005b34801561035a57600080fd5b5061037560048036038101906103709190611f22565b61098a565b604051610382919061200a565b60405180910390f35b34801561039757600080fd5b506103a061099c565b6040516103ad9190612328565b60405180910390f35b3480156103c257600080fd5b506103cb610a55565b6040516103d89190611e8e565b60405180910390f35b3480156103ed57600080fd5b506104086004803603810190610403919061234a565b610a64565b6040516104159190611f6a565b60405180910390f35b34801561042a57600080fd5b5061044560048036038101906104409190611fbb565b610b09565b6040516104529190611f6a565b60405180910390f35b34801561046757600080fd5b50610470610bc2565b60405161047d9190612328565b60405180910390f35b34801561049257600080fd5b506104ad60048036038101906104a89190612435565b610c56565b005b3480156104bb57600080fd5b506104c4610ea2565b6040516104d1919061200a565b60405180910390f35b3480156104e657600080fd5b5061050160048036038101906104fc9190612495565b610ed6565b60405161050e919061200a565b60405180910390f35b34801561052357600080fd5b5061053e600480360381019061053991906124d5565b610eea565b60405161054b9190611f6a565b60405180910390f35b34801561056057600080fd5b5061057b60048036038101906105769190612574565b610efc565b005b606061058761104a565b905090565b60006105978261107e565b9050919050565b60006105ab3384846110b6565b8273ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92584604051610608919061200a565b60405180910390a36001905092915050565b6106233361107e565b61062c57600080fd5b6106358161107e565b61063e57600080fd5b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff160361067757600080fd5b3373ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036106af57600080fd5b6106b8816110ed565b7ff8d49fc529812e9a7c5c50e69c20f0dccc0db8fa95c98bc58cc9a4f1c1299eaf816040516106e7919061224f565b60405180910390a150565b600061071761070160006112c8565b610709611300565b61133490919063ffffffff16565b905090565b6000610744843361073f856107318933611357565b61133490919063ffffffff16565b6110b6565b6107688461076384610755886112c8565b61133490919063ffffffff16565b611392565b61078c8361078784610779876112c8565b6113c690919063ffffffff16565b611392565b8273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef846040516107e9919061200a565b60405180910390a3600190509392505050565b60006108066113e9565b905090565b606061081561141d565b905090565b6108233361107e565b61082c57600080fd5b8073ffffffffffffffffffffffffffffffffffffffff1661084b611451565b73ffffffffffffffffffffffffffffffffffffffff160361086b57600080fd5b61087482611485565b61087d816114b6565b8073ffffffffffffffffffffffffffffffffffffffff16826040516108a29190612654565b60405180910390207f8e05e0e35ff592971ca8b477d4285a33a61ded208d644042667b78693a472f5e60405160405180910390a35050565b60006108e4611451565b905090565b6108f23361107e565b6108fb57600080fd5b6109048161107e565b1561090e57600080fd5b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff160361094757600080fd5b610950816114e7565b7f9465fa0c962cc76958e6373a993326400c1c94f8be2fe3a952adfa7f60b2ea268160405161097f919061224f565b60405180910390a150565b6000610995826112c8565b9050919050565b60606109cb6040516020016109b0906126b7565b60405160208183030381529060405280519060200120611593565b805480602002602001604051908101604052809291908181526020018280548015610a4b57602002820191906000526020600020905b8160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019060010190808311610a01575b5050505050905090565b6060610a5f6115af565b905090565b6000610a8a33610a8586610a77336112c8565b61133490919063ffffffff16565b611392565b610aac610aa785610a99611300565b61133490919063ffffffff16565b6115e3565b3373ffffffffffffffffffffffffffffffffffffffff167fbf4bee5506452a156854c54e249d6b04b0cd83287ba208202be81a4f87a55739858585604051610af6939291906126d7565b60405180910390a2600190509392505050565b6000610b2f33610b2a84610b1c336112c8565b61133490919063ffffffff16565b611392565b610b5383610b4e84610b40876112c8565b6113c690919063ffffffff16565b611392565b8273ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef84604051610bb0919061200a565b60405180910390a36001905092915050565b6060610bcc611614565b805480602002602001604051908101604052809291908181526020018280548015610c4c57602002820191906000526020600020905b8160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019060010190808311610c02575b5050505050905090565b610c5f3361107e565b610c6857600080fd5b60008111610cab576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ca29061278e565b60405180910390fd5b60008383905011610cf1576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ce890612820565b60405180910390fd5b82829050811115610d37576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d2e906128d8565b60405180910390fd5b610d64604051602001610d49906126b7565b60405160208183030381529060405280519060200120611648565b6000610d93604051602001610d78906126b7565b60405160208183030381529060405280519060200120611593565b905060005b84849050811015610e3f5781858583818110610db757610db66128f8565b5b9050602002016020810190610dcc9190611f22565b9080600181540180825580915050600190039060005260206000200160009091909190916101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508080610e3790612956565b915050610d98565b50610e6e604051602001610e52906126b7565b604051602081830303815290604052805190602001208261166a565b610e9c604051602001610e80906129ea565b6040516020818303038152906040528051906020012083611691565b50505050565b6000610ed1604051602001610eb6906129ea565b604051602081830303815290604052805190602001206116ac565b905090565b6000610ee28383611357565b905092915050565b6000610ef5826116c8565b9050919050565b610f05836116c8565b15610f45576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f3c90612a56565b60405180910390fd5b6000858585604051602001610f5c93929190612a76565b604051602081830303815290604052805190602001209050610f8f610f7f61099c565b8484610f89610ea2565b85611700565b610f9884611830565b610fbc86610fb787610fa98a6112c8565b6113c690919063ffffffff16565b611392565b610fde610fd986610fcb611300565b6113c690919063ffffffff16565b6115e3565b83604051610fec9190612654565b60405180910390208673ffffffffffffffffffffffffffffffffffffffff167f85a66b9141978db9980f7e0ce3b468cebf4f7999f32b23091c5c03e798b1ba7a8760405161103a919061200a565b60405180910390a3505050505050565b606061107960405160200161105e90612b00565b60405160208183030381529060405280519060200120611864565b905090565b60006110af826040516020016110949190612b6c565b60405160208183030381529060405280519060200120611909565b9050919050565b6110e883836040516020016110cc929190612be6565b6040516020818303038152906040528051906020012082611691565b505050565b60006110f7611614565b905060005b6001828054905061110d9190612c22565b811015611245578273ffffffffffffffffffffffffffffffffffffffff1682828154811061113e5761113d6128f8565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16036112325781600183805490506111969190612c22565b815481106111a7576111a66128f8565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff168282815481106111e5576111e46128f8565b5b9060005260206000200160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550611245565b808061123d90612956565b9150506110fc565b508080548061125757611256612c56565b5b6001900381819060005260206000200160006101000a81549073ffffffffffffffffffffffffffffffffffffffff0219169055905561129581611933565b6112c4826040516020016112a99190612b6c565b60405160208183030381529060405280519060200120611964565b5050565b60006112f9826040516020016112de9190612cd1565b604051602081830303815290604052805190602001206116ac565b9050919050565b600061132f60405160200161131490612d4b565b604051602081830303815290604052805190602001206116ac565b905090565b60008282111561134357600080fd5b818361134f9190612c22565b905092915050565b600061138a838360405160200161136f929190612be6565b604051602081830303815290604052805190602001206116ac565b905092915050565b6113c2826040516020016113a69190612cd1565b6040516020818303038152906040528051906020012082611691565b5050565b600081836113d49190612d6b565b9050828110156113e357600080fd5b92915050565b60006114186040516020016113fd90612deb565b604051602081830303815290604052805190602001206116ac565b905090565b606061144c60405160200161143190612e57565b60405160208183030381529060405280519060200120611864565b905090565b600061148060405160200161146590612ec3565b6040516020818303038152906040528051906020012061198a565b905090565b6114b360405160200161149790612e57565b60405160208183030381529060405280519060200120826119c7565b50565b6114e46040516020016114c890612ec3565b60405160208183030381529060405280519060200120826119ec565b50565b60006114f1611614565b905080829080600181540180825580915050600190039060005260206000200160009091909190916101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555061155e81611933565b61158f826040516020016115729190612b6c565b604051602081830303815290604052805190602001206001611a42565b5050565b6000600360008381526020019081526020016000209050919050565b60606115de6040516020016115c390612f2f565b60405160208183030381529060405280519060200120611864565b905090565b6116116040516020016115f590612d4b565b6040516020818303038152906040528051906020012082611691565b50565b600061164360405160200161162890612f9b565b60405160208183030381529060405280519060200120611593565b905090565b6003600082815260200190815260200160002060006116679190611d6e565b50565b806003600084815260200190815260200160002090805461168c929190611d8f565b505050565b80600080848152602001908152602001600020819055505050565b6000806000838152602001908152602001600020549050919050565b60006116f9826040516020016116de919061309f565b60405160208183030381529060405280519060200120611909565b9050919050565b6000805b86518110156117e0576000868683818110611722576117216128f8565b5b905060600201600001602081019061173a9190613126565b60ff16146117cd5761177f878281518110611758576117576128f8565b5b602002602001015184888885818110611774576117736128f8565b5b905060600201611a71565b6117b5576040517f8baa579f00000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6001826117c29190612d6b565b9150838210156117e0575b80806117d890612956565b915050611704565b50828110156118285780836040517ff1e86aa600000000000000000000000000000000000000000000000000000000815260040161181f929190613153565b60405180910390fd5b505050505050565b61186181604051602001611844919061309f565b604051602081830303815290604052805190602001206001611a42565b50565b6060600160008381526020019081526020016000208054611884906131ab565b80601f01602080910402602001604051908101604052809291908181526020018280546118b0906131ab565b80156118fd5780601f106118d2576101008083540402835291602001916118fd565b820191906000526020600020905b8154815290600101906020018083116118e057829003601f168201915b50505050509050919050565b60006005600083815260200190815260200160002060009054906101000a900460ff169050919050565b61196160405160200161194590612f9b565b604051602081830303815290604052805190602001208261166a565b50565b6005600082815260200190815260200160002060006101000a81549060ff021916905550565b60006002600083815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050919050565b806001600084815260200190815260200160002090816119e79190613388565b505050565b806002600084815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055505050565b806005600084815260200190815260200160002060006101000a81548160ff0219169083151502179055505050565b60008083604051602001611a8591906134d1565b604051602081830303815290604052805190602001209050611ac381846000016020810190611ab49190613126565b85602001358660400135611afb565b73ffffffffffffffffffffffffffffffffffffffff168573ffffffffffffffffffffffffffffffffffffffff16149150509392505050565b6000806000611b0c87878787611b26565b91509150611b1981611c08565b8192505050949350505050565b6000807f7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a08360001c1115611b61576000600391509150611bff565b600060018787878760405160008152602001604052604051611b869493929190613506565b6020604051602081039080840390855afa158015611ba8573d6000803e3d6000fd5b505050602060405103519050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603611bf657600060019250925050611bff565b80600092509250505b94509492505050565b60006004811115611c1c57611c1b61354b565b5b816004811115611c2f57611c2e61354b565b5b0315611d6b5760016004811115611c4957611c4861354b565b5b816004811115611c5c57611c5b61354b565b5b03611c9c576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611c93906135c6565b60405180910390fd5b60026004811115611cb057611caf61354b565b5b816004811115611cc357611cc261354b565b5b03611d03576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611cfa90613632565b60405180910390fd5b60036004811115611d1757611d1661354b565b5b816004811115611d2a57611d2961354b565b5b03611d6a576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611d61906136c4565b60405180910390fd5b5b50565b5080546000825590600052602060002090810190611d8c9190611de1565b50565b828054828255906000526020600020908101928215611dd05760005260206000209182015b82811115611dcf578254825591600101919060010190611db4565b5b509050611ddd9190611de1565b5090565b5b80821115611dfa576000816000905550600101611de2565b5090565b600081519050919050565b600082825260208201905092915050565b60005b83811015611e38578082015181840152602081019050611e1d565b60008484015250505050565b6000601f19601f8301169050919050565b6000611e6082611dfe565b611e6a8185611e09565b9350611e7a818560208601611e1a565b611e8381611e44565b840191505092915050565b60006020820190508181036000830152611ea88184611e55565b905092915050565b6000604051905090565b600080fd5b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000611eef82611ec4565b9050919050565b611eff81611ee4565b8114611f0a57600080fd5b50565b600081359050611f1c81611ef6565b92915050565b600060208284031215611f3857611f37611eba565b5b6000611f4684828501611f0d565b91505092915050565b60008115159050919050565b611f6481611f4f565b82525050565b6000602082019050611f7f6000830184611f5b565b92915050565b6000819050919050565b611f9881611f85565b8114611fa357600080fd5b50565b600081359050611fb581611f8f565b92915050565b60008060408385031215611fd257611fd1611eba565b5b6000611fe085828601611f0d565b9250506020611ff185828601611fa6565b9150509250929050565b61200481611f85565b82525050565b600060208201905061201f6000830184611ffb565b92915050565b60008060006060848603121561203e5761203d611eba565b5b600061204c86828701611f0d565b935050602061205d86828701611f0d565b925050604061206e86828701611fa6565b9150509250925092565b600060ff82169050919050565b61208e81612078565b82525050565b60006020820190506120a96000830184612085565b92915050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b6120f182611e44565b810181811067ffffffffffffffff821117156121105761210f6120b9565b5b80604052505050565b6000612123611eb0565b905061212f82826120e8565b919050565b600067ffffffffffffffff82111561214f5761214e6120b9565b5b61215882611e44565b9050602081019050919050565b82818337600083830152505050565b600061218761218284612134565b612119565b9050828152602081018484840111156121a3576121a26120b4565b5b6121ae848285612165565b509392505050565b600082601f8301126121cb576121ca6120af565b5b81356121db848260208601612174565b91505092915050565b600080604083850312156121fb576121fa611eba565b5b600083013567ffffffffffffffff81111561221957612218611ebf565b5b612225858286016121b6565b925050602061223685828601611f0d565b9150509250929050565b61224981611ee4565b82525050565b60006020820190506122646000830184612240565b92915050565b600081519050919050565b600082825260208201905092915050565b6000819050602082019050919050565b61229f81611ee4565b82525050565b60006122b18383612296565b60208301905092915050565b6000602082019050919050565b60006122d58261226a565b6122df8185612275565b93506122ea83612286565b8060005b8381101561231b57815161230288826122a5565b975061230d836122bd565b9250506001810190506122ee565b5085935050505092915050565b6000602082019050818103600083015261234281846122ca565b905092915050565b60008060006060848603121561236357612362611eba565b5b600061237186828701611fa6565b935050602084013567ffffffffffffffff81111561239257612391611ebf565b5b61239e868287016121b6565b925050604084013567ffffffffffffffff8111156123bf576123be611ebf565b5b6123cb868287016121b6565b9150509250925092565b600080fd5b600080fd5b60008083601f8401126123f5576123f46120af565b5b8235905067ffffffffffffffff811115612412576124116123d5565b5b60208301915083602082028301111561242e5761242d6123da565b5b9250929050565b60008060006040848603121561244e5761244d611eba565b5b600084013567ffffffffffffffff81111561246c5761246b611ebf565b5b612478868287016123df565b9350935050602061248b86828701611fa6565b9150509250925092565b600080604083850312156124ac576124ab611eba565b5b60006124ba85828601611f0d565b92505060206124cb85828601611f0d565b9150509250929050565b6000602082840312156124eb576124ea611eba565b5b600082013567ffffffffffffffff81111561250957612508611ebf565b5b612515848285016121b6565b91505092915050565b60008083601f840112612534576125336120af565b5b8235905067ffffffffffffffff811115612551576125506123d5565b5b60208301915083606082028301111561256d5761256c6123da565b5b9250929050565b6000806000806000608086880312156125905761258f611eba565b5b600061259e88828901611f0d565b95505060206125af88828901611fa6565b945050604086013567ffffffffffffffff8111156125d0576125cf611ebf565b5b6125dc888289016121b6565b935050606086013567ffffffffffffffff8111156125fd576125fc611ebf565b5b6126098882890161251e565b92509250509295509295909350565b600081905092915050565b600061262e82611dfe565b6126388185612618565b9350612648818560208601611e1a565b80840191505092915050565b60006126608284612623565b915081905092915050565b7f7369676e65727300000000000000000000000000000000000000000000000000600082015250565b60006126a1600783611e09565b91506126ac8261266b565b602082019050919050565b600060208201905081810360008301526126d081612694565b9050919050565b60006060820190506126ec6000830186611ffb565b81810360208301526126fe8185611e55565b905081810360408301526127128184611e55565b9050949350505050565b7f7369676e6174757265735265717569726564206d75737420626520677265617460008201527f6572207468616e20300000000000000000000000000000000000000000000000602082015250565b6000612778602983611e09565b91506127838261271c565b604082019050919050565b600060208201905081810360008301526127a78161276b565b9050919050565b7f6e65775369676e657273206d7573742062652067726561746572207468616e2060008201527f3000000000000000000000000000000000000000000000000000000000000000602082015250565b600061280a602183611e09565b9150612815826127ae565b604082019050919050565b60006020820190508181036000830152612839816127fd565b9050919050565b7f7369676e6174757265735265717569726564206d757374206265206c6573732060008201527f6f7220657175616c207468616e20746865206e756d626572206f66207369676e60208201527f6572730000000000000000000000000000000000000000000000000000000000604082015250565b60006128c2604383611e09565b91506128cd82612840565b606082019050919050565b600060208201905081810360008301526128f1816128b5565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061296182611f85565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff820361299357612992612927565b5b600182019050919050565b7f7369676e61747572657352657175697265640000000000000000000000000000600082015250565b60006129d4601283611e09565b91506129df8261299e565b602082019050919050565b60006020820190508181036000830152612a03816129c7565b9050919050565b7f544654207472616e736163746f6e20494420616c7265616479206b6e6f776e00600082015250565b6000612a40601f83611e09565b9150612a4b82612a0a565b602082019050919050565b60006020820190508181036000830152612a6f81612a33565b9050919050565b6000606082019050612a8b6000830186612240565b612a986020830185611ffb565b8181036040830152612aaa8184611e55565b9050949350505050565b7f6e616d6500000000000000000000000000000000000000000000000000000000600082015250565b6000612aea600483611e09565b9150612af582612ab4565b602082019050919050565b60006020820190508181036000830152612b1981612add565b9050919050565b7f6f776e6572000000000000000000000000000000000000000000000000000000600082015250565b6000612b56600583611e09565b9150612b6182612b20565b602082019050919050565b60006040820190508181036000830152612b8581612b49565b9050612b946020830184612240565b92915050565b7f616c6c6f77656400000000000000000000000000000000000000000000000000600082015250565b6000612bd0600783611e09565b9150612bdb82612b9a565b602082019050919050565b60006060820190508181036000830152612bff81612bc3565b9050612c0e6020830185612240565b612c1b6040830184612240565b9392505050565b6000612c2d82611f85565b9150612c3883611f85565b9250828203905081811115612c5057612c4f612927565b5b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603160045260246000fd5b7f62616c616e636500000000000000000000000000000000000000000000000000600082015250565b6000612cbb600783611e09565b9150612cc682612c85565b602082019050919050565b60006040820190508181036000830152612cea81612cae565b9050612cf96020830184612240565b92915050565b7f746f74616c537570706c79000000000000000000000000000000000000000000600082015250565b6000612d35600b83611e09565b9150612d4082612cff565b602082019050919050565b60006020820190508181036000830152612d6481612d28565b9050919050565b6000612d7682611f85565b9150612d8183611f85565b9250828201905080821115612d9957612d98612927565b5b92915050565b7f646563696d616c73000000000000000000000000000000000000000000000000600082015250565b6000612dd5600883611e09565b9150612de082612d9f565b602082019050919050565b60006020820190508181036000830152612e0481612dc8565b9050919050565b7f76657273696f6e00000000000000000000000000000000000000000000000000600082015250565b6000612e41600783611e09565b9150612e4c82612e0b565b602082019050919050565b60006020820190508181036000830152612e7081612e34565b9050919050565b7f696d706c656d656e746174696f6e000000000000000000000000000000000000600082015250565b6000612ead600e83611e09565b9150612eb882612e77565b602082019050919050565b60006020820190508181036000830152612edc81612ea0565b9050919050565b7f73796d626f6c0000000000000000000000000000000000000000000000000000600082015250565b6000612f19600683611e09565b9150612f2482612ee3565b602082019050919050565b60006020820190508181036000830152612f4881612f0c565b9050919050565b7f6f776e6572730000000000000000000000000000000000000000000000000000600082015250565b6000612f85600683611e09565b9150612f9082612f4f565b602082019050919050565b60006020820190508181036000830152612fb481612f78565b9050919050565b7f6d696e7400000000000000000000000000000000000000000000000000000000600082015250565b6000612ff1600483611e09565b9150612ffc82612fbb565b602082019050919050565b7f7472616e73616374696f6e000000000000000000000000000000000000000000600082015250565b600061303d600b83611e09565b915061304882613007565b602082019050919050565b7f6964000000000000000000000000000000000000000000000000000000000000600082015250565b6000613089600283611e09565b915061309482613053565b602082019050919050565b600060808201905081810360008301526130b881612fe4565b905081810360208301526130cb81613030565b905081810360408301526130de8161307c565b905081810360608301526130f28184611e55565b905092915050565b61310381612078565b811461310e57600080fd5b50565b600081359050613120816130fa565b92915050565b60006020828403121561313c5761313b611eba565b5b600061314a84828501613111565b91505092915050565b60006040820190506131686000830185611ffb565b6131756020830184611ffb565b9392505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806131c357607f821691505b6020821081036131d6576131d561317c565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b60006008830261323e7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82613201565b6132488683613201565b95508019841693508086168417925050509392505050565b6000819050919050565b600061328561328061327b84611f85565b613260565b611f85565b9050919050565b6000819050919050565b61329f8361326a565b6132b36132ab8261328c565b84845461320e565b825550505050565b600090565b6132c86132bb565b6132d3818484613296565b505050565b5b818110156132f7576132ec6000826132c0565b6001810190506132d9565b5050565b601f82111561333c5761330d816131dc565b613316846131f1565b81016020851015613325578190505b613339613331856131f1565b8301826132d8565b50505b505050565b600082821c905092915050565b600061335f60001984600802613341565b1980831691505092915050565b6000613378838361334e565b9150826002028217905092915050565b61339182611dfe565b67ffffffffffffffff8111156133aa576133a96120b9565b5b6133b482546131ab565b6133bf8282856132fb565b600060209050601f8311600181146133f257600084156133e0578287015190505b6133ea858261336c565b865550613452565b601f198416613400866131dc565b60005b8281101561342857848901518255600182019150602085019450602081019050613403565b868310156134455784890151613441601f89168261334e565b8355505b6001600288020188555050505b505050505050565b7f19457468657265756d205369676e6564204d6573736167653a0a333200000000600082015250565b6000613490601c83612618565b915061349b8261345a565b601c82019050919050565b6000819050919050565b6000819050919050565b6134cb6134c6826134a6565b6134b0565b82525050565b60006134dc82613483565b91506134e882846134ba565b60208201915081905092915050565b613500816134a6565b82525050565b600060808201905061351b60008301876134f7565b6135286020830186612085565b61353560408301856134f7565b61354260608301846134f7565b95945050505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602160045260246000fd5b7f45434453413a20696e76616c6964207369676e61747572650000000000000000600082015250565b60006135b0601883611e09565b91506135bb8261357a565b602082019050919050565b600060208201905081810360008301526135df816135a3565b9050919050565b7f45434453413a20696e76616c6964207369676e6174757265206c656e67746800600082015250565b600061361c601f83611e09565b9150613627826135e6565b602082019050919050565b6000602082019050818103600083015261364b8161360f565b9050919050565b7f45434453413a20696e76616c6964207369676e6174757265202773272076616c60008201527f7565000000000000000000000000000000000000000000000000000000000000602082015250565b60006136ae602283611e09565b91506136b982613652565b604082019050919050565b600060208201905081810360008301526136dd816136a1565b905091905056fea2646970667358221220a4991054ed997b7d0c7bcd8327e8b67a9a31fa509fcd8f8446b7480f162be3a664736f6c63430008150033",
 }
 
 // TokenABI is the input ABI used to generate the binding from.
 // Deprecated: Use TokenMetaData.ABI instead.
 var TokenABI = TokenMetaData.ABI
 
+// TokenBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use TokenMetaData.Bin instead.
+var TokenBin = TokenMetaData.Bin
+
+// DeployToken deploys a new Ethereum contract, binding an instance of Token to it.
+func DeployToken(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Token, error) {
+	parsed, err := TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(TokenBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &Token{TokenCaller: TokenCaller{contract: contract}, TokenTransactor: TokenTransactor{contract: contract}, TokenFilterer: TokenFilterer{contract: contract}}, nil
+}
+
 // Token is an auto generated Go binding around an Ethereum contract.
 type Token struct {
 	TokenCaller     // Read-only binding to the contract
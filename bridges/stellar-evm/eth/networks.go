@@ -2,53 +2,192 @@ package eth
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
 )
 
+// ConfirmationMode defines how the bridge decides that a block is confirmed
+type ConfirmationMode string
+
+const (
+	// ConfirmationBlocks waits for a fixed amount of blocks to be built on top of a block
+	ConfirmationBlocks ConfirmationMode = "blocks"
+	// ConfirmationSafe waits until a block is at or below the PoS "safe" block
+	ConfirmationSafe ConfirmationMode = "safe"
+	// ConfirmationFinalized waits until a block is at or below the PoS "finalized" block
+	ConfirmationFinalized ConfirmationMode = "finalized"
+)
+
+// ConfirmationPolicy defines how long the bridge waits before an event on the chain is acted upon
+type ConfirmationPolicy struct {
+	Mode ConfirmationMode
+	// Blocks is the amount of blocks to wait for in ConfirmationBlocks mode
+	Blocks uint64
+}
+
+// ParseConfirmationPolicy parses a confirmation policy, being either a number of blocks,
+// "safe" or "finalized".
+func ParseConfirmationPolicy(policy string) (ConfirmationPolicy, error) {
+	switch ConfirmationMode(policy) {
+	case ConfirmationSafe, ConfirmationFinalized:
+		return ConfirmationPolicy{Mode: ConfirmationMode(policy)}, nil
+	}
+	blocks, err := strconv.ParseUint(policy, 10, 64)
+	if err != nil {
+		return ConfirmationPolicy{}, fmt.Errorf("invalid confirmation policy %q, should be a number of blocks, %q or %q", policy, ConfirmationSafe, ConfirmationFinalized)
+	}
+	return ConfirmationPolicy{Mode: ConfirmationBlocks, Blocks: blocks}, nil
+}
+
+// Depth returns the amount of blocks a block is expected to be behind the chain head
+// before it is confirmed according to this policy.
+// For the PoS block tags this is an estimate, a safe block typically lags 1 epoch and
+// a finalized block 2 epochs of 32 slots.
+func (p ConfirmationPolicy) Depth() uint64 {
+	switch p.Mode {
+	case ConfirmationSafe:
+		return 32
+	case ConfirmationFinalized:
+		return 64
+	default:
+		return p.Blocks
+	}
+}
+
+// String implements the Stringer interface
+func (p ConfirmationPolicy) String() string {
+	if p.Mode == ConfirmationBlocks {
+		return fmt.Sprintf("%d blocks", p.Blocks)
+	}
+	return string(p.Mode)
+}
+
 // NetworkConfiguration defines the Ethereum network specific configuration needed by the bridge
 type NetworkConfiguration struct {
+	// NetworkID is the chain ID of the network
 	NetworkID       uint64
 	NetworkName     string
 	ContractAddress common.Address
+	Confirmations   ConfirmationPolicy
+	// RPCEndpoints are the urls of the ethereum nodes used if none is configured, in order of preference
+	RPCEndpoints []string
 }
 
-var ethNetworkConfigurations = map[string]NetworkConfiguration{
+// Networks are the network configurations by name
+type Networks map[string]NetworkConfiguration
+
+// DefaultConfirmations is the confirmation policy of a network that does not set one.
+// Waiting for safe or finalized blocks is opt-in with the --confirmations flag or a networks file.
+var DefaultConfirmations = ConfirmationPolicy{Mode: ConfirmationBlocks, Blocks: 3}
+
+// builtinNetworks are the networks known without a networks file
+var builtinNetworks = Networks{
 	"eth-mainnet": {
 		NetworkID:       1,
 		NetworkName:     "eth-mainnet",
 		ContractAddress: common.HexToAddress("0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"),
+		Confirmations:   DefaultConfirmations,
 	},
 	"sepolia-testnet": {
 		NetworkID:       11155111,
 		NetworkName:     "sepolia-testnet",
 		ContractAddress: common.HexToAddress("0x3022415B85F4d1E6ce8E9a25904f018455607416"),
-	},
-	"goerli-testnet": {
-		NetworkID:       5,
-		NetworkName:     "goerli-testnet",
-		ContractAddress: common.HexToAddress("0x33f92Ffd12A518ec3fe15875cAc8C8af45cF791d"),
+		Confirmations:   DefaultConfirmations,
 	},
 	"smart-chain-mainnet": {
 		NetworkID:       56,
 		NetworkName:     "bsc-mainnet",
 		ContractAddress: common.HexToAddress("0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"),
+		Confirmations:   DefaultConfirmations,
 	},
 	"smart-chain-testnet": {
 		NetworkID:       97,
 		NetworkName:     "bsc-testnet",
 		ContractAddress: common.HexToAddress("0x4DFe8A53cD9dbA17038cAaDB4cd6743160dAf049"),
+		Confirmations:   DefaultConfirmations,
 	},
 	"hardhat": {
 		NetworkID:       31337,
 		NetworkName:     "homestead",
 		ContractAddress: common.HexToAddress("0x4DFe8A53cD9dbA17038cAaDB4cd6743160dAf049"),
+		Confirmations:   DefaultConfirmations,
+		RPCEndpoints:    []string{"ws://localhost:8545"},
 	},
 }
 
-// GetEthNetworkConfiguration returns the EthNetworkConAfiguration for a specific network
-func GetEthNetworkConfiguration(networkname string) (networkconfig NetworkConfiguration, err error) {
-	networkconfig, found := ethNetworkConfigurations[networkname]
+// networksFile is the format of a networks file:
+//
+//	networks:
+//	  polygon-mainnet:
+//	    chainId: 137
+//	    contract: "0x..."
+//	    confirmations: "128"
+//	    rpc:
+//	      - wss://polygon.example.org
+type networksFile struct {
+	Networks map[string]struct {
+		ChainID       uint64   `yaml:"chainId"`
+		Contract      string   `yaml:"contract"`
+		Confirmations string   `yaml:"confirmations"`
+		RPC           []string `yaml:"rpc"`
+	} `yaml:"networks"`
+}
+
+// DefaultNetworks returns the built-in networks
+func DefaultNetworks() Networks {
+	networks := make(Networks, len(builtinNetworks))
+	for name, network := range builtinNetworks {
+		networks[name] = network
+	}
+	return networks
+}
+
+// LoadNetworks returns the built-in networks extended with the networks defined in the yaml file.
+// A network in the file replaces the built-in network with the same name.
+// If file is empty, only the built-in networks are returned.
+func LoadNetworks(file string) (Networks, error) {
+	networks := DefaultNetworks()
+	if file == "" {
+		return networks, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var parsed networksFile
+	if err = yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid networks file %s: %w", file, err)
+	}
+	for name, network := range parsed.Networks {
+		if network.ChainID == 0 {
+			return nil, fmt.Errorf("network %s in %s has no chainId", name, file)
+		}
+		if !common.IsHexAddress(network.Contract) {
+			return nil, fmt.Errorf("network %s in %s has an invalid contract address %q", name, file, network.Contract)
+		}
+		confirmations := DefaultConfirmations
+		if network.Confirmations != "" {
+			if confirmations, err = ParseConfirmationPolicy(network.Confirmations); err != nil {
+				return nil, fmt.Errorf("network %s in %s: %w", name, file, err)
+			}
+		}
+		networks[name] = NetworkConfiguration{
+			NetworkID:       network.ChainID,
+			NetworkName:     name,
+			ContractAddress: common.HexToAddress(network.Contract),
+			Confirmations:   confirmations,
+			RPCEndpoints:    network.RPC,
+		}
+	}
+	return networks, nil
+}
+
+// Get returns the configuration of a specific network
+func (n Networks) Get(networkname string) (networkconfig NetworkConfiguration, err error) {
+	networkconfig, found := n[networkname]
 	if !found {
 		err = fmt.Errorf("network %s not supported", networkname)
 	}
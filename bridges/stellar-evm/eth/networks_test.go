@@ -0,0 +1,70 @@
+package eth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfirmationPolicy(t *testing.T) {
+	p, err := ParseConfirmationPolicy("12")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfirmationPolicy{Mode: ConfirmationBlocks, Blocks: 12}, p)
+	assert.Equal(t, uint64(12), p.Depth())
+
+	p, err = ParseConfirmationPolicy("finalized")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfirmationFinalized, p.Mode)
+
+	p, err = ParseConfirmationPolicy("safe")
+	assert.NoError(t, err)
+	assert.Equal(t, ConfirmationSafe, p.Mode)
+
+	_, err = ParseConfirmationPolicy("latest")
+	assert.Error(t, err)
+	_, err = ParseConfirmationPolicy("-1")
+	assert.Error(t, err)
+}
+
+func TestLoadNetworks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "networks.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+networks:
+  polygon-mainnet:
+    chainId: 137
+    contract: "0x4DFe8A53cD9dbA17038cAaDB4cd6743160dAf049"
+    confirmations: "128"
+    rpc:
+      - wss://polygon.example.org
+  sepolia-testnet:
+    chainId: 11155111
+    contract: "0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"
+`), 0644))
+
+	networks, err := LoadNetworks(file)
+	require.NoError(t, err)
+
+	polygon, err := networks.Get("polygon-mainnet")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(137), polygon.NetworkID)
+	assert.Equal(t, ConfirmationPolicy{Mode: ConfirmationBlocks, Blocks: 128}, polygon.Confirmations)
+	assert.Equal(t, []string{"wss://polygon.example.org"}, polygon.RPCEndpoints)
+
+	sepolia, err := networks.Get("sepolia-testnet")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"), sepolia.ContractAddress)
+	assert.Equal(t, DefaultConfirmations, sepolia.Confirmations)
+
+	_, err = networks.Get("eth-mainnet")
+	assert.NoError(t, err, "built-in networks are kept")
+	_, err = DefaultNetworks().Get("polygon-mainnet")
+	assert.Error(t, err, "the built-in networks are not modified")
+
+	require.NoError(t, os.WriteFile(file, []byte("networks:\n  broken:\n    contract: \"0x4DFe8A53cD9dbA17038cAaDB4cd6743160dAf049\"\n"), 0644))
+	_, err = LoadNetworks(file)
+	assert.Error(t, err, "a network needs a chain id")
+}
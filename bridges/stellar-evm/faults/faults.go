@@ -4,3 +4,9 @@ package faults
 import "errors"
 
 var ErrInsufficientDepositAmount = errors.New("deposited amount is <= Fee")
+
+// ErrDepositOutsideLimits is returned for a deposit below the minimum or above the maximum mint amount
+var ErrDepositOutsideLimits = errors.New("deposited amount is outside the mint limits")
+
+// ErrVolumeCapExceeded is returned if a mint would exceed the mint volume caps, the mint should be retried later
+var ErrVolumeCapExceeded = errors.New("mint volume cap exceeded")
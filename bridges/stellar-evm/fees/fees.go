@@ -0,0 +1,249 @@
+// Package fees computes the deposit and withdraw fees of the bridge
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// precisionDigits is the amount of decimals of TFT, all amounts are in stroops
+	precisionDigits = 7
+	// basisPointsPerUnit is 100%
+	basisPointsPerUnit = 10000
+
+	tiersPrefix = "tiers:"
+)
+
+// Tier is the fee for the amounts up to and including UpTo
+type Tier struct {
+	UpTo int64
+	Fee  int64
+}
+
+// Schedule computes the fee for an amount. All amounts are in stroops.
+//
+// A schedule is a flat fee, a percentage in basis points bounded by Min and Max,
+// or a list of tiers. It is written as:
+//
+//	50                         a flat fee of 50 TFT
+//	0.5%,min=10,max=500        0.5% of the amount, at least 10 TFT and at most 500 TFT
+//	tiers:1000=5,10000=20,*=50 5 TFT up to 1000 TFT, 20 TFT up to 10000 TFT and 50 TFT above that
+type Schedule struct {
+	Flat int64
+	// BasisPoints is the fee in 1/100 of a percent of the amount
+	BasisPoints int64
+	Min         int64
+	// Max is the maximum fee of a percentage, 0 for no maximum
+	Max int64
+	// Tiers are sorted on UpTo, the last tier applies to all larger amounts
+	Tiers []Tier
+}
+
+// NewFlat creates a flat fee schedule
+func NewFlat(fee int64) Schedule {
+	return Schedule{Flat: fee}
+}
+
+// Fee returns the fee for the amount
+func (s Schedule) Fee(amount int64) int64 {
+	switch {
+	case len(s.Tiers) > 0:
+		for _, tier := range s.Tiers {
+			if amount <= tier.UpTo {
+				return tier.Fee
+			}
+		}
+		return s.Tiers[len(s.Tiers)-1].Fee
+	case s.BasisPoints > 0:
+		// use big numbers as the amount times the basis points might not fit an int64
+		fee := new(big.Int).Mul(big.NewInt(amount), big.NewInt(s.BasisPoints))
+		fee.Quo(fee, big.NewInt(basisPointsPerUnit))
+		if s.Max > 0 && fee.Cmp(big.NewInt(s.Max)) > 0 {
+			return s.Max
+		}
+		if fee.Cmp(big.NewInt(s.Min)) < 0 {
+			return s.Min
+		}
+		return fee.Int64()
+	default:
+		return s.Flat
+	}
+}
+
+// Validate checks if the schedule is consistent
+func (s Schedule) Validate() error {
+	if s.Flat < 0 || s.BasisPoints < 0 || s.Min < 0 || s.Max < 0 {
+		return errors.New("fees can not be negative")
+	}
+	if s.Max > 0 && s.Max < s.Min {
+		return errors.New("the maximum fee is lower than the minimum fee")
+	}
+	if s.BasisPoints > basisPointsPerUnit {
+		return errors.New("the fee can not be more than 100%")
+	}
+	for i, tier := range s.Tiers {
+		if tier.Fee < 0 {
+			return errors.New("fees can not be negative")
+		}
+		if i > 0 && tier.UpTo <= s.Tiers[i-1].UpTo {
+			return errors.New("the tiers should be sorted on increasing amounts")
+		}
+	}
+	return nil
+}
+
+// Parse parses a schedule as described on Schedule
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	var s Schedule
+	var err error
+	switch {
+	case strings.HasPrefix(spec, tiersPrefix):
+		s.Tiers, err = parseTiers(strings.TrimPrefix(spec, tiersPrefix))
+	case strings.Contains(spec, "%"):
+		err = s.parsePercentage(spec)
+	default:
+		s.Flat, err = parseAmount(spec)
+	}
+	if err != nil {
+		return Schedule{}, fmt.Errorf("invalid fee schedule %q: %w", spec, err)
+	}
+	if err = s.Validate(); err != nil {
+		return Schedule{}, fmt.Errorf("invalid fee schedule %q: %w", spec, err)
+	}
+	return s, nil
+}
+
+func (s *Schedule) parsePercentage(spec string) error {
+	parts := strings.Split(spec, ",")
+	percentage, found := strings.CutSuffix(strings.TrimSpace(parts[0]), "%")
+	if !found {
+		return errors.New("the percentage should come first")
+	}
+	value, err := decimal.NewFromString(percentage)
+	if err != nil {
+		return err
+	}
+	basisPoints := value.Shift(2)
+	if !basisPoints.IsInteger() {
+		return errors.New("the percentage can have at most 2 decimals")
+	}
+	s.BasisPoints = basisPoints.IntPart()
+	for _, part := range parts[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return fmt.Errorf("expected min=<amount> or max=<amount>, got %q", part)
+		}
+		amount, err := parseAmount(value)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "min":
+			s.Min = amount
+		case "max":
+			s.Max = amount
+		default:
+			return fmt.Errorf("unknown percentage bound %q", key)
+		}
+	}
+	return nil
+}
+
+func parseTiers(spec string) ([]Tier, error) {
+	var tiers []Tier
+	var catchAll *Tier
+	for _, part := range strings.Split(spec, ",") {
+		upTo, fee, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("expected <amount>=<fee>, got %q", part)
+		}
+		feeAmount, err := parseAmount(fee)
+		if err != nil {
+			return nil, err
+		}
+		if upTo == "*" {
+			catchAll = &Tier{UpTo: math.MaxInt64, Fee: feeAmount}
+			continue
+		}
+		upToAmount, err := parseAmount(upTo)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, Tier{UpTo: upToAmount, Fee: feeAmount})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].UpTo < tiers[j].UpTo })
+	if catchAll != nil {
+		tiers = append(tiers, *catchAll)
+	}
+	if len(tiers) == 0 {
+		return nil, errors.New("no tiers")
+	}
+	return tiers, nil
+}
+
+// parseAmount parses a TFT amount to stroops
+func parseAmount(value string) (int64, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	stroops := amount.Shift(precisionDigits)
+	if !stroops.IsInteger() {
+		return 0, fmt.Errorf("%s has more than %d decimals", value, precisionDigits)
+	}
+	return stroops.IntPart(), nil
+}
+
+func formatAmount(stroops int64) string {
+	return decimal.New(stroops, -precisionDigits).String()
+}
+
+// String formats the schedule in the format Parse accepts
+func (s Schedule) String() string {
+	switch {
+	case len(s.Tiers) > 0:
+		parts := make([]string, 0, len(s.Tiers))
+		for _, tier := range s.Tiers {
+			upTo := formatAmount(tier.UpTo)
+			if tier.UpTo == math.MaxInt64 {
+				upTo = "*"
+			}
+			parts = append(parts, upTo+"="+formatAmount(tier.Fee))
+		}
+		return tiersPrefix + strings.Join(parts, ",")
+	case s.BasisPoints > 0:
+		spec := decimal.New(s.BasisPoints, -2).String() + "%"
+		if s.Min > 0 {
+			spec += ",min=" + formatAmount(s.Min)
+		}
+		if s.Max > 0 {
+			spec += ",max=" + formatAmount(s.Max)
+		}
+		return spec
+	default:
+		return formatAmount(s.Flat)
+	}
+}
+
+// Set implements pflag.Value
+func (s *Schedule) Set(spec string) error {
+	parsed, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Type implements pflag.Value
+func (s *Schedule) Type() string {
+	return "feeschedule"
+}
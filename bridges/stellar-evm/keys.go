@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stellar/go/strkey"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+)
+
+// keystoreCommand encrypts a Stellar secret read from stdin into an ed25519 keystore file
+func keystoreCommand(args []string) {
+	fs := flag.NewFlagSet("keystore", flag.ExitOnError)
+	out := fs.String("out", "", "keystore file to create")
+	passwordFile := fs.String("password-file", "", "file containing the password to encrypt the key with")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge keystore --out <file> --password-file <file> < secret")
+		fmt.Fprintln(os.Stderr, "Reads a stellar secret from stdin and stores it encrypted in an ed25519 keystore file")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *out == "" || *passwordFile == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	secret, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && secret == "" {
+		panic(err)
+	}
+	key, err := keys.StellarSeed(strings.TrimSpace(secret))
+	if err != nil {
+		panic(err)
+	}
+	password, err := os.ReadFile(*passwordFile)
+	if err != nil {
+		panic(err)
+	}
+	content, err := keys.EncryptEd25519Key(key, strings.TrimSpace(string(password)))
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(*out, content, 0600); err != nil {
+		panic(err)
+	}
+	address, err := strkey.Encode(strkey.VersionByteAccountID, keys.NewLocalEd25519Signer(key).PublicKey())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(address)
+}
+
+// remoteSignerCommand runs the reference remote signer
+func remoteSignerCommand(args []string) {
+	fs := flag.NewFlagSet("remotesigner", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8900", "address to listen on")
+	tokenFile := fs.String("token-file", "", "file containing the token clients need to authenticate with")
+	var keyOptions keys.Options
+	fs.StringVar(&keyOptions.PasswordFile, "keystore-password-file", "", "file containing the password of the keystore files")
+	stellarKeys := fs.StringArray("key", nil, "ed25519 key to serve as <name>=<key specification>, the raw key is a stellar secret")
+	ethKeys := fs.StringArray("ethkey", nil, "ethereum key to serve as <name>=<key specification>, the raw key is a hex encoded private key")
+	fs.Parse(args)
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(true))))
+
+	token := ""
+	if *tokenFile != "" {
+		content, err := os.ReadFile(*tokenFile)
+		if err != nil {
+			panic(err)
+		}
+		token = strings.TrimSpace(string(content))
+	} else {
+		log.Warn("No token file provided, the remote signer accepts requests from everyone who can reach it")
+	}
+
+	server := keys.NewRemoteSignerServer(token)
+	for _, key := range *stellarKeys {
+		name, spec, found := strings.Cut(key, "=")
+		if !found {
+			panic(fmt.Sprintf("invalid key %q, expected <name>=<key specification>", key))
+		}
+		signer, err := keys.NewEd25519Signer(spec, keyOptions, keys.StellarSeed)
+		if err != nil {
+			panic(err)
+		}
+		server.AddEd25519(name, signer)
+		log.Info("Serving ed25519 key", "name", name, "url", fmt.Sprintf("http://%s/keys/%s", *listen, name))
+	}
+	for _, key := range *ethKeys {
+		name, spec, found := strings.Cut(key, "=")
+		if !found {
+			panic(fmt.Sprintf("invalid key %q, expected <name>=<key specification>", key))
+		}
+		signer, err := keys.NewEthSigner(spec, keyOptions)
+		if err != nil {
+			panic(err)
+		}
+		if err = server.AddEth(name, signer); err != nil {
+			panic(err)
+		}
+		log.Info("Serving ethereum key", "name", name, "address", signer.Address(), "url", fmt.Sprintf("http://%s/keys/%s", *listen, name))
+	}
+
+	if err := http.ListenAndServe(*listen, server); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Package keys loads the keys the bridge signs with.
+
+A key is configured with a key specification, which is one of:
+
+	keystore:<path>  an encrypted keystore file, the password is read from Options.PasswordFile
+	remote:<url>     a remote signer, see RemoteSignerServer for the protocol
+	<raw key>        the key itself, for backwards compatibility
+
+Keystore files for Ethereum keys are regular geth keystore files, Ed25519 keys
+(Stellar and libp2p) use an Ed25519Keystore file.
+*/
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stellar/go/strkey"
+)
+
+const (
+	keystorePrefix = "keystore:"
+	remotePrefix   = "remote:"
+)
+
+// Ed25519Signer signs messages with an ed25519 key without exposing it
+type Ed25519Signer interface {
+	PublicKey() ed25519.PublicKey
+	Sign(message []byte) ([]byte, error)
+}
+
+// EthSigner signs hashes with a secp256k1 key without exposing it
+type EthSigner interface {
+	Address() common.Address
+	// SignHash signs a 32 byte hash, the signature is in the [R || S || V] format where V is 0 or 1
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// Options are the settings needed to load the keys from a key specification
+type Options struct {
+	// PasswordFile is the file containing the password of the keystore files
+	PasswordFile string
+	// RemoteTokenFile is the file containing the bearer token to authenticate to a remote signer
+	RemoteTokenFile string
+}
+
+// RawEd25519Parser parses a raw key as given in a key specification
+type RawEd25519Parser func(raw string) (ed25519.PrivateKey, error)
+
+// StellarSeed parses a Stellar secret
+func StellarSeed(raw string) (ed25519.PrivateKey, error) {
+	seed, err := strkey.Decode(strkey.VersionByteSeed, raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid seed size '%d' expecting '%d'", len(seed), ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// NewEd25519Signer loads the ed25519 key of the key specification, raw keys are parsed with parseRaw.
+func NewEd25519Signer(spec string, opts Options, parseRaw RawEd25519Parser) (Ed25519Signer, error) {
+	switch {
+	case spec == "":
+		return nil, errors.New("no key configured")
+	case strings.HasPrefix(spec, keystorePrefix):
+		password, err := readSecretFile(opts.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the keystore password: %w", err)
+		}
+		key, err := LoadEd25519Keystore(strings.TrimPrefix(spec, keystorePrefix), password)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalEd25519Signer(key), nil
+	case strings.HasPrefix(spec, remotePrefix):
+		token, err := readOptionalSecretFile(opts.RemoteTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the remote signer token: %w", err)
+		}
+		return NewRemoteEd25519Signer(strings.TrimPrefix(spec, remotePrefix), token)
+	default:
+		key, err := parseRaw(spec)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalEd25519Signer(key), nil
+	}
+}
+
+// NewEthSigner loads the secp256k1 key of the key specification, a raw key is a hex encoded private key.
+func NewEthSigner(spec string, opts Options) (EthSigner, error) {
+	switch {
+	case spec == "":
+		return nil, errors.New("no key configured")
+	case strings.HasPrefix(spec, keystorePrefix):
+		password, err := readSecretFile(opts.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the keystore password: %w", err)
+		}
+		keyjson, err := os.ReadFile(strings.TrimPrefix(spec, keystorePrefix))
+		if err != nil {
+			return nil, err
+		}
+		key, err := keystore.DecryptKey(keyjson, password)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalEthSigner(key.PrivateKey), nil
+	case strings.HasPrefix(spec, remotePrefix):
+		token, err := readOptionalSecretFile(opts.RemoteTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the remote signer token: %w", err)
+		}
+		return NewRemoteEthSigner(strings.TrimPrefix(spec, remotePrefix), token)
+	default:
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(spec, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalEthSigner(key), nil
+	}
+}
+
+type localEd25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewLocalEd25519Signer returns an Ed25519Signer for a key in memory
+func NewLocalEd25519Signer(key ed25519.PrivateKey) Ed25519Signer {
+	return &localEd25519Signer{key: key}
+}
+
+func (s *localEd25519Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *localEd25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, message), nil
+}
+
+type localEthSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewLocalEthSigner returns an EthSigner for a key in memory
+func NewLocalEthSigner(key *ecdsa.PrivateKey) EthSigner {
+	return &localEthSigner{key: key}
+}
+
+func (s *localEthSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *localEthSigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+// readSecretFile reads a file containing a single secret, surrounding whitespace is ignored
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("no file configured")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func readOptionalSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	return readSecretFile(path)
+}
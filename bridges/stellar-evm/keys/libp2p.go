@@ -0,0 +1,52 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	pb "github.com/libp2p/go-libp2p/core/crypto/pb"
+)
+
+// libp2pKey makes an Ed25519Signer usable as the identity of a libp2p host
+type libp2pKey struct {
+	signer Ed25519Signer
+}
+
+// Libp2pKey returns a libp2p private key which signs with the given signer.
+// The raw key is not available so the key can not be marshalled.
+func Libp2pKey(signer Ed25519Signer) (crypto.PrivKey, error) {
+	if len(signer.PublicKey()) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return &libp2pKey{signer: signer}, nil
+}
+
+func (k *libp2pKey) Equals(other crypto.Key) bool {
+	o, ok := other.(crypto.PrivKey)
+	if !ok {
+		return false
+	}
+	return k.GetPublic().Equals(o.GetPublic())
+}
+
+func (k *libp2pKey) Raw() ([]byte, error) {
+	return nil, errors.New("the raw key is not available")
+}
+
+func (k *libp2pKey) Type() pb.KeyType {
+	return pb.KeyType_Ed25519
+}
+
+func (k *libp2pKey) Sign(data []byte) ([]byte, error) {
+	return k.signer.Sign(data)
+}
+
+func (k *libp2pKey) GetPublic() crypto.PubKey {
+	pub, err := crypto.UnmarshalEd25519PublicKey(k.signer.PublicKey())
+	if err != nil {
+		// the public key size is checked when creating the key
+		panic(err)
+	}
+	return pub
+}
@@ -0,0 +1,48 @@
+package limits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tft = int64(1e7)
+
+func TestParse(t *testing.T) {
+	l, err := Parse("max=100000, min=100,daily=1000000,hourly=250000")
+	require.NoError(t, err)
+	assert.Equal(t, Limits{Min: 100 * tft, Max: 100000 * tft, Hourly: 250000 * tft, Daily: 1000000 * tft}, l)
+	assert.Equal(t, "min=100,max=100000,hourly=250000,daily=1000000", l.String())
+
+	l, err = Parse("")
+	require.NoError(t, err)
+	assert.Equal(t, Limits{}, l)
+
+	for _, spec := range []string{"min=10,max=5", "hourly=10,daily=5", "max=100,hourly=50", "max=100,daily=50", "weekly=5", "min", "min=0.00000001"} {
+		_, err = Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	l := Limits{Min: 10 * tft, Max: 1000 * tft, Hourly: 1500 * tft, Daily: 2000 * tft}
+
+	assert.ErrorIs(t, l.CheckAmount(5*tft), ErrBelowMinimum)
+	assert.ErrorIs(t, l.CheckAmount(1001*tft), ErrAboveMaximum)
+	assert.NoError(t, l.CheckAmount(10*tft))
+	assert.NoError(t, l.CheckAmount(1000*tft))
+
+	assert.NoError(t, l.CheckVolume(500*tft, Volume{Hour: 1000 * tft, Day: 1500 * tft}))
+	assert.ErrorIs(t, l.CheckVolume(501*tft, Volume{Hour: 1000 * tft, Day: 1000 * tft}), ErrCapExceeded)
+	assert.ErrorIs(t, l.CheckVolume(100*tft, Volume{Hour: 0, Day: 1950 * tft}), ErrCapExceeded)
+
+	// an amount above a cap would never fit it, it is refused instead of held
+	capped := Limits{Hourly: 100 * tft, Daily: 200 * tft}
+	assert.ErrorIs(t, capped.CheckAmount(101*tft), ErrAboveMaximum)
+	assert.ErrorIs(t, Limits{Daily: 200 * tft}.CheckAmount(201*tft), ErrAboveMaximum)
+	assert.NoError(t, capped.CheckAmount(100*tft))
+
+	assert.NoError(t, Limits{}.CheckAmount(1))
+	assert.NoError(t, Limits{}.CheckVolume(1e18, Volume{Hour: 1e18, Day: 1e18}))
+}
@@ -2,15 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	flag "github.com/spf13/pflag"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/api/bridge"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/config"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/policy"
 	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -18,27 +27,59 @@ import (
 
 var Version = "development"
 
+// envPrefix is the prefix of the environment variables the settings are read from
+const envPrefix = "BRIDGE"
+
 func main() {
 
 	if len(os.Args) > 1 && os.Args[1] == "version" {
 		fmt.Println(Version)
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "keystore" {
+		keystoreCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "remotesigner" {
+		remoteSignerCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		reconcileCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		auditCommand(os.Args[2:])
+		return
+	}
 	var bridgeCfg bridge.BridgeConfig
 	var stellarCfg stellar.StellarConfig
 	var ethCfg bridge.EthConfig
 	var bridgeMasterAddress string
 
 	flag.StringVar(&ethCfg.EthNetworkName, "ethnetwork", "eth-mainnet", "ethereum network name")
-	flag.StringVar(&ethCfg.EthUrl, "ethurl", "ws://localhost:8551", "ethereum rpc url")
+	flag.StringVar(&ethCfg.NetworksFile, "ethnetworks", "", "yaml file with ethereum network definitions, extends and overrides the built-in networks")
+	flag.StringVar(&ethCfg.EthUrl, "ethurl", "", "ethereum rpc url, defaults to the rpc endpoints of the network or "+bridge.DefaultEthUrl)
 	flag.StringVar(&ethCfg.ContractAddress, "contract", "", "token contract address")
+	flag.Uint64Var(&ethCfg.LogRange, "ethlogrange", 2000, "maximum amount of blocks to request logs for in a single call, lower this if the ethereum rpc provider limits the block range")
+	flag.Uint64Var(&ethCfg.Fees.MaxFeePerGas, "maxfee", 300, "maximum fee per gas in gwei for mint transactions, 0 for no limit")
+	flag.Uint64Var(&ethCfg.Fees.MaxPriorityFeePerGas, "maxpriorityfee", 3, "maximum priority fee per gas in gwei for mint transactions, 0 for no limit")
+	flag.Uint64Var(&ethCfg.Fees.BumpPercent, "feebump", 20, "percentage to increase the fees with when replacing a pending mint transaction, at least 10")
+	flag.DurationVar(&ethCfg.Fees.ReplaceAfter, "mintreplaceafter", 2*time.Minute, "time after which a pending mint transaction is replaced with higher fees")
+	flag.StringVar(&ethCfg.Confirmations, "confirmations", "", "confirmation policy, a number of blocks, safe or finalized, overrides the default of the ethereum network")
 
 	flag.StringVar(&bridgeCfg.PersistencyFile, "persistency", "./node.json", "file where last seen blockheight and stellar account cursor is stored")
 
-	flag.StringVar(&ethCfg.EthPrivateKey, "ethkey", "", "ethereum account private key")
+	var keyOptions keys.Options
+	flag.StringVar(&ethCfg.EthPrivateKey, "ethkey", "", "ethereum account key: keystore:<geth keystore file>, remote:<remote signer key url> or the hex encoded private key")
 
-	flag.StringVar(&stellarCfg.StellarSeed, "secret", "", "stellar secret")
-	flag.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet or production")
+	flag.StringVar(&stellarCfg.StellarSeed, "secret", "", "stellar account key: keystore:<ed25519 keystore file>, remote:<remote signer key url> or the stellar secret")
+	flag.StringVar(&keyOptions.PasswordFile, "keystore-password-file", "", "file containing the password of the keystore files")
+	flag.StringVar(&keyOptions.RemoteTokenFile, "remote-signer-token-file", "", "file containing the token to authenticate to the remote signer")
+	flag.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet, production or the name of a network configured with the horizon url, passphrase and asset issuer")
+	flag.StringVar(&stellarCfg.HorizonURL, "horizon-url", "", "horizon url, overrides the horizon of the stellar network")
+	flag.StringVar(&stellarCfg.NetworkPassphrase, "network-passphrase", "", "stellar network passphrase, overrides the passphrase of the stellar network")
+	flag.StringVar(&stellarCfg.AssetIssuer, "asset-issuer", "", "issuer of the TFT asset, overrides the issuer on the stellar network")
 	// Stellar account where fees are sent to
 	flag.StringVar(&stellarCfg.StellarFeeWallet, "feewallet", "", "stellar fee wallet address")
 
@@ -48,41 +89,106 @@ func main() {
 
 	flag.BoolVar(&bridgeCfg.Follower, "follower", false, "if true then the bridge will run in follower mode meaning that it will not submit mint transactions to the multisig contract, if false the bridge will also submit transactions")
 
+	flag.BoolVar(&bridgeCfg.Failover, "failover", false, "elect the master among the signers of the bridge account, the bridge acts as master while it holds the lease and as follower otherwise, --follower is ignored")
+
 	flag.StringVar(&bridgeMasterAddress, "master", "", "master stellar public address")
-	flag.Int64Var(&bridgeCfg.DepositFee, "depositFee", 50, "sets the depositfee in TFT")
+	bridgeCfg.DepositFee = fees.NewFlat(50 * stellar.Precision)
+	flag.Var(&bridgeCfg.DepositFee, "depositFee", "deposit fee schedule in TFT: a flat fee (50), a percentage (0.5%,min=10,max=500) or tiers (tiers:1000=5,10000=20,*=50)")
+	bridgeCfg.WithdrawFee = fees.NewFlat(1 * stellar.Precision)
+	flag.Var(&bridgeCfg.WithdrawFee, "withdrawFee", "withdraw fee schedule in TFT, in the same format as the deposit fee")
+	flag.Var(&bridgeCfg.MintLimits, "mintLimits", "limits on the deposited amounts in TFT: min=100,max=100000,hourly=250000,daily=1000000, all optional")
+	flag.Var(&bridgeCfg.WithdrawLimits, "withdrawLimits", "limits on the withdrawn amounts in TFT, in the same format as the mint limits")
 
 	// P2P Configuration
 	flag.StringVar(&bridgeCfg.Psk, "psk", "", "psk for the relay")
 	flag.StringVar(&bridgeCfg.Relay, "relay", "", "relay address")
 
+	flag.BoolVar(&bridgeCfg.DryRun, "dry-run", false, "build and validate the transactions of the master but record them in decisions.jsonl next to the persistency file instead of submitting them")
+	flag.BoolVar(&bridgeCfg.DryRunSignatures, "dry-run-signatures", false, "request the cosigner signatures for the transactions in dry-run mode")
+
+	var policyFile string
+	flag.StringVar(&policyFile, "policy", "", "yaml file with the signing policy a follower applies to the mints and withdrawals it signs")
+
+	var pauseFile string
+	flag.StringVar(&pauseFile, "pause-file", "", "the bridge is paused while this file exists, it can also be paused through the admin API or with SIGUSR1 and resumed with SIGUSR2")
+
+	flag.StringVar(&bridgeCfg.AdminAddr, "admin-addr", "", "address for the admin http server with the status, metrics, liveness and readiness endpoints, disabled if empty")
+	flag.StringVar(&bridgeCfg.AdminToken, "admin-token", "", "bearer token needed to pause and resume the bridge through the admin server, pausing through the admin server is refused if empty")
+
 	var debug bool
 	flag.BoolVar(&debug, "debug", false, "sets debug level log output")
 
+	flag.String(config.FileFlag, "", "yaml config file with flag names as keys, settings can also be given as BRIDGE_<FLAG> environment variables and read from files with <flag>_file or BRIDGE_<FLAG>_FILE")
+
 	flag.Parse()
+	bridgeCfg.Version = Version
 
-	if err := stellarCfg.Validate(); err != nil {
-		panic(err)
+	// the limits and fees are validated when they are parsed, their errors are in configErr
+	configErr := config.Load(flag.CommandLine, envPrefix)
+	var failoverErr error
+	if bridgeCfg.Failover && bridgeMasterAddress == "" {
+		failoverErr = errors.New("--failover needs the bridge account as --master")
+	}
+	signingPolicy, policyErr := policy.Load(policyFile)
+	_, networkErr := ethCfg.Network()
+	var relayErr error
+	if _, err := peer.AddrInfoFromString(bridgeCfg.Relay); err != nil {
+		relayErr = fmt.Errorf("invalid --relay: %w", err)
+	}
+	var adminErr error
+	if bridgeCfg.AdminAddr != "" {
+		if _, _, err := net.SplitHostPort(bridgeCfg.AdminAddr); err != nil {
+			adminErr = fmt.Errorf("invalid --admin-addr: %w", err)
+		}
+	}
+	// a missing secret is reported by the stellar config
+	var stellarSigner keys.Ed25519Signer
+	var keyErr error
+	if stellarCfg.StellarSeed != "" {
+		var err error
+		if stellarSigner, err = keys.NewEd25519Signer(stellarCfg.StellarSeed, keyOptions, keys.StellarSeed); err != nil {
+			keyErr = fmt.Errorf("failed to load the stellar key: %w", err)
+		}
+	}
+	ethSigner, err := keys.NewEthSigner(ethCfg.EthPrivateKey, keyOptions)
+	if err != nil {
+		keyErr = errors.Join(keyErr, fmt.Errorf("failed to load the ethereum key: %w", err))
 	}
+	if err := errors.Join(configErr, stellarCfg.Validate(), failoverErr, policyErr, networkErr, relayErr, adminErr, keyErr); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+	stellarCfg.SetNetwork()
 
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stdout, log.TerminalFormat(true))))
 	if debug {
 		log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StreamHandler(os.Stdout, log.TerminalFormat(true))))
 	}
 
-	log.Info("connection url provided: ", "url", ethCfg.EthUrl)
+	if ethCfg.EthUrl != "" {
+		log.Info("connection url provided: ", "url", ethCfg.EthUrl)
+	}
 
+	if err := run(&bridgeCfg, &stellarCfg, &ethCfg, bridgeMasterAddress, stellarSigner, ethSigner, signingPolicy, pauseFile); err != nil {
+		log.Error("Bridge stopped", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the bridge with the validated configuration and blocks until it gets SIGINT or SIGTERM or the admin server fails
+func run(bridgeCfg *bridge.BridgeConfig, stellarCfg *stellar.StellarConfig, ethCfg *bridge.EthConfig, bridgeMasterAddress string, stellarSigner keys.Ed25519Signer, ethSigner keys.EthSigner, signingPolicy policy.Policy, pauseFile string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	host, router, err := bridge.NewHost(ctx, stellarCfg.StellarSeed, bridgeCfg.Relay, bridgeCfg.Psk)
+	host, router, err := bridge.NewHost(ctx, stellarSigner, bridgeCfg.Relay, bridgeCfg.Psk)
 	if err != nil {
-		fmt.Println("failed to create host")
-		panic(err)
+		return fmt.Errorf("failed to create host: %w", err)
 	}
+	defer host.Close()
 
 	partialMA, err := multiaddr.NewMultiaddr(fmt.Sprintf("/p2p/%s", host.ID()))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	for _, addr := range host.Addrs() {
@@ -93,35 +199,52 @@ func main() {
 	txStorage := stellar.NewTransactionStorage(stellarCfg.StellarNetwork, bridgeMasterAddress)
 	err = txStorage.ScanBridgeAccount()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to scan the bridge account: %w", err)
 	}
 
-	stellarWallet, err := stellar.NewWallet(&stellarCfg, bridgeCfg.DepositFee, bridge.WithdrawFee, txStorage)
+	stellarWallet, err := stellar.NewWallet(stellarCfg, stellarSigner, bridgeCfg.DepositFee, bridgeCfg.WithdrawFee, txStorage)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create the stellar wallet: %w", err)
 	}
 	log.Info(fmt.Sprintf("Stellar wallet %s loaded on Stellar network %s", stellarWallet.GetAddress(), stellarCfg.StellarNetwork))
+	if bridgeCfg.Failover {
+		stellarWallet.SetBridgeAccount(bridgeMasterAddress)
+	}
 
-	contract, err := bridge.NewBridgeContract(&ethCfg)
+	contract, err := bridge.NewBridgeContract(ethCfg, ethSigner)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to load the token contract: %w", err)
 	}
 
-	br, err := bridge.NewBridge(ctx, stellarWallet, contract, &bridgeCfg, host, router)
+	pauseSwitch := pause.NewSwitch(pauseFile)
+	go pauseSwitch.HandleSignals(ctx)
+
+	br, err := bridge.NewBridge(ctx, stellarWallet, contract, bridgeCfg, host, router, pauseSwitch)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create the bridge: %w", err)
 	}
 
 	err = br.Start(ctx)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to start the bridge: %w", err)
+	}
+
+	failed := make(chan error, 1)
+	if bridgeCfg.AdminAddr != "" {
+		adminHandler := br.AdminHandler()
+		adminHandler.Handle("/metrics", metrics.Handler())
+		go func() {
+			if err := bridge.ServeAdmin(ctx, bridgeCfg.AdminAddr, adminHandler); err != nil {
+				failed <- fmt.Errorf("admin server failed: %w", err)
+			}
+		}()
 	}
 
-	// Start the signer server
-	if bridgeCfg.Follower {
-		err := bridge.NewSignerServer(host, bridgeMasterAddress, contract, stellarWallet, bridgeCfg.DepositFee)
+	// Start the signer server, with failover every bridge can be a follower
+	if bridgeCfg.Follower || bridgeCfg.Failover {
+		err := bridge.NewSignerServer(host, bridgeMasterAddress, contract, stellarWallet, bridgeCfg.DepositFee, bridgeCfg.WithdrawFee, pauseSwitch, signingPolicy, bridgeCfg.PersistencyFile, br.LeaseService(), br.NodeStatus)
 		if err != nil {
-			panic(err)
+			return errors.Join(fmt.Errorf("failed to start the signer server: %w", err), br.Close())
 		}
 	}
 
@@ -130,15 +253,15 @@ func main() {
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 	log.Info("awaiting signal")
-	sig := <-sigs
-	log.Info("signal", "signal", sig)
-	cancel()
-	err = br.Close()
-	if err != nil {
-		panic(err)
+	select {
+	case sig := <-sigs:
+		log.Info("signal", "signal", sig)
+	case err = <-failed:
 	}
+	cancel()
+	err = errors.Join(err, br.Close())
 
-	host.Close()
 	log.Info("exiting")
 	time.Sleep(time.Second * 5)
+	return err
 }
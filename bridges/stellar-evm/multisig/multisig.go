@@ -1,15 +1,213 @@
 package multisig
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"errors"
+	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+)
+
+// ProtocolVersion is the version of the signing protocol spoken by this bridge.
+//
+// Version 1 is the untyped StellarSignRequest from which the cosigner guesses what it signs,
+// version 2 adds the Hello exchange and the typed SignRequest.
+const ProtocolVersion = 2
+
+// MinProtocolVersion is the oldest version a cosigner still signs requests for,
+// so a master can be upgraded after the cosigners.
+const MinProtocolVersion = 1
+
+// ErrUnsupportedRequest is returned for a request of an unknown kind or protocol version
+var ErrUnsupportedRequest = errors.New("unsupported sign request")
+
+// RequestKind is the kind of transfer a cosigner is asked to sign
+type RequestKind string
+
+const (
+	KindWithdrawal  RequestKind = "withdrawal"
+	KindRefund      RequestKind = "refund"
+	KindFeeTransfer RequestKind = "fee"
+	KindMint        RequestKind = "mint"
+)
+
+// Kinds are the request kinds of this protocol version
+var Kinds = []RequestKind{KindWithdrawal, KindRefund, KindFeeTransfer, KindMint}
+
+// CheckVersion checks if a request of the given protocol version can be handled,
+// 0 is a request of a master from before the version was sent.
+func CheckVersion(version int) error {
+	if version == 0 {
+		version = 1
+	}
+	if version < MinProtocolVersion || version > ProtocolVersion {
+		return fmt.Errorf("%w: protocol version %d, supported are %d to %d", ErrUnsupportedRequest, version, MinProtocolVersion, ProtocolVersion)
+	}
+	return nil
+}
+
+// HelloRequest is sent by the master the first time it connects to a cosigner
+type HelloRequest struct {
+	Version int
+}
+
+// HelloResponse is the protocol version of the cosigner and the kinds of requests it signs
+type HelloResponse struct {
+	Version int
+	Kinds   []RequestKind
+}
+
+// StatusRequest asks a signer for its NodeStatus
+type StatusRequest struct{}
+
+// NodeStatus is the state of a signer, the master polls it to spot cosigners which would fail to sign
+type NodeStatus struct {
+	// Version is the release of the bridge
+	Version         string `json:"version"`
+	ProtocolVersion int    `json:"protocolVersion"`
+	// Height is the last ethereum head seen and Synced if the signer caught up with the chain
+	Height        uint64 `json:"height"`
+	Synced        bool   `json:"synced"`
+	StellarCursor string `json:"stellarCursor"`
+	// DepositFee and WithdrawFee are the fee schedules, the fees on the transactions are checked against them
+	DepositFee  string      `json:"depositFee"`
+	WithdrawFee string      `json:"withdrawFee"`
+	Pause       pause.State `json:"pause"`
+}
+
+// Mismatches describes the settings in which other differs from s which make signing fail
+func (s NodeStatus) Mismatches(other NodeStatus) []string {
+	var mismatches []string
+	if other.Version != s.Version {
+		mismatches = append(mismatches, fmt.Sprintf("version %s instead of %s", other.Version, s.Version))
+	}
+	if other.ProtocolVersion != s.ProtocolVersion {
+		mismatches = append(mismatches, fmt.Sprintf("protocol version %d instead of %d", other.ProtocolVersion, s.ProtocolVersion))
+	}
+	if other.DepositFee != s.DepositFee {
+		mismatches = append(mismatches, fmt.Sprintf("deposit fee %s instead of %s", other.DepositFee, s.DepositFee))
+	}
+	if other.WithdrawFee != s.WithdrawFee {
+		mismatches = append(mismatches, fmt.Sprintf("withdraw fee %s instead of %s", other.WithdrawFee, s.WithdrawFee))
+	}
+	if other.Pause.Paused && !s.Pause.Paused {
+		mismatches = append(mismatches, "paused: "+other.Pause.Reason)
+	}
+	return mismatches
+}
+
+// WithdrawalRequest is a payment of a Withdraw event to Stellar
+type WithdrawalRequest struct {
+	// Block is the block of the Withdraw event
+	Block uint64
+	// Sender is the ethereum address which withdrew the tokens
+	Sender common.Address
+}
+
+// RefundRequest is a refund of a deposit which can not be minted
+type RefundRequest struct {
+	// Deposit is the hex encoded hash of the deposit transaction
+	Deposit string
+}
+
+// FeeTransferRequest is the transfer of the fee of a deposit to the fee wallet
+type FeeTransferRequest struct {
+	// Deposit is the hex encoded hash of the deposit transaction
+	Deposit string
+}
+
+// SignRequest asks a cosigner to sign a Stellar transaction of the bridge account.
+// Exactly the payload of the kind is set.
+type SignRequest struct {
+	Version            int
+	Kind               RequestKind
+	TxnXDR             string
+	RequiredSignatures int
+
+	Withdrawal  *WithdrawalRequest
+	Refund      *RefundRequest
+	FeeTransfer *FeeTransferRequest
+}
+
+// Validate checks the protocol version of the request and if the payload matches the kind
+func (r SignRequest) Validate() error {
+	if err := CheckVersion(r.Version); err != nil {
+		return err
+	}
+	var payloads int
+	for _, set := range []bool{r.Withdrawal != nil, r.Refund != nil, r.FeeTransfer != nil} {
+		if set {
+			payloads++
+		}
+	}
+	var valid bool
+	switch r.Kind {
+	case KindWithdrawal:
+		valid = r.Withdrawal != nil
+	case KindRefund:
+		valid = r.Refund != nil
+	case KindFeeTransfer:
+		valid = r.FeeTransfer != nil
+	case KindMint:
+		return fmt.Errorf("%w: mints are not signed as a Stellar transaction", ErrUnsupportedRequest)
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrUnsupportedRequest, r.Kind)
+	}
+	if !valid || payloads != 1 {
+		return fmt.Errorf("%w: the payload does not match the kind %q", ErrUnsupportedRequest, r.Kind)
+	}
+	return nil
+}
+
+// Legacy converts the request to the request of protocol version 1 for cosigners which are not upgraded yet
+func (r SignRequest) Legacy() StellarSignRequest {
+	legacy := StellarSignRequest{
+		TxnXDR:             r.TxnXDR,
+		RequiredSignatures: r.RequiredSignatures,
+	}
+	switch {
+	case r.Withdrawal != nil:
+		legacy.Receiver = r.Withdrawal.Sender
+		legacy.Block = r.Withdrawal.Block
+	case r.Refund != nil:
+		legacy.Message = r.Refund.Deposit
+	}
+	return legacy
+}
+
+// StellarSignRequest is the request of protocol version 1
 type StellarSignRequest struct {
 	TxnXDR             string
 	RequiredSignatures int
-	Receiver           common.Address //TODO: How can this be an Ethereum common.Address ?
+	Receiver           common.Address
 	Block              uint64
 	Message            string //Contains the deposit transaction hash in case of a refund
 }
 
+// Typed converts a request of protocol version 1 to a SignRequest.
+// The kind is derived from the fields which are set: a block for a withdrawal,
+// a message for a refund and a fee transfer otherwise, its deposit is taken from the memo
+// of the transaction by the cosigner.
+func (r StellarSignRequest) Typed() SignRequest {
+	typed := SignRequest{
+		Version:            1,
+		TxnXDR:             r.TxnXDR,
+		RequiredSignatures: r.RequiredSignatures,
+	}
+	switch {
+	case r.Block != 0:
+		typed.Kind = KindWithdrawal
+		typed.Withdrawal = &WithdrawalRequest{Block: r.Block, Sender: r.Receiver}
+	case r.Message != "":
+		typed.Kind = KindRefund
+		typed.Refund = &RefundRequest{Deposit: r.Message}
+	default:
+		typed.Kind = KindFeeTransfer
+		typed.FeeTransfer = &FeeTransferRequest{}
+	}
+	return typed
+}
+
 type StellarSignResponse struct {
 	// Signature is a base64 of the signature
 	Signature string
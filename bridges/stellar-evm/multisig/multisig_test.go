@@ -0,0 +1,53 @@
+package multisig
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/pause"
+)
+
+func TestLegacyRequests(t *testing.T) {
+	sender := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	for name, test := range map[string]struct {
+		legacy StellarSignRequest
+		typed  SignRequest
+	}{
+		"withdrawal": {
+			StellarSignRequest{TxnXDR: "tx", RequiredSignatures: 2, Receiver: sender, Block: 5},
+			SignRequest{Version: 1, Kind: KindWithdrawal, TxnXDR: "tx", RequiredSignatures: 2, Withdrawal: &WithdrawalRequest{Block: 5, Sender: sender}},
+		},
+		"refund": {
+			StellarSignRequest{TxnXDR: "tx", RequiredSignatures: 2, Message: "deposit"},
+			SignRequest{Version: 1, Kind: KindRefund, TxnXDR: "tx", RequiredSignatures: 2, Refund: &RefundRequest{Deposit: "deposit"}},
+		},
+		"fee transfer": {
+			StellarSignRequest{TxnXDR: "tx", RequiredSignatures: 2},
+			SignRequest{Version: 1, Kind: KindFeeTransfer, TxnXDR: "tx", RequiredSignatures: 2, FeeTransfer: &FeeTransferRequest{}},
+		},
+	} {
+		assert.Equal(t, test.typed, test.legacy.Typed(), name)
+		assert.NoError(t, test.typed.Validate(), name)
+		assert.Equal(t, test.legacy, test.typed.Legacy(), name)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion(0))
+	assert.NoError(t, CheckVersion(MinProtocolVersion))
+	assert.NoError(t, CheckVersion(ProtocolVersion))
+	assert.ErrorIs(t, CheckVersion(ProtocolVersion+1), ErrUnsupportedRequest)
+	assert.ErrorIs(t, CheckVersion(-1), ErrUnsupportedRequest)
+}
+
+func TestNodeStatusMismatches(t *testing.T) {
+	own := NodeStatus{Version: "v1.2.0", ProtocolVersion: ProtocolVersion, Height: 10, StellarCursor: "5", DepositFee: "50", WithdrawFee: "1"}
+	other := own
+	other.Height, other.StellarCursor = 8, "4"
+	assert.Empty(t, own.Mismatches(other), "the progress on the chains is not a mismatch")
+
+	other.Version, other.DepositFee = "v1.1.0", "0.5%,min=10"
+	other.Pause = pause.State{Paused: true, Reason: "maintenance"}
+	assert.Equal(t, []string{"version v1.1.0 instead of v1.2.0", "deposit fee 0.5%,min=10 instead of 50", "paused: maintenance"}, own.Mismatches(other))
+}
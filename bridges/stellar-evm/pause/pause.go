@@ -0,0 +1,148 @@
+// Package pause implements the emergency pause switch of the bridge
+package pause
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// PollInterval is how often Wait checks if the bridge is resumed
+const PollInterval = 5 * time.Second
+
+// ErrPaused is returned for requests which are refused while the bridge is paused
+var ErrPaused = errors.New("the bridge is paused")
+
+// Switch pauses the processing of the bridge. It is paused while it is paused manually,
+// through the admin API or a signal, or while the sentinel file exists.
+type Switch struct {
+	file string
+
+	lock   sync.RWMutex
+	paused bool
+	reason string
+	since  time.Time
+}
+
+// State is the state of the switch as reported by the admin server
+type State struct {
+	Paused bool       `json:"paused"`
+	Reason string     `json:"reason,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+	// File is the sentinel file, the bridge is paused while it exists
+	File        string `json:"file,omitempty"`
+	FilePresent bool   `json:"filePresent,omitempty"`
+}
+
+// NewSwitch creates a switch which is not paused manually, file is the sentinel file and can be empty
+func NewSwitch(file string) *Switch {
+	return &Switch{file: file}
+}
+
+func (s *Switch) filePresent() bool {
+	if s.file == "" {
+		return false
+	}
+	_, err := os.Stat(s.file)
+	return err == nil
+}
+
+// Paused reports if the bridge is paused manually or by the sentinel file
+func (s *Switch) Paused() bool {
+	s.lock.RLock()
+	paused := s.paused
+	s.lock.RUnlock()
+	return paused || s.filePresent()
+}
+
+// Pause pauses the bridge until Resume is called
+func (s *Switch) Pause(reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.paused {
+		return
+	}
+	s.paused = true
+	s.reason = reason
+	s.since = time.Now()
+	log.Warn("Bridge paused", "reason", reason)
+}
+
+// Resume undoes Pause, it fails if the sentinel file still pauses the bridge
+func (s *Switch) Resume() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.filePresent() {
+		return fmt.Errorf("the bridge stays paused while %s exists", s.file)
+	}
+	if s.paused {
+		log.Warn("Bridge resumed")
+	}
+	s.paused = false
+	s.reason = ""
+	s.since = time.Time{}
+	return nil
+}
+
+// State returns the current state of the switch
+func (s *Switch) State() State {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	state := State{
+		Paused:      s.paused,
+		Reason:      s.reason,
+		File:        s.file,
+		FilePresent: s.filePresent(),
+	}
+	if s.paused {
+		since := s.since
+		state.Since = &since
+	}
+	state.Paused = state.Paused || state.FilePresent
+	return state
+}
+
+// Wait blocks until the bridge is not paused, it returns the context error if the context is done first
+func (s *Switch) Wait(ctx context.Context) error {
+	logged := false
+	for s.Paused() {
+		if !logged {
+			log.Info("Bridge is paused, waiting to resume")
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(PollInterval):
+		}
+	}
+	return nil
+}
+
+// HandleSignals pauses the bridge on SIGUSR1 and resumes it on SIGUSR2 until the context is done
+func (s *Switch) HandleSignals(ctx context.Context) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case sig := <-sigs:
+			if sig == syscall.SIGUSR1 {
+				s.Pause("SIGUSR1 received")
+				continue
+			}
+			if err := s.Resume(); err != nil {
+				log.Error("Failed to resume the bridge", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package pause
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "paused")
+	s := NewSwitch(file)
+	assert.False(t, s.Paused())
+
+	s.Pause("testing")
+	assert.True(t, s.Paused())
+	state := s.State()
+	assert.Equal(t, "testing", state.Reason)
+	assert.NotNil(t, state.Since)
+	require.NoError(t, s.Resume())
+	assert.False(t, s.Paused())
+
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	assert.True(t, s.Paused())
+	assert.True(t, s.State().FilePresent)
+	assert.Error(t, s.Resume(), "the sentinel file keeps the bridge paused")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, s.Wait(ctx), context.Canceled)
+
+	require.NoError(t, os.Remove(file))
+	assert.NoError(t, s.Wait(context.Background()))
+}
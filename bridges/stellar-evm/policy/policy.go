@@ -0,0 +1,246 @@
+// Package policy holds the rules a cosigner applies to the transfers it signs,
+// on top of checking them against the chains
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// precisionDigits is the amount of decimals of TFT, all amounts are in stroops
+const precisionDigits = 7
+
+var (
+	// ErrAboveMaximum is returned for a transfer larger than the maximum of the policy
+	ErrAboveMaximum = errors.New("transfer above the maximum of the signing policy")
+	// ErrDailyVolumeExceeded is returned if a transfer would exceed the volume signed in a rolling day
+	ErrDailyVolumeExceeded = errors.New("daily volume of the signing policy exceeded")
+	// ErrBlockedDestination is returned for a transfer to a blocked address
+	ErrBlockedDestination = errors.New("destination is blocked by the signing policy")
+	// ErrOutsideBusinessHours is returned for a large transfer outside the business hours
+	ErrOutsideBusinessHours = errors.New("large transfer outside the business hours of the signing policy")
+)
+
+// Violated returns true if the error is returned because a transfer is refused by the policy
+func Violated(err error) bool {
+	return errors.Is(err, ErrAboveMaximum) || errors.Is(err, ErrDailyVolumeExceeded) ||
+		errors.Is(err, ErrBlockedDestination) || errors.Is(err, ErrOutsideBusinessHours)
+}
+
+// Policy bounds the transfers a cosigner signs. All amounts are in stroops, an amount of 0 is not enforced.
+// The empty policy allows everything.
+type Policy struct {
+	// MaxTransfer bounds a single transfer
+	MaxTransfer int64
+	// DailyVolume bounds the amount signed in a rolling day, for mints and withdrawals each
+	DailyVolume int64
+	// Blocked are the destinations which are never paid
+	Blocked map[string]bool
+	// BusinessHours are the hours in which transfers of at least LargeTransfer are signed, nil for always
+	BusinessHours *BusinessHours
+	LargeTransfer int64
+}
+
+// BusinessHours is a daily time window, End is before Start for a window which spans midnight
+type BusinessHours struct {
+	Days     [7]bool
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// Contains checks if the time is within the business hours
+func (h BusinessHours) Contains(t time.Time) bool {
+	t = t.In(h.Location)
+	if !h.Days[t.Weekday()] {
+		return false
+	}
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if h.Start <= h.End {
+		return sinceMidnight >= h.Start && sinceMidnight < h.End
+	}
+	return sinceMidnight >= h.Start || sinceMidnight < h.End
+}
+
+// Check checks a transfer of amount to destination at the given time,
+// volume is the amount already signed in the last day in the same direction.
+func (p Policy) Check(amount int64, destination string, volume int64, at time.Time) error {
+	if p.Blocked[normalize(destination)] {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, destination)
+	}
+	if p.MaxTransfer > 0 && amount > p.MaxTransfer {
+		return fmt.Errorf("%w of %s TFT", ErrAboveMaximum, formatAmount(p.MaxTransfer))
+	}
+	if p.DailyVolume > 0 && volume+amount > p.DailyVolume {
+		return fmt.Errorf("%w: %s TFT signed in the last day, the maximum is %s TFT", ErrDailyVolumeExceeded, formatAmount(volume), formatAmount(p.DailyVolume))
+	}
+	if p.BusinessHours != nil && amount >= p.LargeTransfer && !p.BusinessHours.Contains(at) {
+		return fmt.Errorf("%w for transfers of %s TFT or more", ErrOutsideBusinessHours, formatAmount(p.LargeTransfer))
+	}
+	return nil
+}
+
+// normalize makes ethereum addresses case insensitive, stellar addresses are upper case already
+func normalize(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// policyFile is the format of a policy file, the amounts are in TFT:
+//
+//	maxTransfer: 100000
+//	dailyVolume: 500000
+//	blocked:
+//	  - GBLOCKED...
+//	  - "0x..."
+//	largeTransfer: 50000
+//	businessHours:
+//	  days: [mon, tue, wed, thu, fri]
+//	  hours: "08:00-18:00"
+//	  timezone: Europe/Brussels
+type policyFile struct {
+	MaxTransfer   string   `yaml:"maxTransfer"`
+	DailyVolume   string   `yaml:"dailyVolume"`
+	Blocked       []string `yaml:"blocked"`
+	LargeTransfer string   `yaml:"largeTransfer"`
+	BusinessHours *struct {
+		Days     []string `yaml:"days"`
+		Hours    string   `yaml:"hours"`
+		Timezone string   `yaml:"timezone"`
+	} `yaml:"businessHours"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Load reads the policy from a yaml file, if file is empty the empty policy is returned
+func Load(file string) (Policy, error) {
+	if file == "" {
+		return Policy{}, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Policy{}, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("invalid policy file %s: %w", file, err)
+	}
+	return p, nil
+}
+
+// Parse parses a policy in the yaml format of a policy file
+func Parse(data []byte) (Policy, error) {
+	var parsed policyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// an empty file is the empty policy
+	if err := decoder.Decode(&parsed); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, err
+	}
+
+	var p Policy
+	var err error
+	for _, amount := range []struct {
+		name  string
+		value string
+		to    *int64
+	}{{"maxTransfer", parsed.MaxTransfer, &p.MaxTransfer}, {"dailyVolume", parsed.DailyVolume, &p.DailyVolume}, {"largeTransfer", parsed.LargeTransfer, &p.LargeTransfer}} {
+		if amount.value == "" {
+			continue
+		}
+		if *amount.to, err = parseAmount(amount.value); err != nil {
+			return Policy{}, fmt.Errorf("invalid %s: %w", amount.name, err)
+		}
+	}
+	if p.MaxTransfer > 0 && p.DailyVolume > 0 && p.DailyVolume < p.MaxTransfer {
+		return Policy{}, errors.New("the daily volume is lower than the maximum transfer")
+	}
+
+	if len(parsed.Blocked) > 0 {
+		p.Blocked = make(map[string]bool, len(parsed.Blocked))
+		for _, address := range parsed.Blocked {
+			p.Blocked[normalize(address)] = true
+		}
+	}
+
+	if parsed.BusinessHours == nil {
+		if p.LargeTransfer > 0 {
+			return Policy{}, errors.New("largeTransfer is set without businessHours")
+		}
+		return p, nil
+	}
+	hours := &BusinessHours{Location: time.UTC}
+	if parsed.BusinessHours.Timezone != "" {
+		if hours.Location, err = time.LoadLocation(parsed.BusinessHours.Timezone); err != nil {
+			return Policy{}, fmt.Errorf("invalid business hours timezone: %w", err)
+		}
+	}
+	if len(parsed.BusinessHours.Days) == 0 {
+		return Policy{}, errors.New("the business hours have no days")
+	}
+	for _, day := range parsed.BusinessHours.Days {
+		weekday, found := weekdays[strings.ToLower(day)]
+		if !found {
+			return Policy{}, fmt.Errorf("invalid business day %q, should be one of mon, tue, wed, thu, fri, sat or sun", day)
+		}
+		hours.Days[weekday] = true
+	}
+	start, end, found := strings.Cut(parsed.BusinessHours.Hours, "-")
+	if !found {
+		return Policy{}, fmt.Errorf("invalid business hours %q, should be like 08:00-18:00", parsed.BusinessHours.Hours)
+	}
+	if hours.Start, err = parseTimeOfDay(start); err != nil {
+		return Policy{}, err
+	}
+	if hours.End, err = parseTimeOfDay(end); err != nil {
+		return Policy{}, err
+	}
+	if hours.Start == hours.End {
+		return Policy{}, errors.New("the business hours start and end at the same time")
+	}
+	p.BusinessHours = hours
+	return p, nil
+}
+
+// parseTimeOfDay parses a time like 08:00 to the duration since midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, should be like 08:00", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseAmount parses a TFT amount to stroops
+func parseAmount(value string) (int64, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if amount.IsNegative() {
+		return 0, fmt.Errorf("%s is negative", value)
+	}
+	stroops := amount.Shift(precisionDigits)
+	if !stroops.IsInteger() {
+		return 0, fmt.Errorf("%s has more than %d decimals", value, precisionDigits)
+	}
+	return stroops.IntPart(), nil
+}
+
+func formatAmount(stroops int64) string {
+	return decimal.New(stroops, -precisionDigits).String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/api/bridge"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/reconcile"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar"
+)
+
+// reconcileCommand replays the transfers of both chains in a range and prints the ones which do not add up as json
+func reconcileCommand(args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	var ethCfg bridge.EthConfig
+	fs.StringVar(&ethCfg.EthNetworkName, "ethnetwork", "eth-mainnet", "ethereum network name")
+	fs.StringVar(&ethCfg.NetworksFile, "ethnetworks", "", "yaml file with ethereum network definitions, extends and overrides the built-in networks")
+	fs.StringVar(&ethCfg.EthUrl, "ethurl", "", "ethereum rpc url, defaults to the rpc endpoints of the network or "+bridge.DefaultEthUrl)
+	fs.StringVar(&ethCfg.ContractAddress, "contract", "", "token contract address")
+	fs.Uint64Var(&ethCfg.LogRange, "ethlogrange", 2000, "maximum amount of blocks to request logs for in a single call")
+	fromBlock := fs.Uint64("from-block", 0, "first ethereum block to reconcile")
+	toBlock := fs.Uint64("to-block", 0, "last ethereum block to reconcile, the latest block if 0")
+	var stellarCfg stellar.StellarConfig
+	fs.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet, production or the name of a network configured with the horizon url, passphrase and asset issuer")
+	fs.StringVar(&stellarCfg.HorizonURL, "horizon-url", "", "horizon url, overrides the horizon of the stellar network")
+	fs.StringVar(&stellarCfg.NetworkPassphrase, "network-passphrase", "", "stellar network passphrase, overrides the passphrase of the stellar network")
+	fs.StringVar(&stellarCfg.AssetIssuer, "asset-issuer", "", "issuer of the TFT asset, overrides the issuer on the stellar network")
+	vault := fs.String("vault", "", "stellar address of the bridge account")
+	feeWallet := fs.String("feewallet", "", "stellar fee wallet address")
+	fromLedger := fs.Int32("from-ledger", 0, "first stellar ledger to reconcile")
+	toLedger := fs.Int32("to-ledger", 0, "last stellar ledger to reconcile, the latest ledger if 0")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge reconcile --vault <address> --feewallet <address> [--from-ledger <ledger>] [--from-block <block>]")
+		fmt.Fprintln(os.Stderr, "Pairs the deposits on the bridge account with their mints, refunds and fee transfers and the withdrawals with their payments.")
+		fmt.Fprintln(os.Stderr, "The report is printed as json, the exit code is 1 if there are issues.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !stellar.IsValidStellarAddress(*vault) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	stellarCfg.SetNetwork()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	log.Info("Loading the stellar transactions", "vault", *vault, "from", *fromLedger, "to", *toLedger)
+	deposits, payments, err := stellar.VaultTransfers(ctx, stellarCfg.StellarNetwork, *vault, *feeWallet, *fromLedger, *toLedger)
+	if err != nil {
+		panic(err)
+	}
+	log.Info("Loading the ethereum events", "network", ethCfg.EthNetworkName, "from", *fromBlock, "to", *toBlock)
+	mints, withdrawals, err := bridge.ContractTransfers(ctx, &ethCfg, *fromBlock, *toBlock)
+	if err != nil {
+		panic(err)
+	}
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+
+	report := reconcile.Match(reconcile.Input{
+		Vault:       *vault,
+		FeeWallet:   *feeWallet,
+		Deposits:    deposits,
+		Mints:       mints,
+		Withdrawals: withdrawals,
+		Payments:    payments,
+		MintKey:     bridge.MintKey,
+	})
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		panic(err)
+	}
+	if len(report.Issues) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,207 @@
+// Package reconcile pairs the transfers on both chains of the bridge and reports the ones which do not add up
+package reconcile
+
+import "strings"
+
+// Kinds of issues found by Match
+const (
+	// IssueUnmatchedDeposit is a deposit which is neither minted nor refunded
+	IssueUnmatchedDeposit = "unmatched-deposit"
+	// IssueUnmatchedWithdrawal is a withdrawal without a Stellar payment
+	IssueUnmatchedWithdrawal = "unmatched-withdrawal"
+	// IssueUnmatchedMint is a mint without a deposit
+	IssueUnmatchedMint = "unmatched-mint"
+	// IssueUnmatchedPayment is a Stellar payment of the vault which does not belong to a deposit or withdrawal
+	IssueUnmatchedPayment = "unmatched-payment"
+	// IssueDoubleProcessed is a deposit or withdrawal which is paid out more than once
+	IssueDoubleProcessed = "double-processed"
+	// IssueAmountMismatch is a transfer of which the amounts on both sides do not add up
+	IssueAmountMismatch = "amount-mismatch"
+	// IssueDestinationMismatch is a withdrawal paid to another account than requested
+	IssueDestinationMismatch = "destination-mismatch"
+)
+
+// Deposit is a TFT payment to the bridge vault on Stellar, amounts are in stroops
+type Deposit struct {
+	TxHash string
+	Ledger int32
+	Amount int64
+}
+
+// Mint is a Mint event of the token contract
+type Mint struct {
+	// DepositID is the id of the deposit as carried by the mint, see Input.MintKey
+	DepositID string
+	TxHash    string
+	Block     uint64
+	Receiver  string
+	Amount    int64
+}
+
+// Withdrawal is a Withdraw event on the token contract
+type Withdrawal struct {
+	// ID is the hex encoded memo hash the Stellar payment for this withdrawal carries
+	ID          string
+	TxHash      string
+	Block       uint64
+	Destination string
+	Amount      int64
+}
+
+// Payment is a Stellar transaction of the vault paying out TFT
+type Payment struct {
+	TxHash string
+	Ledger int32
+	// Memo is the hex encoded memo hash, or the return memo if Return is set
+	Memo        string
+	Return      bool
+	Destination string
+	Amount      int64
+	// Fee is the amount paid to the fee wallet in the same transaction
+	Fee int64
+}
+
+// Input holds the transfers of both chains in the reconciled range
+type Input struct {
+	// Vault is the bridge account on Stellar, withdrawals to it or to the FeeWallet are not paid out
+	Vault       string
+	FeeWallet   string
+	Deposits    []Deposit
+	Mints       []Mint
+	Withdrawals []Withdrawal
+	Payments    []Payment
+	// MintKey returns the DepositID the mint of a deposit carries, the deposit hash is used if it is nil
+	MintKey func(depositHash string) string
+}
+
+// Issue is a transfer which does not add up
+type Issue struct {
+	Kind string `json:"kind"`
+	// ID is the deposit hash, the withdrawal id, the mint deposit id or the payment memo
+	ID           string   `json:"id"`
+	Expected     int64    `json:"expected,omitempty"`
+	Actual       int64    `json:"actual,omitempty"`
+	Destination  string   `json:"destination,omitempty"`
+	Transactions []string `json:"transactions,omitempty"`
+}
+
+// Report is the result of a reconciliation
+type Report struct {
+	Deposits    int     `json:"deposits"`
+	Withdrawals int     `json:"withdrawals"`
+	Matched     int     `json:"matched"`
+	Issues      []Issue `json:"issues"`
+}
+
+func key(id string) string {
+	return strings.ToLower(strings.TrimPrefix(id, "0x"))
+}
+
+// Match pairs every deposit with its mint or refund and its fee transfer, and every withdrawal
+// with the Stellar payment carrying its id as memo hash.
+// Mints and payments which are not paired are reported as well.
+func Match(in Input) Report {
+	report := Report{Deposits: len(in.Deposits), Withdrawals: len(in.Withdrawals), Issues: []Issue{}}
+	mintKey := in.MintKey
+	if mintKey == nil {
+		mintKey = func(depositHash string) string { return depositHash }
+	}
+
+	mints := make(map[string][]int)
+	for i, m := range in.Mints {
+		mints[key(m.DepositID)] = append(mints[key(m.DepositID)], i)
+	}
+	refunds := make(map[string][]int)
+	fees := make(map[string][]int)
+	payouts := make(map[string][]int)
+	for i, p := range in.Payments {
+		switch {
+		case p.Return:
+			refunds[key(p.Memo)] = append(refunds[key(p.Memo)], i)
+		case p.Destination == in.FeeWallet && p.Fee == 0:
+			fees[key(p.Memo)] = append(fees[key(p.Memo)], i)
+		default:
+			payouts[key(p.Memo)] = append(payouts[key(p.Memo)], i)
+		}
+	}
+	usedMints := make(map[int]bool)
+	usedPayments := make(map[int]bool)
+
+	for _, d := range in.Deposits {
+		id := key(d.TxHash)
+		var txs []string
+		var paid int64
+		var outcomes int
+		for _, i := range mints[key(mintKey(d.TxHash))] {
+			usedMints[i] = true
+			txs = append(txs, in.Mints[i].TxHash)
+			paid += in.Mints[i].Amount
+			outcomes++
+		}
+		for _, i := range refunds[id] {
+			usedPayments[i] = true
+			txs = append(txs, in.Payments[i].TxHash)
+			paid += in.Payments[i].Amount + in.Payments[i].Fee
+			outcomes++
+		}
+		feeTransfers := fees[id]
+		for _, i := range feeTransfers {
+			usedPayments[i] = true
+			txs = append(txs, in.Payments[i].TxHash)
+			paid += in.Payments[i].Amount
+		}
+		switch {
+		case outcomes == 0:
+			report.Issues = append(report.Issues, Issue{Kind: IssueUnmatchedDeposit, ID: id, Expected: d.Amount, Actual: paid, Transactions: txs})
+		case outcomes > 1 || len(feeTransfers) > 1:
+			report.Issues = append(report.Issues, Issue{Kind: IssueDoubleProcessed, ID: id, Expected: d.Amount, Actual: paid, Transactions: txs})
+		case paid != d.Amount:
+			report.Issues = append(report.Issues, Issue{Kind: IssueAmountMismatch, ID: id, Expected: d.Amount, Actual: paid, Transactions: txs})
+		default:
+			report.Matched++
+		}
+	}
+
+	for _, w := range in.Withdrawals {
+		id := key(w.ID)
+		if w.Destination == in.Vault || w.Destination == in.FeeWallet {
+			report.Matched++
+			continue
+		}
+		txs := []string{w.TxHash}
+		var paid int64
+		destination := ""
+		for _, i := range payouts[id] {
+			usedPayments[i] = true
+			txs = append(txs, in.Payments[i].TxHash)
+			paid += in.Payments[i].Amount + in.Payments[i].Fee
+			if in.Payments[i].Destination != w.Destination {
+				destination = in.Payments[i].Destination
+			}
+		}
+		switch payments := len(payouts[id]); {
+		case payments == 0:
+			report.Issues = append(report.Issues, Issue{Kind: IssueUnmatchedWithdrawal, ID: id, Expected: w.Amount, Destination: w.Destination, Transactions: txs})
+		case payments > 1:
+			report.Issues = append(report.Issues, Issue{Kind: IssueDoubleProcessed, ID: id, Expected: w.Amount, Actual: paid, Transactions: txs})
+		case destination != "":
+			report.Issues = append(report.Issues, Issue{Kind: IssueDestinationMismatch, ID: id, Expected: w.Amount, Actual: paid, Destination: destination, Transactions: txs})
+		case paid != w.Amount:
+			report.Issues = append(report.Issues, Issue{Kind: IssueAmountMismatch, ID: id, Expected: w.Amount, Actual: paid, Transactions: txs})
+		default:
+			report.Matched++
+		}
+	}
+
+	for i, m := range in.Mints {
+		if !usedMints[i] {
+			report.Issues = append(report.Issues, Issue{Kind: IssueUnmatchedMint, ID: key(m.DepositID), Actual: m.Amount, Destination: m.Receiver, Transactions: []string{m.TxHash}})
+		}
+	}
+	for i, p := range in.Payments {
+		if !usedPayments[i] {
+			report.Issues = append(report.Issues, Issue{Kind: IssueUnmatchedPayment, ID: key(p.Memo), Actual: p.Amount + p.Fee, Destination: p.Destination, Transactions: []string{p.TxHash}})
+		}
+	}
+	return report
+}
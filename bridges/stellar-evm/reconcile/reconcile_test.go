@@ -0,0 +1,89 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const feeWallet = "GFEE"
+
+func TestMatch(t *testing.T) {
+	in := Input{
+		Vault:     "GVAULT",
+		FeeWallet: feeWallet,
+		Deposits: []Deposit{
+			{TxHash: "aa", Amount: 100},
+			{TxHash: "bb", Amount: 50},
+		},
+		Mints: []Mint{
+			{DepositID: "0xAA", TxHash: "0x01", Amount: 90},
+		},
+		Withdrawals: []Withdrawal{
+			{ID: "0xcc", TxHash: "0xcc", Destination: "GUSER", Amount: 40},
+			{ID: "dd", TxHash: "0xdd", Destination: feeWallet, Amount: 10},
+		},
+		Payments: []Payment{
+			{TxHash: "s1", Memo: "aa", Destination: feeWallet, Amount: 10},
+			{TxHash: "s2", Memo: "bb", Return: true, Destination: "GUSER", Amount: 49, Fee: 1},
+			{TxHash: "s3", Memo: "cc", Destination: "GUSER", Amount: 39, Fee: 1},
+		},
+	}
+	report := Match(in)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 2, report.Deposits)
+	assert.Equal(t, 2, report.Withdrawals)
+	assert.Equal(t, 4, report.Matched)
+}
+
+func TestMatchIssues(t *testing.T) {
+	in := Input{
+		FeeWallet: feeWallet,
+		Deposits: []Deposit{
+			{TxHash: "aa", Amount: 100},
+			{TxHash: "bb", Amount: 50},
+			{TxHash: "cc", Amount: 70},
+		},
+		Mints: []Mint{
+			{DepositID: "aa", TxHash: "0x01", Amount: 100},
+			{DepositID: "aa", TxHash: "0x02", Amount: 100},
+			{DepositID: "bb", TxHash: "0x03", Amount: 45},
+			{DepositID: "ff", TxHash: "0x04", Amount: 5},
+		},
+		Withdrawals: []Withdrawal{
+			{ID: "dd", TxHash: "0xdd", Destination: "GUSER", Amount: 40},
+			{ID: "ee", TxHash: "0xee", Destination: "GUSER", Amount: 40},
+		},
+		Payments: []Payment{
+			{TxHash: "s1", Memo: "ee", Destination: "GOTHER", Amount: 40},
+			{TxHash: "s2", Memo: "99", Destination: "GUSER", Amount: 1},
+		},
+	}
+	report := Match(in)
+	assert.Equal(t, 0, report.Matched)
+	require.Len(t, report.Issues, 7)
+
+	kinds := make(map[string]string)
+	for _, issue := range report.Issues {
+		kinds[issue.ID] = issue.Kind
+	}
+	assert.Equal(t, IssueDoubleProcessed, kinds["aa"])
+	assert.Equal(t, IssueAmountMismatch, kinds["bb"])
+	assert.Equal(t, IssueUnmatchedDeposit, kinds["cc"])
+	assert.Equal(t, IssueUnmatchedWithdrawal, kinds["dd"])
+	assert.Equal(t, IssueDestinationMismatch, kinds["ee"])
+	assert.Equal(t, IssueUnmatchedMint, kinds["ff"])
+	assert.Equal(t, IssueUnmatchedPayment, kinds["99"])
+	assert.Equal(t, []string{"0x01", "0x02"}, report.Issues[0].Transactions)
+}
+
+func TestMatchMintKey(t *testing.T) {
+	report := Match(Input{
+		Deposits: []Deposit{{TxHash: "aa", Amount: 10}},
+		Mints:    []Mint{{DepositID: "0x1234", Amount: 10}},
+		MintKey:  func(string) string { return "1234" },
+	})
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 1, report.Matched)
+}
@@ -0,0 +1,172 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a request a cosigner signed
+type AuditEntry struct {
+	// Sequence numbers the entries from 1
+	Sequence uint64    `json:"sequence"`
+	At       time.Time `json:"at"`
+	// Request is the kind of transfer as used in the metrics: mint, withdrawal, refund or fee
+	Request string `json:"request"`
+	// ID is the deposit transaction for mints and the memo for Stellar transactions
+	ID          string `json:"id"`
+	Destination string `json:"destination,omitempty"`
+	Amount      int64  `json:"amount"`
+	Fee         int64  `json:"fee,omitempty"`
+	// Peer is the libp2p peer id of the bridge which requested the signature
+	Peer string `json:"peer,omitempty"`
+	// Transaction is the base64 XDR of a signed Stellar transaction
+	Transaction string `json:"transaction,omitempty"`
+	// Signature is the signature which was handed out
+	Signature string `json:"signature"`
+	// Previous is the hash of the previous entry, empty for the first one
+	Previous string `json:"previous"`
+	// Hash is the hex encoded sha256 of the json encoding of the entry without its hash
+	Hash string `json:"hash"`
+}
+
+// hash computes the hash of the entry
+func (e AuditEntry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditLog appends the requests a cosigner signed to a file with a json document per line.
+// Every entry includes the hash of the entry before it, so entries can not be changed,
+// removed or inserted without breaking the chain.
+type AuditLog struct {
+	location string
+	last     AuditEntry
+	lock     sync.Mutex
+}
+
+// AuditFile returns the location of the audit log which is stored
+// next to the given ChainPersistency file.
+func AuditFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "audit.jsonl")
+}
+
+// NewAuditLog opens the AuditLog at the given location to append to it.
+// It fails if the entries already in the log do not form a valid chain.
+func NewAuditLog(location string) (*AuditLog, error) {
+	entries, err := ReadAuditLog(location)
+	if err != nil {
+		return nil, err
+	}
+	if err = VerifyAuditLog(entries); err != nil {
+		return nil, fmt.Errorf("audit log %s is broken: %w", location, err)
+	}
+	l := &AuditLog{location: location}
+	if len(entries) > 0 {
+		l.last = entries[len(entries)-1]
+	}
+	return l, nil
+}
+
+// Record chains an entry to the previous one and appends it to the log
+func (l *AuditLog) Record(e AuditEntry) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	e.Sequence = l.last.Sequence + 1
+	e.Previous = l.last.Hash
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	// the time is stored in UTC so the entry encodes the same when it is read back
+	e.At = e.At.UTC()
+	hash, err := e.hash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	l.last = e
+	return nil
+}
+
+// Entries reads all entries in the log
+func (l *AuditLog) Entries() ([]AuditEntry, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return ReadAuditLog(l.location)
+}
+
+// ReadAuditLog reads all entries in the audit log at the given location
+func ReadAuditLog(location string) ([]AuditEntry, error) {
+	file, err := os.Open(location)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var e AuditEntry
+		if err = decoder.Decode(&e); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// VerifyAuditLog checks that the entries are numbered in order, that every entry has the hash of the previous one
+// and that the hashes match the contents. The first broken entry is reported.
+func VerifyAuditLog(entries []AuditEntry) error {
+	var previous AuditEntry
+	for _, e := range entries {
+		if e.Sequence != previous.Sequence+1 {
+			return fmt.Errorf("entry %d follows entry %d", e.Sequence, previous.Sequence)
+		}
+		if e.Previous != previous.Hash {
+			return fmt.Errorf("entry %d does not chain to the hash of entry %d", e.Sequence, previous.Sequence)
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("entry %d does not match its hash", e.Sequence)
+		}
+		previous = e
+	}
+	return nil
+}
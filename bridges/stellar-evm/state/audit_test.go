@@ -0,0 +1,58 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	location := AuditFile(filepath.Join(t.TempDir(), "node.json"))
+	log, err := NewAuditLog(location)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(AuditEntry{Request: "mint", ID: "deposit", Destination: "0x01", Amount: 100, Peer: "12D3KooW", Signature: "aa"}))
+	require.NoError(t, log.Record(AuditEntry{Request: "withdrawal", ID: "memo", Destination: "GDEST", Amount: 7, Fee: 1, Signature: "bb"}))
+
+	// the chain continues after a restart
+	log, err = NewAuditLog(location)
+	require.NoError(t, err)
+	require.NoError(t, log.Record(AuditEntry{Request: "refund", ID: "deposit2", Amount: 5, Signature: "cc"}))
+
+	entries, err := ReadAuditLog(location)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.NoError(t, VerifyAuditLog(entries))
+	assert.Equal(t, uint64(3), entries[2].Sequence)
+	assert.Equal(t, entries[1].Hash, entries[2].Previous)
+	assert.Empty(t, entries[0].Previous)
+	assert.Equal(t, "12D3KooW", entries[0].Peer)
+
+	changed := append([]AuditEntry(nil), entries...)
+	changed[1].Amount = 700
+	assert.ErrorContains(t, VerifyAuditLog(changed), "entry 2 does not match its hash")
+
+	assert.ErrorContains(t, VerifyAuditLog([]AuditEntry{entries[0], entries[2]}), "entry 3 follows entry 1")
+
+	// an entry which is removed and the rest renumbered and rehashed breaks the chain
+	renumbered := entries[2]
+	renumbered.Sequence = 2
+	renumbered.Hash, err = renumbered.hash()
+	require.NoError(t, err)
+	assert.ErrorContains(t, VerifyAuditLog([]AuditEntry{entries[0], renumbered}), "entry 2 does not chain")
+
+	// a log which is changed on disk is not appended to
+	var lines []string
+	for _, e := range changed {
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		lines = append(lines, string(data))
+	}
+	require.NoError(t, os.WriteFile(location, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	_, err = NewAuditLog(location)
+	assert.Error(t, err)
+}
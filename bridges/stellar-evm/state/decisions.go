@@ -0,0 +1,95 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Decision is a transaction the bridge would have submitted when it runs in dry-run mode
+type Decision struct {
+	At time.Time `json:"at"`
+	// Transfer is the kind of transfer as used in the metrics: mint, withdrawal, refund or fee
+	Transfer string `json:"transfer"`
+	// ID identifies the transfer on both chains, the deposit transaction for mints
+	// and the memo for Stellar transactions
+	ID          string `json:"id"`
+	Destination string `json:"destination,omitempty"`
+	Amount      int64  `json:"amount"`
+	Fee         int64  `json:"fee,omitempty"`
+	// Signatures is the amount of signatures on the transaction, including the one of the master
+	Signatures int `json:"signatures"`
+	// Transaction is the base64 XDR of a Stellar transaction or the hex encoded call data of a mint
+	Transaction string `json:"transaction,omitempty"`
+}
+
+// DecisionLog appends the decisions of a dry-run to a file with a json document per line
+// so they can be compared with what was actually submitted.
+type DecisionLog struct {
+	location string
+	lock     sync.Mutex
+}
+
+// DecisionsFile returns the location of the decision log which is stored
+// next to the given ChainPersistency file.
+func DecisionsFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "decisions.jsonl")
+}
+
+// NewDecisionLog creates a DecisionLog writing to the given location
+func NewDecisionLog(location string) *DecisionLog {
+	return &DecisionLog{location: location}
+}
+
+// Record appends a decision to the log
+func (l *DecisionLog) Record(d Decision) error {
+	if d.At.IsZero() {
+		d.At = time.Now()
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	file, err := os.OpenFile(l.location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Decisions reads all decisions in the log
+func (l *DecisionLog) Decisions() ([]Decision, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	file, err := os.Open(l.location)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var decisions []Decision
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var d Decision
+		if err = decoder.Decode(&d); err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
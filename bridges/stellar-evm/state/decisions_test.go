@@ -0,0 +1,29 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionLog(t *testing.T) {
+	location := DecisionsFile(filepath.Join(t.TempDir(), "node.json"))
+	log := NewDecisionLog(location)
+
+	decisions, err := log.Decisions()
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+
+	require.NoError(t, log.Record(Decision{Transfer: "mint", ID: "deposit", Amount: 100, Fee: 5, Signatures: 3}))
+	require.NoError(t, log.Record(Decision{Transfer: "withdrawal", ID: "memo", Amount: 7, Transaction: "AAAA"}))
+
+	decisions, err = NewDecisionLog(location).Decisions()
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "deposit", decisions[0].ID)
+	assert.Equal(t, int64(5), decisions[0].Fee)
+	assert.False(t, decisions[0].At.IsZero())
+	assert.Equal(t, "AAAA", decisions[1].Transaction)
+}
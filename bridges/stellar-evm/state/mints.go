@@ -0,0 +1,159 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// PendingMint is a mint transaction which is broadcasted but not mined yet.
+// Replacements of the transaction reuse the nonce, so at most one of them can be mined.
+type PendingMint struct {
+	TxID  string `json:"txId"`
+	Nonce uint64 `json:"nonce"`
+	// Transactions are the signed transactions sent for this mint, the last one is the most recent replacement
+	Transactions []hexutil.Bytes `json:"transactions"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
+
+// MintTracker keeps track of the nonces and the transactions of the mints which are not mined yet.
+// A nonce is persisted together with the signed transaction before it is broadcasted,
+// so after a restart an unconfirmed mint is resumed with the same nonce instead of being sent again.
+type MintTracker struct {
+	location string
+	pending  map[string]*PendingMint
+
+	lock sync.Mutex
+}
+
+// MintsFile returns the location of the mint tracker which is stored
+// next to the given ChainPersistency file.
+func MintsFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "mints.json")
+}
+
+// NewMintTracker loads the MintTracker stored at the given location.
+// If the file does not exist yet, an empty tracker is returned.
+func NewMintTracker(location string) (*MintTracker, error) {
+	t := &MintTracker{
+		location: location,
+		pending:  make(map[string]*PendingMint),
+	}
+
+	file, err := os.ReadFile(location)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pending []*PendingMint
+	if err = json.Unmarshal(file, &pending); err != nil {
+		return nil, err
+	}
+	for _, p := range pending {
+		t.pending[p.TxID] = p
+	}
+	return t, nil
+}
+
+// Get returns a copy of the pending mint for the given Stellar transaction id
+func (t *MintTracker) Get(txID string) (p PendingMint, found bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stored, found := t.pending[txID]
+	if found {
+		p = *stored
+		p.Transactions = append([]hexutil.Bytes(nil), stored.Transactions...)
+	}
+	return
+}
+
+// NextNonce returns the nonce to use for a new mint.
+// chainNonce is the pending nonce of the account as reported by the ethereum node, the returned nonce
+// is never lower than that and skips the nonces which are still reserved by pending mints.
+func (t *MintTracker) NextNonce(chainNonce uint64) uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	nonce := chainNonce
+	for _, p := range t.pending {
+		if p.Nonce >= nonce {
+			nonce = p.Nonce + 1
+		}
+	}
+	return nonce
+}
+
+// AddTransaction stores a signed transaction for a mint with the given nonce.
+// It must be called before the transaction is broadcasted.
+func (t *MintTracker) AddTransaction(txID string, nonce uint64, rawTx []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p, found := t.pending[txID]
+	if !found {
+		p = &PendingMint{TxID: txID, Nonce: nonce}
+		t.pending[txID] = p
+	}
+	previous := *p
+	p.Transactions = append(p.Transactions, rawTx)
+	p.UpdatedAt = time.Now()
+	if err := t.save(); err != nil {
+		if found {
+			*p = previous
+		} else {
+			delete(t.pending, txID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove forgets the pending mint for the given Stellar transaction id, releasing its nonce.
+func (t *MintTracker) Remove(txID string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p, found := t.pending[txID]
+	if !found {
+		return nil
+	}
+	delete(t.pending, txID)
+	if err := t.save(); err != nil {
+		t.pending[txID] = p
+		return err
+	}
+	return nil
+}
+
+func (t *MintTracker) save() error {
+	pending := make([]*PendingMint, 0, len(t.pending))
+	for _, p := range t.pending {
+		pending = append(pending, p)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Nonce < pending[j].Nonce
+	})
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(t.location, data)
+}
+
+// writeFileAtomic writes to a temporary file first and moves it in place afterwards
+// so a crash while writing does not corrupt the file.
+func writeFileAtomic(location string, data []byte) error {
+	tmp := location + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, location)
+}
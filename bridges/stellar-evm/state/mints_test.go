@@ -0,0 +1,32 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMintTrackerNonces(t *testing.T) {
+	location := MintsFile(filepath.Join(t.TempDir(), "node.json"))
+
+	tracker, err := NewMintTracker(location)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), tracker.NextNonce(7))
+
+	require.NoError(t, tracker.AddTransaction("first", 7, []byte{1}))
+	require.NoError(t, tracker.AddTransaction("first", 7, []byte{2}))
+	assert.Equal(t, uint64(8), tracker.NextNonce(7), "a reserved nonce should not be handed out again")
+
+	reloaded, err := NewMintTracker(location)
+	require.NoError(t, err)
+	pending, found := reloaded.Get("first")
+	require.True(t, found)
+	assert.Equal(t, uint64(7), pending.Nonce)
+	assert.Len(t, pending.Transactions, 2)
+	assert.Equal(t, uint64(8), reloaded.NextNonce(7))
+
+	require.NoError(t, reloaded.Remove("first"))
+	assert.Equal(t, uint64(7), reloaded.NextNonce(7))
+}
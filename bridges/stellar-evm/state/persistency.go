@@ -3,15 +3,21 @@ package state
 import (
 	"encoding/json"
 	"os"
+	"sync"
 )
 
 type Blockheight struct {
 	LastHeight    uint64 `json:"lastHeight"`
 	StellarCursor string `json:"stellarCursor"`
+	// WithdrawCheckpoint is the height up to which all Withdraw events are handed to the withdrawal queue
+	WithdrawCheckpoint uint64 `json:"withdrawCheckpoint"`
 }
 
 type ChainPersistency struct {
 	location string
+
+	// lock protects the read-modify-write cycles of the different Save methods
+	lock sync.Mutex
 }
 
 // NewChainPersistency creates new ChainPersistency object and returns a reference to it.
@@ -22,26 +28,44 @@ func NewChainPersistency(location string) *ChainPersistency {
 }
 
 func (b *ChainPersistency) SaveHeight(height uint64) error {
-	blockheight, err := b.GetHeight()
-	if err != nil {
-		return err
-	}
-
-	blockheight.LastHeight = height
-	return b.Save(blockheight)
+	return b.update(func(blockheight *Blockheight) {
+		blockheight.LastHeight = height
+	})
 }
 
 func (b *ChainPersistency) SaveStellarCursor(cursor string) error {
-	blockheight, err := b.GetHeight()
+	return b.update(func(blockheight *Blockheight) {
+		blockheight.StellarCursor = cursor
+	})
+}
+
+// SaveWithdrawCheckpoint stores the height up to which all Withdraw events are processed
+func (b *ChainPersistency) SaveWithdrawCheckpoint(height uint64) error {
+	return b.update(func(blockheight *Blockheight) {
+		blockheight.WithdrawCheckpoint = height
+	})
+}
+
+func (b *ChainPersistency) update(modify func(*Blockheight)) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	blockheight, err := b.load()
 	if err != nil {
 		return err
 	}
-
-	blockheight.StellarCursor = cursor
-	return b.Save(blockheight)
+	modify(blockheight)
+	return b.save(blockheight)
 }
 
 func (b *ChainPersistency) GetHeight() (*Blockheight, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.load()
+}
+
+func (b *ChainPersistency) load() (*Blockheight, error) {
 	var blockheight Blockheight
 	file, err := os.ReadFile(b.location)
 	if os.IsNotExist(err) {
@@ -59,6 +83,13 @@ func (b *ChainPersistency) GetHeight() (*Blockheight, error) {
 }
 
 func (b *ChainPersistency) Save(blockheight *Blockheight) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.save(blockheight)
+}
+
+func (b *ChainPersistency) save(blockheight *Blockheight) error {
 	updatedPersistency, err := json.Marshal(blockheight)
 	if err != nil {
 		return err
@@ -0,0 +1,214 @@
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WithdrawalStatus is the processing status of a withdrawal
+type WithdrawalStatus string
+
+const (
+	// WithdrawalPending is a withdrawal which is waiting for enough confirmations
+	WithdrawalPending WithdrawalStatus = "pending"
+	// WithdrawalSubmitted is a withdrawal for which the Stellar payment is being created
+	WithdrawalSubmitted WithdrawalStatus = "submitted"
+	// WithdrawalDone is a withdrawal which is paid out or which is deliberately skipped
+	WithdrawalDone WithdrawalStatus = "done"
+	// WithdrawalFailed is a withdrawal for which the last attempt failed, it is retried
+	WithdrawalFailed WithdrawalStatus = "failed"
+	// WithdrawalRemoved is a withdrawal of which the Withdraw event is no longer part of the canonical chain
+	WithdrawalRemoved WithdrawalStatus = "removed"
+	// WithdrawalHeld is a withdrawal which does not fit the withdraw limits, it is paid out once it does
+	WithdrawalHeld WithdrawalStatus = "held"
+	// WithdrawalRejected is a withdrawal below the minimum of the withdraw limits, it is not paid out or retried
+	WithdrawalRejected WithdrawalStatus = "rejected"
+)
+
+// doneRetention is how long finished or removed withdrawals are kept in the queue
+const doneRetention = 30 * 24 * time.Hour
+
+// Withdrawal is a Withdraw event of the token contract as it is stored in the WithdrawalQueue
+type Withdrawal struct {
+	TxHash            common.Hash      `json:"txHash"`
+	BlockHash         common.Hash      `json:"blockHash"`
+	BlockHeight       uint64           `json:"blockHeight"`
+	Receiver          common.Address   `json:"receiver"`
+	Amount            *big.Int         `json:"amount"`
+	BlockchainAddress string           `json:"blockchainAddress"`
+	Network           string           `json:"network"`
+	Raw               []byte           `json:"raw,omitempty"`
+	Status            WithdrawalStatus `json:"status"`
+	Attempts          int              `json:"attempts"`
+	LastError         string           `json:"lastError,omitempty"`
+	UpdatedAt         time.Time        `json:"updatedAt"`
+}
+
+// closed returns true if the withdrawal does not need any further processing
+func (w *Withdrawal) closed() bool {
+	return w.Status == WithdrawalDone || w.Status == WithdrawalRemoved || w.Status == WithdrawalRejected
+}
+
+// WithdrawalQueue is a durable queue of withdrawals, keyed by the transaction hash of the Withdraw event.
+// Every change is written to disk before it is acknowledged so no withdrawal is lost on a restart.
+type WithdrawalQueue struct {
+	location    string
+	withdrawals map[common.Hash]*Withdrawal
+
+	lock sync.Mutex
+}
+
+// WithdrawalsFile returns the location of the withdrawal queue which is stored
+// next to the given ChainPersistency file.
+func WithdrawalsFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "withdrawals.json")
+}
+
+// NewWithdrawalQueue loads the WithdrawalQueue stored at the given location.
+// If the file does not exist yet, an empty queue is returned.
+func NewWithdrawalQueue(location string) (*WithdrawalQueue, error) {
+	q := &WithdrawalQueue{
+		location:    location,
+		withdrawals: make(map[common.Hash]*Withdrawal),
+	}
+
+	file, err := os.ReadFile(location)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var withdrawals []*Withdrawal
+	if err = json.Unmarshal(file, &withdrawals); err != nil {
+		return nil, err
+	}
+	for _, w := range withdrawals {
+		if w.closed() && time.Since(w.UpdatedAt) > doneRetention {
+			continue
+		}
+		q.withdrawals[w.TxHash] = w
+	}
+	return q, nil
+}
+
+// Add stores a new withdrawal as pending.
+// It returns false if the withdrawal was already known, in which case the queue is not modified.
+func (q *WithdrawalQueue) Add(w Withdrawal) (added bool, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, known := q.withdrawals[w.TxHash]; known {
+		return false, nil
+	}
+	w.Status = WithdrawalPending
+	w.UpdatedAt = time.Now()
+	q.withdrawals[w.TxHash] = &w
+	if err = q.save(); err != nil {
+		delete(q.withdrawals, w.TxHash)
+		return false, err
+	}
+	return true, nil
+}
+
+// Get returns a copy of the withdrawal with the given transaction hash
+func (q *WithdrawalQueue) Get(txHash common.Hash) (w Withdrawal, found bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	stored, found := q.withdrawals[txHash]
+	if found {
+		w = *stored
+	}
+	return
+}
+
+// Open returns the withdrawals which are not done, removed or rejected, ordered by blockheight.
+func (q *WithdrawalQueue) Open() []Withdrawal {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	open := make([]Withdrawal, 0, len(q.withdrawals))
+	for _, w := range q.withdrawals {
+		if !w.closed() {
+			open = append(open, *w)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].BlockHeight == open[j].BlockHeight {
+			return open[i].TxHash.Hex() < open[j].TxHash.Hex()
+		}
+		return open[i].BlockHeight < open[j].BlockHeight
+	})
+	return open
+}
+
+// SetStatus updates the status of a known withdrawal.
+// A non nil cause is kept as the last error of the withdrawal.
+func (q *WithdrawalQueue) SetStatus(txHash common.Hash, status WithdrawalStatus, cause error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	w, found := q.withdrawals[txHash]
+	if !found {
+		return os.ErrNotExist
+	}
+	previous := *w
+	if status == WithdrawalSubmitted {
+		w.Attempts++
+	}
+	w.Status = status
+	w.LastError = ""
+	if cause != nil {
+		w.LastError = cause.Error()
+	}
+	w.UpdatedAt = time.Now()
+	if err := q.save(); err != nil {
+		*w = previous
+		return err
+	}
+	return nil
+}
+
+// Relocate moves a pending withdrawal to the block it got included in after a chain reorganization.
+func (q *WithdrawalQueue) Relocate(txHash common.Hash, blockHash common.Hash, blockHeight uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	w, found := q.withdrawals[txHash]
+	if !found {
+		return os.ErrNotExist
+	}
+	previous := *w
+	w.BlockHash = blockHash
+	w.BlockHeight = blockHeight
+	w.Status = WithdrawalPending
+	w.UpdatedAt = time.Now()
+	if err := q.save(); err != nil {
+		*w = previous
+		return err
+	}
+	return nil
+}
+
+func (q *WithdrawalQueue) save() error {
+	withdrawals := make([]*Withdrawal, 0, len(q.withdrawals))
+	for _, w := range q.withdrawals {
+		withdrawals = append(withdrawals, w)
+	}
+	sort.Slice(withdrawals, func(i, j int) bool {
+		return withdrawals[i].BlockHeight < withdrawals[j].BlockHeight
+	})
+	data, err := json.MarshalIndent(withdrawals, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(q.location, data)
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawalQueuePersistency(t *testing.T) {
+	location := WithdrawalsFile(filepath.Join(t.TempDir(), "node.json"))
+
+	q, err := NewWithdrawalQueue(location)
+	require.NoError(t, err)
+
+	first := Withdrawal{TxHash: common.HexToHash("0x01"), BlockHeight: 20, Amount: big.NewInt(100)}
+	second := Withdrawal{TxHash: common.HexToHash("0x02"), BlockHeight: 10, Amount: big.NewInt(200)}
+	for _, w := range []Withdrawal{first, second} {
+		added, err := q.Add(w)
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	added, err := q.Add(first)
+	require.NoError(t, err)
+	assert.False(t, added, "a known withdrawal should not be added twice")
+
+	require.NoError(t, q.SetStatus(second.TxHash, WithdrawalSubmitted, nil))
+	require.NoError(t, q.SetStatus(second.TxHash, WithdrawalFailed, errors.New("horizon unavailable")))
+	require.NoError(t, q.SetStatus(first.TxHash, WithdrawalSubmitted, nil))
+	require.NoError(t, q.SetStatus(first.TxHash, WithdrawalDone, nil))
+
+	reloaded, err := NewWithdrawalQueue(location)
+	require.NoError(t, err)
+
+	open := reloaded.Open()
+	require.Len(t, open, 1)
+	assert.Equal(t, second.TxHash, open[0].TxHash)
+	assert.Equal(t, WithdrawalFailed, open[0].Status)
+	assert.Equal(t, 1, open[0].Attempts)
+	assert.Equal(t, "horizon unavailable", open[0].LastError)
+	assert.Equal(t, big.NewInt(200), open[0].Amount)
+
+	done, found := reloaded.Get(first.TxHash)
+	require.True(t, found)
+	assert.Equal(t, WithdrawalDone, done.Status)
+}
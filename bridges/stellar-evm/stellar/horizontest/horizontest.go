@@ -0,0 +1,488 @@
+// Package horizontest provides an in-process fake Horizon server for tests.
+//
+// The server keeps an in-memory ledger of accounts with their TFT balance, signers and thresholds.
+// Payments can be made on the ledger directly, like deposits of users, or submitted through the
+// Horizon api like the bridge does. Submitted transactions are checked for the sequence number,
+// the signatures and the destinations and fail with the result codes Horizon returns.
+// Only payment operations are supported.
+package horizontest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Passphrase is the network passphrase of the fake network
+const Passphrase = "Horizon Test Network"
+
+// genesis is the close time of the first ledger, every transaction closes a new ledger 5 seconds later
+var genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type account struct {
+	sequence   int64
+	balance    int64
+	trustline  bool
+	signers    map[string]int32
+	thresholds hProtocol.AccountThresholds
+}
+
+type transaction struct {
+	record       hProtocol.Transaction
+	participants []string
+	effects      []interface{}
+}
+
+// Server is a fake Horizon server
+type Server struct {
+	*httptest.Server
+	assetCode   string
+	assetIssuer string
+
+	lock         sync.Mutex
+	accounts     map[string]*account
+	transactions []transaction
+	submitted    []*txnbuild.Transaction
+}
+
+// NewServer starts a fake Horizon server for the TFT asset issued by the given account
+func NewServer(assetIssuer string) *Server {
+	s := &Server{
+		assetCode:   "TFT",
+		assetIssuer: assetIssuer,
+		accounts:    make(map[string]*account),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accounts/", s.handleAccounts)
+	mux.HandleFunc("/transactions", s.handleSubmit)
+	mux.HandleFunc("/transactions/", s.handleTransactions)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Asset returns the TFT asset of the server as CODE:ISSUER
+func (s *Server) Asset() string {
+	return s.assetCode + ":" + s.assetIssuer
+}
+
+// Client returns a horizon client for the server
+func (s *Server) Client() *horizonclient.Client {
+	return &horizonclient.Client{HorizonURL: s.URL, HTTP: s.Server.Client()}
+}
+
+// CreateAccount adds an account with a TFT trustline and the given balance in stroops,
+// the account is signed for by its own key
+func (s *Server) CreateAccount(address string, balance int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.accounts[address] = &account{
+		sequence:  int64(len(s.accounts)+1) << 32,
+		balance:   balance,
+		trustline: true,
+		signers:   map[string]int32{address: 1},
+	}
+}
+
+// CreateAccountWithoutTrustline adds an account which can not receive TFT
+func (s *Server) CreateAccountWithoutTrustline(address string) {
+	s.CreateAccount(address, 0)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.accounts[address].trustline = false
+}
+
+// SetSigners replaces the signers of an account, the weight of its own key is 0 if it is not given.
+// The threshold is used for the low, medium and high thresholds.
+func (s *Server) SetSigners(address string, signers map[string]int32, threshold byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	acc := s.accounts[address]
+	acc.signers = make(map[string]int32)
+	for key, weight := range signers {
+		acc.signers[key] = weight
+	}
+	acc.thresholds = hProtocol.AccountThresholds{LowThreshold: threshold, MedThreshold: threshold, HighThreshold: threshold}
+}
+
+// Balance returns the TFT balance of an account in stroops
+func (s *Server) Balance(address string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if acc, found := s.accounts[address]; found {
+		return acc.balance
+	}
+	return 0
+}
+
+// Submitted returns the transactions which were submitted successfully through the api
+func (s *Server) Submitted() []*txnbuild.Transaction {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*txnbuild.Transaction(nil), s.submitted...)
+}
+
+// Pay makes a TFT payment from an account on the ledger without checking its signatures, the hash of the transaction is returned
+func (s *Server) Pay(from string, to string, stroops int64, memo txnbuild.Memo) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	acc, found := s.accounts[from]
+	if !found {
+		return "", fmt.Errorf("account %s does not exist", from)
+	}
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: from, Sequence: acc.sequence},
+		IncrementSequenceNum: true,
+		Operations: []txnbuild.Operation{&txnbuild.Payment{
+			Destination: to,
+			Amount:      amount.StringFromInt64(stroops),
+			Asset:       txnbuild.CreditAsset{Code: s.assetCode, Issuer: s.assetIssuer},
+		}},
+		Memo:          memo,
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	if err != nil {
+		return "", err
+	}
+	record, codes := s.apply(tx, false)
+	if codes != nil {
+		return "", fmt.Errorf("payment failed: %s %v", codes.TransactionCode, codes.OperationCodes)
+	}
+	return record.Hash, nil
+}
+
+// apply executes a transaction on the ledger, the result codes are returned if it fails
+func (s *Server) apply(tx *txnbuild.Transaction, checkSignatures bool) (hProtocol.Transaction, *hProtocol.TransactionResultCodes) {
+	source := tx.SourceAccount().AccountID
+	acc, found := s.accounts[source]
+	if !found {
+		return hProtocol.Transaction{}, &hProtocol.TransactionResultCodes{TransactionCode: "tx_no_source_account"}
+	}
+	if tx.SequenceNumber() != acc.sequence+1 {
+		return hProtocol.Transaction{}, &hProtocol.TransactionResultCodes{TransactionCode: "tx_bad_seq"}
+	}
+	hash, err := tx.Hash(Passphrase)
+	if err != nil {
+		return hProtocol.Transaction{}, &hProtocol.TransactionResultCodes{TransactionCode: "tx_malformed"}
+	}
+	if checkSignatures && !s.authorized(acc, hash[:], tx) {
+		return hProtocol.Transaction{}, &hProtocol.TransactionResultCodes{TransactionCode: "tx_bad_auth"}
+	}
+
+	balances := make(map[string]int64)
+	balance := func(address string) int64 {
+		if b, found := balances[address]; found {
+			return b
+		}
+		return s.accounts[address].balance
+	}
+	codes := make([]string, 0, len(tx.Operations()))
+	failed := false
+	participants := []string{source}
+	var effectRecords []interface{}
+	for _, op := range tx.Operations() {
+		payment, ok := op.(*txnbuild.Payment)
+		if !ok {
+			codes, failed = append(codes, "op_not_supported"), true
+			continue
+		}
+		from := source
+		if payment.SourceAccount != "" {
+			from = payment.SourceAccount
+		}
+		stroops, err := amount.ParseInt64(payment.Amount)
+		if err != nil || stroops <= 0 {
+			codes, failed = append(codes, "op_malformed"), true
+			continue
+		}
+		asset, ok := payment.Asset.(txnbuild.CreditAsset)
+		if !ok || asset.Code != s.assetCode || asset.Issuer != s.assetIssuer {
+			codes, failed = append(codes, "op_not_supported"), true
+			continue
+		}
+		destination, found := s.accounts[payment.Destination]
+		switch {
+		case s.accounts[from] == nil:
+			codes, failed = append(codes, "op_no_source_account"), true
+		case !found:
+			codes, failed = append(codes, "op_no_destination"), true
+		case !destination.trustline:
+			codes, failed = append(codes, "op_no_trust"), true
+		case balance(from) < stroops:
+			codes, failed = append(codes, "op_underfunded"), true
+		default:
+			codes = append(codes, "op_success")
+			balances[from] = balance(from) - stroops
+			balances[payment.Destination] = balance(payment.Destination) + stroops
+			participants = append(participants, payment.Destination)
+			effectRecords = append(effectRecords,
+				effects.AccountDebited{Base: effects.Base{Account: from, Type: effects.EffectTypeNames[effects.EffectAccountDebited], TypeI: int32(effects.EffectAccountDebited)}, Asset: s.baseAsset(), Amount: payment.Amount},
+				effects.AccountCredited{Base: effects.Base{Account: payment.Destination, Type: effects.EffectTypeNames[effects.EffectAccountCredited], TypeI: int32(effects.EffectAccountCredited)}, Asset: s.baseAsset(), Amount: payment.Amount},
+			)
+		}
+	}
+	if failed {
+		return hProtocol.Transaction{}, &hProtocol.TransactionResultCodes{TransactionCode: "tx_failed", OperationCodes: codes}
+	}
+
+	acc.sequence = tx.SequenceNumber()
+	for address, b := range balances {
+		s.accounts[address].balance = b
+	}
+	ledger := int32(len(s.transactions) + 1)
+	closeTime := genesis.Add(time.Duration(ledger) * 5 * time.Second)
+	pagingToken := strconv.FormatInt(int64(ledger)<<32, 10)
+	envelope, _ := tx.Base64()
+	record := hProtocol.Transaction{
+		ID:              fmt.Sprintf("%x", hash),
+		PT:              pagingToken,
+		Successful:      true,
+		Hash:            fmt.Sprintf("%x", hash),
+		Ledger:          ledger,
+		LedgerCloseTime: closeTime,
+		Account:         source,
+		AccountSequence: tx.SequenceNumber(),
+		FeeAccount:      source,
+		FeeCharged:      tx.BaseFee() * int64(len(tx.Operations())),
+		MaxFee:          tx.MaxFee(),
+		OperationCount:  int32(len(tx.Operations())),
+		EnvelopeXdr:     envelope,
+		Signatures:      []string{},
+	}
+	record.MemoType, record.Memo = memoOf(tx.Memo())
+	for _, signature := range tx.Signatures() {
+		record.Signatures = append(record.Signatures, base64.StdEncoding.EncodeToString(signature.Signature))
+	}
+	for i, e := range effectRecords {
+		id := fmt.Sprintf("%s-%010d", pagingToken, i+1)
+		switch effect := e.(type) {
+		case effects.AccountDebited:
+			effect.ID, effect.PT, effect.LedgerCloseTime = id, id, closeTime
+			effectRecords[i] = effect
+		case effects.AccountCredited:
+			effect.ID, effect.PT, effect.LedgerCloseTime = id, id, closeTime
+			effectRecords[i] = effect
+		}
+	}
+	s.transactions = append(s.transactions, transaction{record: record, participants: participants, effects: effectRecords})
+	return record, nil
+}
+
+// authorized checks if the signatures of the transaction reach the medium threshold of the source account
+func (s *Server) authorized(acc *account, hash []byte, tx *txnbuild.Transaction) bool {
+	weight := int32(0)
+	for key, w := range acc.signers {
+		kp, err := keypair.ParseAddress(key)
+		if err != nil {
+			continue
+		}
+		for _, signature := range tx.Signatures() {
+			if signature.Hint == kp.Hint() && kp.Verify(hash, signature.Signature) == nil {
+				weight += w
+				break
+			}
+		}
+	}
+	return weight > 0 && weight >= int32(acc.thresholds.MedThreshold)
+}
+
+func (s *Server) baseAsset() base.Asset {
+	assetType := "credit_alphanum4"
+	if len(s.assetCode) > 4 {
+		assetType = "credit_alphanum12"
+	}
+	return base.Asset{Type: assetType, Code: s.assetCode, Issuer: s.assetIssuer}
+}
+
+func memoOf(memo txnbuild.Memo) (memoType string, value string) {
+	switch m := memo.(type) {
+	case txnbuild.MemoHash:
+		return "hash", base64.StdEncoding.EncodeToString(m[:])
+	case txnbuild.MemoReturn:
+		return "return", base64.StdEncoding.EncodeToString(m[:])
+	case txnbuild.MemoText:
+		return "text", string(m)
+	case txnbuild.MemoID:
+		return "id", strconv.FormatUint(uint64(m), 10)
+	}
+	return "none", ""
+}
+
+// handleAccounts serves /accounts/{id} and /accounts/{id}/transactions
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/"), "/")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	acc, found := s.accounts[parts[0]]
+	if !found || len(parts) > 2 || (len(parts) == 2 && parts[1] != "transactions") {
+		notFound(w)
+		return
+	}
+	if len(parts) == 1 {
+		writeJSON(w, http.StatusOK, s.accountRecord(parts[0], acc))
+		return
+	}
+
+	var records []hProtocol.Transaction
+	for _, tx := range s.transactions {
+		for _, participant := range tx.participants {
+			if participant == parts[0] {
+				records = append(records, tx.record)
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, page(records, r, func(tx hProtocol.Transaction) string { return tx.PT }))
+}
+
+func (s *Server) accountRecord(address string, acc *account) hProtocol.Account {
+	record := hProtocol.Account{
+		ID:         address,
+		AccountID:  address,
+		Sequence:   acc.sequence,
+		Thresholds: acc.thresholds,
+		PT:         address,
+		Balances:   []hProtocol.Balance{{Balance: "10000.0000000", Asset: base.Asset{Type: "native"}}},
+	}
+	if acc.trustline {
+		record.Balances = append(record.Balances, hProtocol.Balance{Balance: amount.StringFromInt64(acc.balance), Asset: s.baseAsset()})
+	}
+	keys := make([]string, 0, len(acc.signers))
+	for key := range acc.signers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.Signers = append(record.Signers, hProtocol.Signer{Key: key, Weight: acc.signers[key], Type: "ed25519_public_key"})
+	}
+	return record
+}
+
+// handleTransactions serves /transactions/{hash} and /transactions/{hash}/effects
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/transactions/"), "/"), "/")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, tx := range s.transactions {
+		if tx.record.Hash != parts[0] {
+			continue
+		}
+		switch {
+		case len(parts) == 1:
+			writeJSON(w, http.StatusOK, tx.record)
+		case len(parts) == 2 && parts[1] == "effects":
+			writeJSON(w, http.StatusOK, page(tx.effects, r, func(e interface{}) string {
+				switch effect := e.(type) {
+				case effects.AccountDebited:
+					return effect.PT
+				case effects.AccountCredited:
+					return effect.PT
+				}
+				return ""
+			}))
+		default:
+			notFound(w)
+		}
+		return
+	}
+	notFound(w)
+}
+
+// handleSubmit serves the transaction submissions
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		notFound(w)
+		return
+	}
+	generic, err := txnbuild.TransactionFromXDR(r.FormValue("tx"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, problem("Transaction Malformed", nil))
+		return
+	}
+	tx, ok := generic.Transaction()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, problem("Transaction Failed", &hProtocol.TransactionResultCodes{TransactionCode: "tx_not_supported"}))
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	record, codes := s.apply(tx, true)
+	if codes != nil {
+		writeJSON(w, http.StatusBadRequest, problem("Transaction Failed", codes))
+		return
+	}
+	s.submitted = append(s.submitted, tx)
+	writeJSON(w, http.StatusOK, record)
+}
+
+// page returns a page of records after the cursor of the request in the requested order
+func page[T any](records []T, r *http.Request, pagingToken func(T) string) map[string]interface{} {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	cursor, _ := strconv.ParseInt(query.Get("cursor"), 10, 64)
+	desc := query.Get("order") == "desc"
+
+	selected := make([]T, 0, limit)
+	for i := range records {
+		record := records[i]
+		if desc {
+			record = records[len(records)-1-i]
+		}
+		token, _ := strconv.ParseInt(pagingToken(record), 10, 64)
+		if cursor != 0 && ((!desc && token <= cursor) || (desc && token >= cursor)) {
+			continue
+		}
+		if len(selected) == limit {
+			break
+		}
+		selected = append(selected, record)
+	}
+	return map[string]interface{}{
+		"_links":    map[string]interface{}{},
+		"_embedded": map[string]interface{}{"records": selected},
+	}
+}
+
+func problem(title string, codes *hProtocol.TransactionResultCodes) map[string]interface{} {
+	p := map[string]interface{}{
+		"type":   "https://stellar.org/horizon-errors/transaction_failed",
+		"title":  title,
+		"status": http.StatusBadRequest,
+	}
+	if codes != nil {
+		p["extras"] = map[string]interface{}{"result_codes": codes}
+	}
+	return p
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"type":   "https://stellar.org/horizon-errors/not_found",
+		"title":  "Resource Missing",
+		"status": http.StatusNotFound,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/hal+json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
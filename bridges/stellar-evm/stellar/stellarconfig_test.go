@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStellarConfigValidate(t *testing.T) {
@@ -14,3 +15,31 @@ func TestStellarConfigValidate(t *testing.T) {
 	c.StellarFeeWallet = "GBA4RKS7ELQ3B77INEHSHHDCIYJV7LNNPTUQVW5RL6DJJWDSIYRZFPF6"
 	assert.NoError(t, c.Validate())
 }
+
+func TestStellarConfigCustomNetwork(t *testing.T) {
+	c := StellarConfig{
+		StellarNetwork:   "standalone",
+		StellarSeed:      "SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS",
+		StellarFeeWallet: "GBA4RKS7ELQ3B77INEHSHHDCIYJV7LNNPTUQVW5RL6DJJWDSIYRZFPF6",
+	}
+	assert.Error(t, c.Validate(), "other networks need all settings")
+	_, err := GetNetworkPassPhrase(c.StellarNetwork)
+	assert.Error(t, err)
+
+	c.HorizonURL = "http://localhost:8000"
+	c.NetworkPassphrase = "Standalone Network ; February 2017"
+	c.AssetIssuer = "GA47YZA3PKFUZMPLQ3B5F2E3CJIB57TGGU7SPCQT2WAEYKN766PWIMB3"
+	require.NoError(t, c.Validate())
+	c.SetNetwork()
+
+	passphrase, err := GetNetworkPassPhrase(c.StellarNetwork)
+	require.NoError(t, err)
+	assert.Equal(t, c.NetworkPassphrase, passphrase)
+	client, err := GetHorizonClient(c.StellarNetwork)
+	require.NoError(t, err)
+	assert.Equal(t, c.HorizonURL, client.HorizonURL)
+	code, issuer, err := GetTFTAsset(c.StellarNetwork)
+	require.NoError(t, err)
+	assert.Equal(t, "TFT", code)
+	assert.Equal(t, c.AssetIssuer, issuer)
+}
@@ -0,0 +1,275 @@
+package stellar
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/eth"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/faults"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-evm/stellar/horizontest"
+)
+
+// newTestWallet creates a bridge wallet on a fake horizon server with 1000 TFT in the vault
+func newTestWallet(t *testing.T, depositFee, withdrawFee fees.Schedule) (*Wallet, *horizontest.Server) {
+	server := horizontest.NewServer(keypair.MustRandom().Address())
+	t.Cleanup(server.Close)
+	SetNetwork("horizontest", Network{HorizonURL: server.URL, Passphrase: horizontest.Passphrase, Asset: server.Asset()})
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	vault, err := strkey.Encode(strkey.VersionByteAccountID, pub)
+	require.NoError(t, err)
+	feeWallet := keypair.MustRandom().Address()
+	server.CreateAccount(vault, 1000_0000000)
+	server.CreateAccount(feeWallet, 0)
+
+	config := &StellarConfig{StellarNetwork: "horizontest", StellarFeeWallet: feeWallet}
+	wallet, err := NewWallet(config, keys.NewLocalEd25519Signer(key), depositFee, withdrawFee, NewTransactionStorage(config.StellarNetwork, vault))
+	require.NoError(t, err)
+	wallet.SetHorizonClient(server.Client())
+	return wallet, server
+}
+
+func TestWalletPayments(t *testing.T) {
+	wallet, server := newTestWallet(t, fees.NewFlat(0), fees.NewFlat(0))
+	ctx := context.Background()
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 0)
+
+	withdrawal := common.Hash{1}
+	require.NoError(t, wallet.CreateAndSubmitPayment(ctx, user, 90_0000000, common.Address{}, 1, withdrawal, 10_0000000))
+	assert.Equal(t, int64(90_0000000), server.Balance(user))
+	assert.Equal(t, int64(10_0000000), server.Balance(wallet.Config.StellarFeeWallet))
+	assert.Equal(t, int64(900_0000000), server.Balance(wallet.GetAddress()))
+	require.Len(t, server.Submitted(), 1)
+	assert.Equal(t, txnbuild.MemoHash(withdrawal), server.Submitted()[0].Memo())
+
+	// a payment with the same memo is not made twice
+	require.NoError(t, wallet.CreateAndSubmitPayment(ctx, user, 90_0000000, common.Address{}, 1, withdrawal, 10_0000000))
+	assert.Len(t, server.Submitted(), 1)
+	exists, err := wallet.TransactionStorage.TransactionWithMemoExists(hex.EncodeToString(withdrawal[:]))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// payments to accounts which can not receive TFT are skipped
+	noTrust := keypair.MustRandom().Address()
+	server.CreateAccountWithoutTrustline(noTrust)
+	assert.NoError(t, wallet.CreateAndSubmitPayment(ctx, noTrust, 10_0000000, common.Address{}, 2, common.Hash{2}, 0))
+	assert.NoError(t, wallet.CreateAndSubmitPayment(ctx, keypair.MustRandom().Address(), 10_0000000, common.Address{}, 3, common.Hash{3}, 0))
+	assert.Len(t, server.Submitted(), 1)
+
+	deposit := hex.EncodeToString(common.Hash{4}.Bytes())
+	require.NoError(t, wallet.CreateAndSubmitRefund(ctx, user, 5_0000000, deposit, 1_0000000))
+	require.NoError(t, wallet.CreateAndSubmitFeepayment(ctx, 2_0000000, common.Hash{5}))
+	require.Len(t, server.Submitted(), 3)
+	assert.Equal(t, txnbuild.MemoReturn(common.Hash{4}), server.Submitted()[1].Memo())
+	assert.Equal(t, int64(95_0000000), server.Balance(user))
+	assert.Equal(t, int64(13_0000000), server.Balance(wallet.Config.StellarFeeWallet))
+}
+
+// flakyCosigner signs the transactions unless fail is set, like the signers client
+// it keeps the last transaction it was asked to sign per kind and memo
+type flakyCosigner struct {
+	key          *keypair.Full
+	fail         bool
+	requested    []string
+	transactions map[string]string
+}
+
+func (c *flakyCosigner) Sign(ctx context.Context, signRequest multisig.SignRequest) ([]multisig.StellarSignResponse, error) {
+	c.requested = append(c.requested, signRequest.TxnXDR)
+	tx, err := parseTransaction(signRequest.TxnXDR)
+	if err != nil {
+		return nil, err
+	}
+	memo, err := ExtractMemoFromTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	c.transactions[string(signRequest.Kind)+"/"+memo] = signRequest.TxnXDR
+	if c.fail {
+		return nil, errors.New("not enough cosigners replied")
+	}
+	hash, err := tx.Hash(horizontest.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := c.key.SignBase64(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return []multisig.StellarSignResponse{{Signature: signature, Address: c.key.Address()}}, nil
+}
+
+func (c *flakyCosigner) Transaction(kind multisig.RequestKind, memo string) string {
+	return c.transactions[string(kind)+"/"+memo]
+}
+
+func TestWalletRetryReusesTransaction(t *testing.T) {
+	wallet, server := newTestWallet(t, fees.NewFlat(0), fees.NewFlat(0))
+	ctx := context.Background()
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 0)
+	cosigner := &flakyCosigner{key: keypair.MustRandom(), fail: true, transactions: make(map[string]string)}
+	server.SetSigners(wallet.GetAddress(), map[string]int32{wallet.GetAddress(): 1, cosigner.key.Address(): 1}, 2)
+	wallet.SetSignerClient(cosigner)
+	wallet.SetRequiredSignatures(2)
+
+	withdrawal := common.Hash{1}
+	assert.Error(t, wallet.CreateAndSubmitPayment(ctx, user, 10_0000000, common.Address{}, 1, withdrawal, 0))
+
+	// after a restart the transaction is signed again, a transaction built again would have other timebounds
+	time.Sleep(time.Second)
+	restarted, err := NewWallet(wallet.Config, wallet.signer, fees.NewFlat(0), fees.NewFlat(0), wallet.TransactionStorage)
+	require.NoError(t, err)
+	restarted.SetHorizonClient(server.Client())
+	restarted.SetSignerClient(cosigner)
+	restarted.SetRequiredSignatures(2)
+	cosigner.fail = false
+	require.NoError(t, restarted.CreateAndSubmitPayment(ctx, user, 10_0000000, common.Address{}, 1, withdrawal, 0))
+	require.Len(t, cosigner.requested, 2)
+	assert.Equal(t, cosigner.requested[0], cosigner.requested[1], "the retry asks to sign the same transaction")
+	assert.Equal(t, int64(10_0000000), server.Balance(user))
+
+	// a transaction is not reused once another one took its sequence number
+	deposit := hex.EncodeToString(common.Hash{2}.Bytes())
+	cosigner.fail = true
+	assert.Error(t, restarted.CreateAndSubmitRefund(ctx, user, 5_0000000, deposit, 0))
+	cosigner.fail = false
+	require.NoError(t, restarted.CreateAndSubmitPayment(ctx, user, 10_0000000, common.Address{}, 3, common.Hash{3}, 0))
+	require.NoError(t, restarted.CreateAndSubmitRefund(ctx, user, 5_0000000, deposit, 0))
+	require.Len(t, cosigner.requested, 5)
+	assert.NotEqual(t, cosigner.requested[2], cosigner.requested[4])
+	assert.Equal(t, int64(25_0000000), server.Balance(user))
+}
+
+func TestTransactionStorageDeposits(t *testing.T) {
+	wallet, server := newTestWallet(t, fees.NewFlat(0), fees.NewFlat(0))
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+
+	hash, err := server.Pay(user, wallet.GetAddress(), 50_0000000, txnbuild.MemoText("deposit"))
+	require.NoError(t, err)
+
+	tx, err := wallet.TransactionStorage.GetTransactionWithId(hash)
+	require.NoError(t, err)
+	assert.Equal(t, user, tx.Account)
+	_, err = wallet.TransactionStorage.GetTransactionWithId(hex.EncodeToString(common.Hash{1}.Bytes()))
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	deposited, sender, err := wallet.GetDepositAmountAndSender(hash, wallet.GetAddress())
+	require.NoError(t, err)
+	assert.Equal(t, int64(50_0000000), deposited)
+	assert.Equal(t, user, sender)
+}
+
+func TestMonitorBridgeAccountAndMint(t *testing.T) {
+	wallet, server := newTestWallet(t, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+	receiver := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	valid, err := server.Pay(user, wallet.GetAddress(), 10_0000000, txnbuild.MemoText(base64.StdEncoding.EncodeToString(receiver.Bytes())))
+	require.NoError(t, err)
+	_, err = server.Pay(user, wallet.GetAddress(), 1_0000000, txnbuild.MemoText(base64.StdEncoding.EncodeToString(receiver.Bytes())))
+	require.NoError(t, err)
+	_, err = server.Pay(user, wallet.GetAddress(), 5_0000000, txnbuild.MemoText("not an address"))
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var minted []string
+	mint := func(address eth.ERC20Address, amount *big.Int, txID string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, receiver, common.Address(address))
+		assert.Equal(t, int64(10_0000000), amount.Int64())
+		minted = append(minted, txID)
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	persistency := state.NewChainPersistency(filepath.Join(t.TempDir(), "node.json"))
+	held, err := state.NewHeldMints(state.HeldMintsFile(filepath.Join(t.TempDir(), "node.json")))
+	require.NoError(t, err)
+	go wallet.MonitorBridgeAccountAndMint(ctx, mint, persistency, held)
+
+	// the fee of the valid deposit is transferred, the too small one is not refunded and the other one is
+	require.Eventually(t, func() bool { return len(server.Submitted()) == 2 }, 10*time.Second, 50*time.Millisecond)
+	cancel()
+	lock.Lock()
+	assert.Equal(t, []string{valid}, minted)
+	lock.Unlock()
+	assert.Equal(t, int64(2_0000000), server.Balance(wallet.Config.StellarFeeWallet))
+	assert.Equal(t, int64(88_0000000), server.Balance(user))
+}
+
+func TestMonitorBridgeAccountHoldsMints(t *testing.T) {
+	wallet, server := newTestWallet(t, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+	memo := txnbuild.MemoText(base64.StdEncoding.EncodeToString(common.HexToAddress("0x2000000000000000000000000000000000000002").Bytes()))
+
+	capped, err := server.Pay(user, wallet.GetAddress(), 50_0000000, memo)
+	require.NoError(t, err)
+	fits, err := server.Pay(user, wallet.GetAddress(), 10_0000000, memo)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	var minted []string
+	capReached := true
+	mint := func(address eth.ERC20Address, amount *big.Int, txID string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if txID == capped && capReached {
+			return faults.ErrVolumeCapExceeded
+		}
+		minted = append(minted, txID)
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	persistency := state.NewChainPersistency(filepath.Join(t.TempDir(), "node.json"))
+	held, err := state.NewHeldMints(state.HeldMintsFile(filepath.Join(t.TempDir(), "node.json")))
+	require.NoError(t, err)
+	go wallet.MonitorBridgeAccountAndMint(ctx, mint, persistency, held)
+
+	// the deposit over the cap does not block the one after it
+	require.Eventually(t, func() bool { return len(server.Submitted()) == 1 }, 10*time.Second, 50*time.Millisecond)
+	cancel()
+	lock.Lock()
+	assert.Equal(t, []string{fits}, minted)
+	capReached = false
+	lock.Unlock()
+	mints := held.All()
+	require.Len(t, mints, 1)
+	assert.Equal(t, capped, mints[0].TxID)
+	assert.Equal(t, int64(50_0000000), mints[0].Amount)
+	assert.Equal(t, user, mints[0].Sender)
+
+	// once the volume frees up the held deposit is minted and its fee transferred
+	wallet.mintHeld(context.Background(), mint, held)
+	assert.Equal(t, []string{fits, capped}, minted)
+	assert.Empty(t, held.All())
+	assert.Len(t, server.Submitted(), 2)
+	assert.Equal(t, int64(2_0000000), server.Balance(wallet.Config.StellarFeeWallet))
+}
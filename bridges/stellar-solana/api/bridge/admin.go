@@ -0,0 +1,308 @@
+package bridge
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/limits"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
+)
+
+// adminShutdownTimeout is the time in flight admin requests get to finish on shutdown
+const adminShutdownTimeout = 5 * time.Second
+
+// Status is the state of the bridge as reported by the admin server
+type Status struct {
+	Follower bool        `json:"follower"`
+	DryRun   bool        `json:"dryRun"`
+	Synced   bool        `json:"synced"`
+	Pause    pause.State `json:"pause"`
+	// LastSlot is the slot of the last processed burn and LastSlotSeen when it was processed
+	LastSlot      uint64    `json:"lastSlot"`
+	LastSlotSeen  time.Time `json:"lastSlotSeen"`
+	StellarCursor string    `json:"stellarCursor"`
+	// PendingWithdrawals are the burns of this run which are not paid out on Stellar
+	PendingWithdrawals []PendingWithdrawal `json:"pendingWithdrawals"`
+	// HeldMints are the deposits which wait for the mint volume caps
+	HeldMints []state.HeldMint `json:"heldMints"`
+	// MintVolume and WithdrawVolume are the amounts counted against the volume caps
+	MintVolume     limits.Volume `json:"mintVolume"`
+	WithdrawVolume limits.Volume `json:"withdrawVolume"`
+	// Cosigners is only set on the master bridge
+	Cosigners []CosignerStatus `json:"cosigners,omitempty"`
+	// Lease is only set with failover
+	Lease    *lease.Status  `json:"lease,omitempty"`
+	Balances BalancesStatus `json:"balances"`
+}
+
+// PendingWithdrawal is a burn which is being paid out or for which the payout failed
+type PendingWithdrawal struct {
+	TxID        string `json:"txId"`
+	Slot        uint64 `json:"slot"`
+	Destination string `json:"destination"`
+	// Amount burned in lamports
+	Amount uint64 `json:"amount"`
+	// Held is set if the burn does not fit the withdraw limits, it is paid out once it does
+	Held bool `json:"held,omitempty"`
+	// Rejected is set if the burn is below the minimum of the withdraw limits, it is not paid out or retried
+	Rejected bool `json:"rejected,omitempty"`
+	// Error of the last failed payout attempt or the reason it is held
+	Error string `json:"error,omitempty"`
+}
+
+// BalancesStatus holds the balances of the hot wallets of the bridge.
+// Balances which could not be fetched are reported in Errors.
+type BalancesStatus struct {
+	// Sol is the balance of the solana wallet in lamports
+	Sol     *uint64                  `json:"sol,omitempty"`
+	Stellar []stellar.AccountBalance `json:"stellar,omitempty"`
+	Errors  []string                 `json:"errors,omitempty"`
+}
+
+func (bridge *Bridge) startWithdrawal(burn solana.Burn) {
+	bridge.statusLock.Lock()
+	defer bridge.statusLock.Unlock()
+	bridge.pending[burn.TxID().String()] = PendingWithdrawal{
+		TxID:        burn.TxID().String(),
+		Slot:        burn.Slot(),
+		Destination: burn.Memo(),
+		Amount:      burn.RawAmount(),
+	}
+}
+
+// holdWithdrawal marks a burn as held because of err, it returns false if it was already held for the same reason
+func (bridge *Bridge) holdWithdrawal(burn solana.Burn, err error) bool {
+	bridge.statusLock.Lock()
+	defer bridge.statusLock.Unlock()
+	txID := burn.TxID().String()
+	pending := bridge.pending[txID]
+	if pending.Held && pending.Error == err.Error() {
+		return false
+	}
+	pending.Held = true
+	pending.Error = err.Error()
+	bridge.pending[txID] = pending
+	return true
+}
+
+// withdrawalHeld checks if a burn is held
+func (bridge *Bridge) withdrawalHeld(burn solana.Burn) bool {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	return bridge.pending[burn.TxID().String()].Held
+}
+
+func (bridge *Bridge) finishWithdrawal(burn solana.Burn, err error) {
+	bridge.statusLock.Lock()
+	defer bridge.statusLock.Unlock()
+	txID := burn.TxID().String()
+	if err != nil {
+		pending := bridge.pending[txID]
+		pending.Held = false
+		pending.Rejected = errors.Is(err, limits.ErrBelowMinimum)
+		pending.Error = err.Error()
+		bridge.pending[txID] = pending
+	} else {
+		delete(bridge.pending, txID)
+	}
+	if burn.Slot() > bridge.lastSlot {
+		bridge.lastSlot = burn.Slot()
+	}
+	bridge.lastSlotSeen = time.Now()
+}
+
+func (bridge *Bridge) stopWatchingBurns() {
+	bridge.statusLock.Lock()
+	defer bridge.statusLock.Unlock()
+	bridge.watchingBurns = false
+}
+
+// Status collects the current status of the bridge
+func (bridge *Bridge) Status(ctx context.Context) (Status, error) {
+	bridge.statusLock.RLock()
+	status := Status{
+		Follower:           !bridge.leading(),
+		DryRun:             bridge.decisions != nil,
+		Synced:             bridge.synced,
+		LastSlot:           bridge.lastSlot,
+		LastSlotSeen:       bridge.lastSlotSeen,
+		Pause:              bridge.pause.State(),
+		PendingWithdrawals: make([]PendingWithdrawal, 0, len(bridge.pending)),
+	}
+	for _, pending := range bridge.pending {
+		status.PendingWithdrawals = append(status.PendingWithdrawals, pending)
+	}
+	bridge.statusLock.RUnlock()
+
+	persisted, err := bridge.blockPersistency.GetHeight()
+	if err != nil {
+		return status, err
+	}
+	status.StellarCursor = persisted.StellarCursor
+	status.MintVolume = bridge.volume(state.VolumeMint)
+	status.WithdrawVolume = bridge.volume(state.VolumeWithdraw)
+	status.HeldMints = bridge.heldMints.All()
+	if bridge.signersClient != nil {
+		status.Cosigners = bridge.signersClient.Cosigners()
+	}
+	if bridge.elector != nil {
+		election := bridge.elector.Status()
+		status.Lease = &election
+	}
+
+	if sol, err := bridge.solanaWallet.Balance(ctx); err != nil {
+		status.Balances.Errors = append(status.Balances.Errors, "solana: "+err.Error())
+	} else {
+		status.Balances.Sol = &sol
+	}
+	if status.Balances.Stellar, err = bridge.wallet.GetBalances(); err != nil {
+		status.Balances.Errors = append(status.Balances.Errors, "stellar: "+err.Error())
+	}
+	return status, nil
+}
+
+// NodeStatus returns the state of this bridge which is exchanged with the other signers
+func (bridge *Bridge) NodeStatus() multisig.NodeStatus {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	return multisig.NodeStatus{
+		Version:         bridge.config.Version,
+		ProtocolVersion: multisig.ProtocolVersion,
+		Height:          bridge.lastSlot,
+		Synced:          bridge.synced,
+		StellarCursor:   bridge.wallet.TransactionStorage.Cursor(),
+		DepositFee:      bridge.config.DepositFee.String(),
+		WithdrawFee:     bridge.config.WithdrawFee.String(),
+		Pause:           bridge.pause.State(),
+	}
+}
+
+// alive reports an error if the bridge stopped following the solana burns
+func (bridge *Bridge) alive() error {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	// synced is set when the burns are watched, so this does not fail while the bridge is starting
+	if !bridge.watchingBurns && bridge.synced {
+		return errors.New("stopped watching solana burns")
+	}
+	return nil
+}
+
+// ready reports an error if the bridge can not process deposits and withdrawals
+func (bridge *Bridge) ready() error {
+	bridge.statusLock.RLock()
+	defer bridge.statusLock.RUnlock()
+	if !bridge.watchingBurns {
+		return errors.New("not watching solana burns")
+	}
+	return nil
+}
+
+// AdminHandler returns the handler of the admin server:
+//
+//	GET /status   the Status of the bridge
+//	GET /healthz  liveness, fails if the bridge stopped following the solana burns
+//	GET /readyz   readiness, fails if the bridge is not following the solana burns yet
+//	POST /pause   pauses the bridge, an optional reason query parameter is shown in the status
+//	POST /resume  resumes the bridge
+//
+// Pause and resume need the admin token of the BridgeConfig as a bearer token in the Authorization header,
+// they are refused if no admin token is configured.
+func (bridge *Bridge) AdminHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status, err := bridge.Status(r.Context())
+		if err != nil {
+			log.Error().Err(err).Msg("failed to get the bridge status")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, status)
+	})
+	mux.HandleFunc("/pause", bridge.adminAction(func(w http.ResponseWriter, r *http.Request) {
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "paused through the admin API"
+		}
+		bridge.pause.Pause(reason)
+		writeJSON(w, bridge.pause.State())
+	}))
+	mux.HandleFunc("/resume", bridge.adminAction(func(w http.ResponseWriter, r *http.Request) {
+		if err := bridge.pause.Resume(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, bridge.pause.State())
+	}))
+	mux.HandleFunc("/healthz", probeHandler(bridge.alive))
+	mux.HandleFunc("/readyz", probeHandler(bridge.ready))
+	return mux
+}
+
+// adminAction only passes POST requests with the admin token to handler
+func (bridge *Bridge) adminAction(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if bridge.config.AdminToken == "" {
+			http.Error(w, "no admin token configured", http.StatusForbidden)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+bridge.config.AdminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func probeHandler(probe func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := probe(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+// ServeAdmin runs the admin server on addr until the context is cancelled
+func ServeAdmin(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Str("address", addr).Msg("Admin server listening")
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
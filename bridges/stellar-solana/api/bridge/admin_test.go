@@ -0,0 +1,32 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
+)
+
+func TestAdminPauseNeedsToken(t *testing.T) {
+	bridge := &Bridge{config: &BridgeConfig{}, pause: pause.NewSwitch("")}
+	pauseWith := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/pause", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		bridge.AdminHandler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusForbidden, pauseWith("secret"), "pausing is refused without an admin token")
+
+	bridge.config.AdminToken = "secret"
+	assert.Equal(t, http.StatusUnauthorized, pauseWith(""))
+	assert.Equal(t, http.StatusUnauthorized, pauseWith("wrong"))
+	assert.False(t, bridge.pause.Paused())
+	assert.Equal(t, http.StatusOK, pauseWith("secret"))
+	assert.True(t, bridge.pause.Paused())
+}
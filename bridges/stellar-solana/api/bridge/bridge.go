@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -11,7 +12,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/faults"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/limits"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/p2p"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
@@ -21,21 +28,50 @@ const (
 	// EthBlockDelay is the amount of blocks to wait before
 	// pushing eth transaction to the stellar network
 	EthBlockDelay = 3
-	// Withdrawing from smartchain to Stellar fee
-	WithdrawFee   = int64(1 * stellar.Precision) // WithdrawFeeof 1 TFT in Stroops
 	BridgeNetwork = "stellar"
 )
 
+var errNotMaster = errors.New("the bridge is not the master")
+
+// statusInterval is how often the status of the cosigners is polled
+const statusInterval = time.Minute
+
+// leaseConfig waits out the time bounds of the Stellar transactions of the previous master before taking over
+var leaseConfig = lease.Config{
+	Duration: lease.DefaultConfig.Duration,
+	Renew:    lease.DefaultConfig.Renew,
+	Handover: stellar.TransactionTimeout,
+}
+
 // Bridge is a high lvl structure which listens on contract events and bridge-related
 // stellar transactions, and handles them
 type Bridge struct {
 	solanaWallet     *solana.Solana
 	wallet           *stellar.Wallet
 	blockPersistency *state.ChainPersistency
+	volumes          *state.VolumeTracker
+	heldMints        *state.HeldMints
 	mut              sync.Mutex
 	config           *BridgeConfig
 	synced           bool
 	signersClient    *SignersClient
+	pause            *pause.Switch
+	// decisions is only set in dry-run mode, see BridgeConfig.DryRun
+	decisions *state.DecisionLog
+	// leases and elector are only set with BridgeConfig.Failover
+	leases  *lease.Service
+	elector *lease.Elector
+	// stellarSigners and mintSigners are the signers last seen by watchSigners
+	stellarSigners signerSet
+	mintSigners    signerSet
+
+	// started and the fields below statusLock are only used to report the status, see admin.go
+	started       time.Time
+	statusLock    sync.RWMutex
+	watchingBurns bool
+	lastSlot      uint64
+	lastSlotSeen  time.Time
+	pending       map[string]PendingWithdrawal
 }
 
 type BridgeConfig struct {
@@ -45,23 +81,56 @@ type BridgeConfig struct {
 	Follower            bool
 	Relay               string
 	Psk                 string
-	// deposit fee in TFT units
-	DepositFee int64
+	// Failover elects the master among the signers of the bridge account instead of Follower
+	Failover bool
+	// DepositFee is charged on deposits from Stellar
+	DepositFee fees.Schedule
+	// WithdrawFee is charged on withdrawals and refunds to Stellar
+	WithdrawFee fees.Schedule
+	// MintLimits and WithdrawLimits bound the amounts before fees
+	MintLimits     limits.Limits
+	WithdrawLimits limits.Limits
+	// AdminAddr is the address the admin http server listens on, empty disables it
+	AdminAddr string
+	// AdminToken authorizes the pause and resume requests on the admin server, empty refuses them
+	AdminToken string
+	// DryRun builds and validates the transactions of the master but records them
+	// in the decision log instead of submitting them.
+	// DryRunSignatures requests the cosigner signatures for them as well.
+	DryRun           bool
+	DryRunSignatures bool
+	// Version is the release of the bridge, it is compared with the one of the cosigners
+	Version string
 }
 
 // NewBridge creates a new Bridge.
 // TODO: context is not used
-func NewBridge(ctx context.Context, wallet *stellar.Wallet, sol *solana.Solana, config *BridgeConfig, host host.Host, router routing.PeerRouting) (bridge *Bridge, err error) {
+func NewBridge(ctx context.Context, wallet *stellar.Wallet, sol *solana.Solana, config *BridgeConfig, host host.Host, router routing.PeerRouting, pauseSwitch *pause.Switch) (bridge *Bridge, err error) {
 	blockPersistency := state.NewChainPersistency(config.PersistencyFile)
 
+	volumes, err := state.NewVolumeTracker(state.VolumeFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	heldMints, err := state.NewHeldMints(state.HeldMintsFile(config.PersistencyFile))
+	if err != nil {
+		return nil, err
+	}
+
 	bridge = &Bridge{
 		solanaWallet:     sol,
 		blockPersistency: blockPersistency,
+		volumes:          volumes,
+		heldMints:        heldMints,
 		wallet:           wallet,
 		config:           config,
+		pause:            pauseSwitch,
+		started:          time.Now(),
+		pending:          make(map[string]PendingWithdrawal),
 	}
-	// Only create the signer client if the bridge is running in master mode
-	if !config.Follower {
+	// Only create the signer client if the bridge can run in master mode
+	if !config.Follower || config.Failover {
 		relayAddrInfo, addrErr := peer.AddrInfoFromString(config.Relay)
 		if addrErr != nil {
 			return nil, addrErr
@@ -76,9 +145,26 @@ func NewBridge(ctx context.Context, wallet *stellar.Wallet, sol *solana.Solana,
 		if err != nil {
 			return nil, err
 		}
-		bridge.signersClient = NewSignersClient(host, router, cosignerPeerIDs, relayAddrInfo)
+		signatures, err := state.NewPartialSignatures(state.SignaturesFile(config.PersistencyFile))
+		if err != nil {
+			return nil, err
+		}
+		bridge.signersClient = NewSignersClient(host, router, cosignerPeerIDs, relayAddrInfo, signatures)
+		bridge.stellarSigners = newSignerSet(cosigners, int64(requiredSignatures))
+
+		if config.Failover {
+			bridge.leases = lease.NewService(host.ID(), leaseConfig)
+			bridge.elector = lease.NewElector(bridge.leases, cosignerPeerIDs, bridge.signersClient.callLease)
+		}
 
 		wallet.SetSignerClient(bridge.signersClient)
+		wallet.SetPauseSwitch(pauseSwitch)
+
+		if config.DryRun {
+			bridge.decisions = state.NewDecisionLog(state.DecisionsFile(config.PersistencyFile))
+			wallet.SetDryRun(bridge.decisions, config.DryRunSignatures)
+			log.Warn().Str("file", state.DecisionsFile(config.PersistencyFile)).Bool("signatures", config.DryRunSignatures).Msg("Running in dry-run mode, transactions are recorded instead of submitted")
+		}
 	}
 
 	if config.RescanBridgeAccount {
@@ -108,6 +194,12 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 	if !bridge.synced {
 		return errors.New("bridge is not synced, retry later")
 	}
+	if bridge.pause.Paused() {
+		return pause.ErrPaused
+	}
+	if !bridge.leading() {
+		return errNotMaster
+	}
 
 	// Check if this tx is a known mint TX
 	log.Info().Str("receiver", memoAddress.String()).Str("txID", txID).Msg("Minting")
@@ -118,6 +210,8 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 	}
 	if known {
 		log.Info().Str("txID", txID).Msg("Skipping known minting transaction")
+		// the bridge might have stopped after the mint and before recording it, a mint is not counted twice
+		bridge.recordMintVolume(txID, depositedAmount)
 		// we already know this withdrawal address, so ignore the transaction
 		return
 	}
@@ -149,7 +243,7 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 		return faults.ErrInvalidReceiver
 	}
 
-	depositFeeBigInt := big.NewInt(stellar.IntToStroops(bridge.config.DepositFee))
+	depositFeeBigInt := big.NewInt(bridge.config.DepositFee.Fee(depositedAmount.Int64()))
 
 	if depositedAmount.Cmp(depositFeeBigInt) <= 0 {
 		log.Error().Str("amount", depositedAmount.String()).Str("txID", txID).Msg("Deposited amount is <= Fee, should be returned")
@@ -158,6 +252,15 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 	amount := &big.Int{}
 	amount = amount.Sub(depositedAmount, depositFeeBigInt)
 
+	if err = bridge.config.MintLimits.CheckAmount(depositedAmount.Int64()); err != nil {
+		log.Warn().Err(err).Str("amount", depositedAmount.String()).Str("txID", txID).Msg("Deposited amount is outside the mint limits, should be returned")
+		return faults.ErrDepositOutsideLimits
+	}
+	if err = bridge.config.MintLimits.CheckVolume(depositedAmount.Int64(), bridge.volume(state.VolumeMint)); err != nil {
+		log.Warn().Err(err).Str("amount", depositedAmount.String()).Str("txID", txID).Msg("Holding the mint until it fits the volume caps")
+		return faults.ErrVolumeCapExceeded
+	}
+
 	requiredSignatureCount, err := bridge.solanaWallet.GetRequiresSignatureCount(ctx)
 	if err != nil {
 		return err
@@ -195,16 +298,21 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 		onlinePeers = append(onlinePeers, p)
 	}
 
-	res, err := bridge.signersClient.SignMint(ctx, onlinePeers, SolanaRequest{
-		Receiver: receiver,
-		Amount:   amount.Int64(),
-		TxID:     txID,
-		// subtract 1 from the required signature count, because the master signature is already included
-		RequiredSignatures: requiredSignatureCount - 1,
-		Tx:                 txB64,
-	})
-	if err != nil {
-		return err
+	var res []SolanaResponse
+	requestSignatures := bridge.decisions == nil || bridge.config.DryRunSignatures
+	if requestSignatures {
+		res, err = bridge.signersClient.SignMint(ctx, onlinePeers, SolanaRequest{
+			Version:  multisig.ProtocolVersion,
+			Receiver: receiver,
+			Amount:   amount.Int64(),
+			TxID:     txID,
+			// subtract 1 from the required signature count, because the master signature is already included
+			RequiredSignatures: requiredSignatureCount - 1,
+			Tx:                 txB64,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// First create the master signature
@@ -221,7 +329,12 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 		return err
 	}
 
-	orderderedSignatures := make([]solana.Signature, len(res))
+	signatureSlots := len(res)
+	if !requestSignatures {
+		// the signatures of the cosigners stay empty
+		signatureSlots = int(tx.Message.Header.NumRequiredSignatures)
+	}
+	orderderedSignatures := make([]solana.Signature, signatureSlots)
 	for i := 0; i < len(signers); i++ {
 		for _, sign := range res {
 			if sign.SigIdx == i {
@@ -234,12 +347,89 @@ func (bridge *Bridge) mint(ctx context.Context, memoAddress solana.Address, depo
 
 	tx.Signatures = orderderedSignatures
 
-	if err = tx.VerifySignatures(); err != nil {
-		log.Error().Err(err).Msg("Signature verification error")
+	if requestSignatures {
+		if err = tx.VerifySignatures(); err != nil {
+			log.Error().Err(err).Msg("Signature verification error")
+			return err
+		}
+	}
+
+	if bridge.decisions != nil {
+		return bridge.recordMint(tx, receiver, amount.Int64(), depositFeeBigInt.Int64(), txID, len(res))
+	}
+
+	if err = bridge.solanaWallet.Mint(ctx, tx); err != nil {
 		return err
 	}
+	metrics.Transfer(metrics.TransferMint, amount.Int64())
+	bridge.recordMintVolume(txID, depositedAmount)
+	return nil
+}
+
+// recordMintVolume counts a deposit which is minted in the mint volume
+func (bridge *Bridge) recordMintVolume(txID string, depositedAmount *big.Int) {
+	// a dry run does not count in the volume caps of a later live run
+	if bridge.decisions != nil {
+		return
+	}
+	if err := bridge.volumes.Record(state.VolumeMint, txID, depositedAmount.Int64()); err != nil {
+		log.Error().Err(err).Str("txID", txID).Msg("failed to record the mint volume")
+	}
+}
+
+// volume returns the amount transferred in the given direction during the last hour and day
+func (bridge *Bridge) volume(direction string) limits.Volume {
+	return limits.Volume{
+		Hour: bridge.volumes.Volume(direction, time.Hour),
+		Day:  bridge.volumes.Volume(direction, 24*time.Hour),
+	}
+}
+
+// recordMint records a mint in the decision log instead of submitting it
+func (bridge *Bridge) recordMint(tx *solana.Transaction, receiver solana.Address, amount int64, fee int64, txID string, signatureCount int) error {
+	encoded, err := tx.ToBase64()
+	if err != nil {
+		return errors.Wrap(err, "could not encode solana transaction to base64")
+	}
+	log.Info().Str("txID", txID).Str("receiver", receiver.String()).Str("amount", stellar.StroopsToDecimal(amount).String()).Msg("Dry run, recording the mint instead of submitting it")
+	return bridge.decisions.Record(state.Decision{
+		Transfer:    metrics.TransferMint,
+		ID:          txID,
+		Destination: receiver.String(),
+		Amount:      amount,
+		Fee:         fee,
+		Signatures:  signatureCount,
+		Transaction: encoded,
+	})
+}
+
+// leading reports if the bridge acts as master
+func (bridge *Bridge) leading() bool {
+	if bridge.elector != nil {
+		return bridge.elector.Leading()
+	}
+	return !bridge.config.Follower
+}
+
+// LeaseService returns the service granting the master lease to the other signers, nil without failover
+func (bridge *Bridge) LeaseService() *lease.Service {
+	return bridge.leases
+}
+
+// monitorDeposits mints the deposits on the bridge account until the context is done
+func (bridge *Bridge) monitorDeposits(ctx context.Context) {
+	// Scan bridge account for outgoing transactions to avoid double withdraws or refunds
+	if err := bridge.wallet.ScanBridgeAccount(ctx); err != nil {
+		panic(err)
+	}
 
-	return bridge.solanaWallet.Mint(ctx, tx)
+	// Monitor the bridge wallet for incoming transactions
+	// mint transactions on solana if possible
+	go func() {
+		if err := bridge.wallet.MonitorBridgeAccountAndMint(ctx, bridge.mint, bridge.blockPersistency, bridge.heldMints); err != nil {
+			panic(err)
+		}
+	}()
 }
 
 // Start the main processing loop of the bridge
@@ -252,25 +442,30 @@ func (bridge *Bridge) Start(ctx context.Context) error {
 	// Only the bridge running as the master bridge should do the following things:
 	// - Monitor the Bridge Stellar account and initiate Minting transactions accordingly
 	// - Monitor the Contract for Withdrawal events and initiate a Withdrawal transaction accordingly
-	if !bridge.config.Follower {
-		// Scan bridge account for outgoing transactions to avoid double withdraws or refunds
-		if err := bridge.wallet.ScanBridgeAccount(ctx); err != nil {
-			panic(err)
-		}
-
-		// Monitor the bridge wallet for incoming transactions
-		// mint transactions on solana if possible
-		go func() {
-			if err := bridge.wallet.MonitorBridgeAccountAndMint(ctx, bridge.mint, bridge.blockPersistency); err != nil {
-				panic(err)
-			}
-		}()
+	// With failover every signer keeps the burns, so the one taking over withdraws the burns
+	// the previous master did not, the deposits and burns are only processed while it is master.
+	if bridge.elector != nil {
+		go bridge.elector.Run(ctx, bridge.monitorDeposits)
+	} else if !bridge.config.Follower {
+		bridge.monitorDeposits(ctx)
+	}
 
+	if bridge.signersClient != nil {
+		go bridge.signersClient.MonitorCosigners(ctx, statusInterval, bridge.NodeStatus)
+		go bridge.watchSigners(ctx)
 	}
 
 	go func() {
 		// txMap := make(map[string]solana.Burn)
+		bridge.statusLock.Lock()
 		bridge.synced = true
+		bridge.watchingBurns = true
+		bridge.statusLock.Unlock()
+		defer bridge.stopWatchingBurns()
+		// burns are queued while the bridge is paused and withdrawn in order once it is resumed
+		var backlog []solana.Burn
+		resumeCheck := time.NewTicker(pause.PollInterval)
+		defer resumeCheck.Stop()
 		for {
 			select {
 			// Remember new withdraws
@@ -282,28 +477,79 @@ func (bridge *Bridge) Start(ctx context.Context) error {
 					return
 				}
 
-				// log.Info().Str("txHash", burn.TxID().String()).Str("shortTxHash", burn.ShortTxID().String()).Msg("Remembering withdraw event")
-				log.Info().Str("txHash", burn.TxID().String()).Str("shortTxHash", burn.ShortTxID().String()).Msg("Starting withdrawal")
+				log.Info().Str("txHash", burn.TxID().String()).Str("shortTxHash", burn.ShortTxID().String()).Msg("Remembering withdraw event")
 				// txMap[burn.ShortTxID().String()] = burn
-				// log.Info().Str("txHash", we.TxID().String()).Msg("Starting withdrawal")
-				err := bridge.withdraw(ctx, burn)
-				if err != nil {
-					log.Error().Err(err).Str("address", burn.Memo()).Msg("failed to create payment for withdrawal")
-					continue
-				}
+				bridge.startWithdrawal(burn)
+				backlog = append(backlog, burn)
+			case <-resumeCheck.C:
 			case <-ctx.Done():
 				return
 			}
+			backlog = bridge.processBurns(ctx, backlog)
 		}
 	}()
 
 	return nil
 }
 
+// processBurns withdraws the queued burns in order until the bridge is paused, it returns the burns which are left.
+// Burns which do not fit the withdraw limits are held, they are kept and tried again on the next call.
+func (bridge *Bridge) processBurns(ctx context.Context, burns []solana.Burn) []solana.Burn {
+	var held []solana.Burn
+	for len(burns) > 0 {
+		if bridge.pause.Paused() {
+			log.Debug().Int("queued", len(burns)).Msg("Bridge is paused, not processing burns")
+			return append(held, burns...)
+		}
+		// with failover the burns are kept until the bridge is master
+		if bridge.elector != nil && !bridge.elector.Leading() {
+			return append(held, burns...)
+		}
+		burn := burns[0]
+		burns = burns[1:]
+
+		if !bridge.withdrawalHeld(burn) {
+			log.Info().Str("txHash", burn.TxID().String()).Str("shortTxHash", burn.ShortTxID().String()).Msg("Starting withdrawal")
+		}
+		err := bridge.withdraw(ctx, burn)
+		if errors.Is(err, limits.ErrBelowMinimum) {
+			// a burn below the minimum never fits the limits, it is not held forever
+			log.Error().Err(err).Str("txHash", burn.TxID().String()).Msg("Rejecting withdrawal below the minimum, it is not paid out")
+		} else if limits.Exceeded(err) {
+			// held burns are checked again on every tick, only log a change
+			if bridge.holdWithdrawal(burn, err) {
+				log.Warn().Err(err).Str("txHash", burn.TxID().String()).Msg("Holding withdrawal")
+			}
+			held = append(held, burn)
+			continue
+		}
+		bridge.finishWithdrawal(burn, err)
+		bridge.recordHeadLag(ctx, burn)
+		if err != nil {
+			log.Error().Err(err).Str("address", burn.Memo()).Msg("failed to create payment for withdrawal")
+		}
+	}
+	return held
+}
+
+// recordHeadLag records the slots between the chain tip and the processed burn in the metrics
+func (bridge *Bridge) recordHeadLag(ctx context.Context, burn solana.Burn) {
+	tip, err := bridge.solanaWallet.CurrentSlot(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("could not get the current solana slot")
+		return
+	}
+	if tip > burn.Slot() {
+		metrics.HeadLag.Set(float64(tip - burn.Slot()))
+	} else {
+		metrics.HeadLag.Set(0)
+	}
+}
+
 func (bridge *Bridge) withdraw(ctx context.Context, burn solana.Burn) (err error) {
 	// if a withdraw was made to the bridge fee wallet or the bridge address, soak the funds and return
 	// TODO: Should these adresses be fetched through the wallet?
-	if burn.Memo() == bridge.wallet.Config.StellarFeeWallet || burn.Memo() == bridge.wallet.GetAddress() {
+	if burn.Memo() == bridge.wallet.Config.StellarFeeWallet || burn.Memo() == bridge.wallet.BridgeAccount() {
 		log.Warn().Msg("Received a withdrawal with destination which is either the fee wallet or the bridge wallet, skipping...")
 		return nil
 	}
@@ -317,16 +563,36 @@ func (bridge *Bridge) withdraw(ctx context.Context, burn solana.Burn) (err error
 		return
 	}
 
-	if amount <= uint64(WithdrawFee) {
+	fee := bridge.config.WithdrawFee.Fee(int64(amount))
+	if amount <= uint64(fee) {
 		log.Warn().Str("amount", stellar.StroopsToDecimal(int64(amount)).String()).Str("solanaTx", hash.String()).Str("shortSolanaTxID", shortTxID.String()).Msg("Withdrawn amount is less than the withdraw fee, skip it")
 		return
 	}
 
+	withdrawn := int64(amount)
+	if err = bridge.config.WithdrawLimits.CheckAmount(withdrawn); err != nil {
+		return
+	}
+	if err = bridge.config.WithdrawLimits.CheckVolume(withdrawn, bridge.volume(state.VolumeWithdraw)); err != nil {
+		return
+	}
+
 	log.Info().Str("solanaTx", hash.String()).Str("shortSolanaTxID", shortTxID.String()).Str("destination", burn.Memo()).Str("amount", stellar.StroopsToDecimal(int64(amount)).String()).Msg("Creating a withdraw tx")
 
-	amount -= uint64(WithdrawFee)
+	amount -= uint64(fee)
 	// TODO: Should this adress be fetched through the wallet?
-	includeWithdrawFee := bridge.wallet.Config.StellarFeeWallet != ""
-	err = bridge.wallet.CreateAndSubmitPayment(ctx, burn.Memo(), amount, burn.Caller(), shortTxID, "", includeWithdrawFee)
+	if bridge.wallet.Config.StellarFeeWallet == "" {
+		fee = 0
+	}
+	if err = bridge.wallet.CreateAndSubmitPayment(ctx, burn.Memo(), amount, burn.Caller(), shortTxID, fee); err != nil {
+		return
+	}
+	// a dry run does not count in the volume caps of a later live run
+	if bridge.decisions != nil {
+		return
+	}
+	if err := bridge.volumes.Record(state.VolumeWithdraw, shortTxID.String(), withdrawn); err != nil {
+		log.Error().Err(err).Str("solanaTx", hash.String()).Msg("failed to record the withdraw volume")
+	}
 	return
 }
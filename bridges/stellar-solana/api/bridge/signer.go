@@ -2,13 +2,15 @@ package bridge
 
 import (
 	"context"
-	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	gorpc "github.com/libp2p/go-libp2p-gorpc"
-	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -16,10 +18,16 @@ import (
 	"github.com/libp2p/go-libp2p/core/routing"
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
 	"github.com/rs/zerolog/log"
-	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/quorum"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
 	"github.com/threefoldtech/libp2p-relay/client"
 )
 
@@ -44,26 +52,12 @@ func (c *SignerConfig) Valid() error {
 	return nil
 }
 
-func NewHost(ctx context.Context, secret, relay string, psk string) (host.Host, routing.PeerRouting, error) {
-	seed, err := strkey.Decode(strkey.VersionByteSeed, secret)
+func NewHost(ctx context.Context, identity keys.Ed25519Signer, relay string, psk string) (host.Host, routing.PeerRouting, error) {
+	privKey, err := keys.Libp2pKey(identity)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if len(seed) != ed25519.SeedSize {
-		return nil, nil, fmt.Errorf("invalid seed size '%d' expecting '%d'", len(seed), ed25519.SeedSize)
-	}
-
-	var privKey crypto.PrivKey
-	if secret != "" {
-		privKey, err = crypto.UnmarshalEd25519PrivateKey(
-			ed25519.NewKeyFromSeed(seed),
-		)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
 	key, err := hex.DecodeString(psk)
 	if err != nil {
 		return nil, nil, err
@@ -95,34 +89,72 @@ func NewHost(ctx context.Context, secret, relay string, psk string) (host.Host,
 }
 
 type SignersClient struct {
-	peers    []peer.ID
 	host     host.Host
 	router   routing.PeerRouting
 	client   *gorpc.Client
 	idClient *gorpc.Client
 	relay    *peer.AddrInfo
+	// signatures are the signatures collected for requests which did not get the required signatures yet
+	signatures *state.PartialSignatures
+
+	// peers are the cosigners, they are replaced when the signers of the bridge account change
+	peers     []peer.ID
+	peersLock sync.Mutex
+
+	// lastSeen is the time of the last valid reply of every cosigner
+	lastSeen     map[peer.ID]time.Time
+	lastSeenLock sync.Mutex
+
+	// versions is the signing protocol version of every cosigner, exchanged the first time the bridge connects to it
+	versions     map[peer.ID]int
+	versionsLock sync.Mutex
+
+	// nodes is the outcome of the last status poll of every cosigner
+	nodes     map[peer.ID]nodeStatus
+	nodesLock sync.Mutex
 }
 
-type response struct {
-	answer *multisig.StellarSignResponse
-	peer   peer.ID
-	err    error
+// nodeStatus is the outcome of the status poll of a cosigner
+type nodeStatus struct {
+	// status is the last status the cosigner answered, it is kept if a later poll fails
+	status     *multisig.NodeStatus
+	polled     time.Time
+	err        error
+	mismatches []string
 }
 
-type solanaResponse struct {
-	answer *SolanaResponse
-	peer   peer.ID
-	err    error
+// CosignerStatus is the last time a cosigner replied to a request
+type CosignerStatus struct {
+	PeerID string `json:"peerId"`
+	// LastSeen is nil if the cosigner did not reply since the bridge started
+	LastSeen *time.Time `json:"lastSeen"`
+	// ProtocolVersion is the signing protocol version used with the cosigner, 0 if it is not known yet
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
+	// Node is the last status the cosigner answered to the status poll, Polled when it was last polled
+	// and PollError why it did not answer then
+	Node      *multisig.NodeStatus `json:"node,omitempty"`
+	Polled    *time.Time           `json:"polled,omitempty"`
+	PollError string               `json:"pollError,omitempty"`
+	// Mismatches are the settings of the cosigner which differ from the ones of this bridge
+	Mismatches []string `json:"mismatches,omitempty"`
 }
 
-type idResponse struct {
-	answer *IDResponse
-	peer   peer.ID
-	err    error
+// errStatusUnsupported is the poll error of a cosigner which does not answer the status poll yet
+var errStatusUnsupported = errors.New("the cosigner runs a version without the status poll")
+
+// signingRound asks a cosigner again if it can not be reached, a cosigner which refuses a request is not asked again in the same round
+var signingRound = quorum.Config{
+	Timeout:  quorum.DefaultConfig.Timeout,
+	Attempts: quorum.DefaultConfig.Attempts,
+	Backoff:  quorum.DefaultConfig.Backoff,
+	Retry: func(err error) bool {
+		return !gorpc.IsServerError(err)
+	},
 }
 
-// NewSignersClient creates a signer client to ask cosigners to sign
-func NewSignersClient(host host.Host, router routing.PeerRouting, cosigners []peer.ID, relay *peer.AddrInfo) *SignersClient {
+// NewSignersClient creates a signer client to ask cosigners to sign.
+// The signatures collected for a request are kept in signatures, if it is not nil.
+func NewSignersClient(host host.Host, router routing.PeerRouting, cosigners []peer.ID, relay *peer.AddrInfo, signatures *state.PartialSignatures) *SignersClient {
 	return &SignersClient{
 		client:   gorpc.NewClient(host, Protocol),
 		idClient: gorpc.NewClient(host, SolIDProtocol),
@@ -130,158 +162,235 @@ func NewSignersClient(host host.Host, router routing.PeerRouting, cosigners []pe
 		router:   router,
 		peers:    cosigners,
 		relay:    relay,
+
+		signatures: signatures,
+		lastSeen:   make(map[peer.ID]time.Time),
+		versions:   make(map[peer.ID]int),
+		nodes:      make(map[peer.ID]nodeStatus),
 	}
 }
 
-func (s *SignersClient) Sign(ctx context.Context, signRequest multisig.StellarSignRequest) ([]multisig.StellarSignResponse, error) {
-	// cancel context after 30 seconds
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	responseChannels := make([]chan response, 0, len(s.peers))
-	for _, addr := range s.peers {
-		respCh := make(chan response, 1)
-		responseChannels = append(responseChannels, respCh)
-		go func(peerID peer.ID, ch chan response) {
-			defer close(ch)
-			answer, err := s.sign(ctxWithTimeout, peerID, signRequest)
-
-			select {
-			case <-ctxWithTimeout.Done():
-			case ch <- response{answer: answer, peer: peerID, err: err}:
-			}
-		}(addr, respCh)
+// SetPeers replaces the cosigners, the signatures collected earlier from the cosigners which are kept are still used
+func (s *SignersClient) SetPeers(cosigners []peer.ID) {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+	s.peers = cosigners
+}
 
+// store returns where the signatures are kept between rounds, nil if they are not kept
+func (s *SignersClient) store() quorum.Store {
+	if s.signatures == nil {
+		return nil
 	}
+	return s.signatures
+}
 
-	var results []multisig.StellarSignResponse
+func (s *SignersClient) currentPeers() []peer.ID {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+	return s.peers
+}
 
-	for len(responseChannels) > 0 {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
+func (s *SignersClient) seen(id peer.ID) {
+	s.lastSeenLock.Lock()
+	defer s.lastSeenLock.Unlock()
+	s.lastSeen[id] = time.Now()
+}
+
+// report logs the outcome of a signing round for every cosigner
+func (s *SignersClient) report(kind string, outcomes []quorum.Outcome) {
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.Stored:
+			log.Info().Str("peerID", outcome.Peer.String()).Str("kind", kind).Msg("signature of an earlier round")
+		case outcome.Answered:
+			log.Info().Str("peerID", outcome.Peer.String()).Str("kind", kind).Int("attempts", outcome.Attempts).Msg("got a valid reply")
+		case outcome.Failed():
+			log.Error().Err(outcome.Err).Str("peerID", outcome.Peer.String()).Str("kind", kind).Int("attempts", outcome.Attempts).Msg("failed to get signature")
+			metrics.SigningFailures.WithLabelValues(kind, outcome.Peer.String()).Inc()
 		}
-		receivedFrom := -1
-	responsechannelsLoop:
-		for i, responseChannel := range responseChannels {
-			select {
-			case reply := <-responseChannel:
-				receivedFrom = i
-				if reply.err != nil {
-					log.Error().Err(reply.err).Str("peerID", reply.peer.String()).Msg("failed to get signature")
-				} else {
-					if reply.answer != nil {
-						log.Info().Str("peerID", reply.peer.String()).Msg("got a valid reply")
-						results = append(results, *reply.answer)
-					} else {
-						log.Info().Str("peerID", reply.peer.String()).Msg("got empty signing request reply")
-					}
-				}
-				break responsechannelsLoop
-			default: // don't block
-			}
+		if outcome.StoreErr != nil {
+			log.Warn().Err(outcome.StoreErr).Str("peerID", outcome.Peer.String()).Str("kind", kind).Msg("failed to keep the signature for a later round")
+		}
+	}
+}
+
+// Cosigners returns the status of the cosigners
+func (s *SignersClient) Cosigners() []CosignerStatus {
+	s.lastSeenLock.Lock()
+	defer s.lastSeenLock.Unlock()
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	s.nodesLock.Lock()
+	defer s.nodesLock.Unlock()
+	peers := s.currentPeers()
+	cosigners := make([]CosignerStatus, 0, len(peers))
+	for _, id := range peers {
+		status := CosignerStatus{PeerID: id.String(), ProtocolVersion: s.versions[id]}
+		if lastSeen, found := s.lastSeen[id]; found {
+			status.LastSeen = &lastSeen
 		}
-		if receivedFrom >= 0 {
-			// Remove the channel from the list
-			responseChannels[receivedFrom] = responseChannels[len(responseChannels)-1]
-			responseChannels = responseChannels[:len(responseChannels)-1]
-			// check if we have enough signatures
-			if len(results) == signRequest.RequiredSignatures {
-				break
+		if node, found := s.nodes[id]; found {
+			status.Node = node.status
+			status.Polled = &node.polled
+			status.Mismatches = node.mismatches
+			if node.err != nil {
+				status.PollError = node.err.Error()
 			}
-		} else {
-			time.Sleep(time.Millisecond * 100)
 		}
+		cosigners = append(cosigners, status)
+	}
+	return cosigners
+}
 
+// version returns the signing protocol version to use with a cosigner, it is exchanged with a Hello the first time.
+// A cosigner which does not know Hello yet speaks version 1.
+func (s *SignersClient) version(ctx context.Context, id peer.ID) (int, error) {
+	s.versionsLock.Lock()
+	version, found := s.versions[id]
+	s.versionsLock.Unlock()
+	if found {
+		return version, nil
 	}
 
-	if len(results) != signRequest.RequiredSignatures {
-		return nil, fmt.Errorf("required number of signatures is not met")
+	var response multisig.HelloResponse
+	err := s.client.CallContext(ctx, id, "SignerService", "Hello", &multisig.HelloRequest{Version: multisig.ProtocolVersion}, &response)
+	switch {
+	case err == nil:
+		// a newer cosigner still signs the requests of the versions it supports
+		version = min(response.Version, multisig.ProtocolVersion)
+	case unknownMethod(err):
+		version = 1
+	default:
+		return 0, err
+	}
+	if err = multisig.CheckVersion(version); err != nil {
+		return 0, err
 	}
+	log.Info().Str("peerID", id.String()).Int("version", version).Msg("signing protocol version of cosigner")
 
-	return results, nil
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	s.versions[id] = version
+	return version, nil
 }
 
-func (s *SignersClient) sign(ctx context.Context, id peer.ID, signRequest multisig.StellarSignRequest) (*multisig.StellarSignResponse, error) {
-	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the signatures prove private key ownership")
-	arHost := s.host.(*autorelay.AutoRelayHost)
+// forgetVersion makes the protocol version be exchanged again on the next request, after a cosigner was downgraded
+func (s *SignersClient) forgetVersion(id peer.ID) {
+	s.versionsLock.Lock()
+	defer s.versionsLock.Unlock()
+	delete(s.versions, id)
+}
 
-	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
-		return nil, errors.Wrapf(err, "failed to connect to host id '%s'", id)
-	}
+// unknownMethod checks if a call failed because the cosigner does not have the method
+func unknownMethod(err error) bool {
+	return gorpc.IsServerError(err) && strings.Contains(err.Error(), "can't find method")
+}
 
-	var response multisig.StellarSignResponse
-	log.Info().Str("PeerID", id.String()).Msg("Calling SignerService Sign")
-	if err := s.client.CallContext(ctx, id, "SignerService", "Sign", &signRequest, &response); err != nil {
+// Sign asks the cosigners to sign a Stellar transaction until the required signatures are collected.
+// The signatures are kept per kind and memo of the transaction together with the transaction itself,
+// so signing the same transaction again only asks the missing cosigners. Signing another transaction
+// for the same kind and memo drops the signatures collected before.
+func (s *SignersClient) Sign(ctx context.Context, signRequest multisig.SignRequest) ([]multisig.StellarSignResponse, error) {
+	defer metrics.ObserveSince(metrics.SigningDuration.WithLabelValues(metrics.SignStellar), time.Now())
+	memo, err := transactionMemo(signRequest.TxnXDR)
+	if err != nil {
 		return nil, err
 	}
+	request := stellarRequest(signRequest.Kind, memo)
+	if s.signatures != nil {
+		if err = s.signatures.SetPayload(request, signRequest.TxnXDR); err != nil {
+			log.Warn().Err(err).Str("kind", string(signRequest.Kind)).Str("memo", memo).Msg("failed to keep the transaction for a later round")
+		}
+	}
 
-	return &response, nil
+	signatures, outcomes, err := quorum.Collect(ctx, signingRound, s.store(), request, s.currentPeers(), signRequest.RequiredSignatures, func(ctx context.Context, id peer.ID) (multisig.StellarSignResponse, error) {
+		response, err := s.sign(ctx, id, signRequest)
+		if err != nil {
+			return multisig.StellarSignResponse{}, err
+		}
+		return *response, nil
+	})
+	s.report(metrics.SignStellar, outcomes)
+	return signatures, err
 }
 
-func (s *SignersClient) SignMint(ctx context.Context, peers []peer.ID, signRequest SolanaRequest) ([]SolanaResponse, error) {
-	// cancel context after 30 seconds
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+// Transaction returns the Stellar transaction the signatures for kind and memo are collected for,
+// an empty string if there is none.
+func (s *SignersClient) Transaction(kind multisig.RequestKind, memo string) string {
+	if s.signatures == nil {
+		return ""
+	}
+	return s.signatures.Payload(stellarRequest(kind, memo))
+}
 
-	responseChannels := make([]chan solanaResponse, 0, len(s.peers))
-	for _, addr := range peers {
-		respCh := make(chan solanaResponse, 1)
-		responseChannels = append(responseChannels, respCh)
-		go func(peerID peer.ID, ch chan solanaResponse) {
-			defer close(ch)
-			answer, err := s.signMint(ctxWithTimeout, peerID, signRequest)
-
-			select {
-			case <-ctxWithTimeout.Done():
-			case ch <- solanaResponse{answer: answer, peer: peerID, err: err}:
-			}
-		}(addr, respCh)
+// stellarRequest is the id under which the signatures for a Stellar transaction are kept
+func stellarRequest(kind multisig.RequestKind, memo string) string {
+	return fmt.Sprintf("stellar/%s/%s", kind, memo)
+}
 
+// transactionMemo returns the hex encoded memo of a Stellar transaction
+func transactionMemo(txnXDR string) (string, error) {
+	generic, err := txnbuild.TransactionFromXDR(txnXDR)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode the transaction")
 	}
+	tx, ok := generic.Transaction()
+	if !ok {
+		return "", errors.New("not a transaction")
+	}
+	return stellar.ExtractMemoFromTx(tx)
+}
 
-	var results []SolanaResponse
+func (s *SignersClient) sign(ctx context.Context, id peer.ID, signRequest multisig.SignRequest) (*multisig.StellarSignResponse, error) {
+	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the signatures prove private key ownership")
+	arHost := s.host.(*autorelay.AutoRelayHost)
 
-	for len(responseChannels) > 0 {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-		receivedFrom := -1
-	responsechannelsLoop:
-		for i, responseChannel := range responseChannels {
-			select {
-			case reply := <-responseChannel:
-				receivedFrom = i
-				if reply.err != nil {
-					log.Error().Err(reply.err).Str("peerID", reply.peer.String()).Msg("failed to get signature")
-				} else {
-					if reply.answer != nil {
-						log.Info().Msg("got a valid reply from a signer")
-						results = append(results, *reply.answer)
-					}
-				}
-				break responsechannelsLoop
-			default: // don't block
-			}
-		}
-		if receivedFrom >= 0 {
-			// Remove the channel from the list
-			responseChannels[receivedFrom] = responseChannels[len(responseChannels)-1]
-			responseChannels = responseChannels[:len(responseChannels)-1]
-			// check if we have enough signatures
-			if len(results) == int(signRequest.RequiredSignatures) {
-				break
-			}
-		} else {
-			time.Sleep(time.Millisecond * 100)
-		}
+	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to host id '%s'", id)
+	}
 
+	version, err := s.version(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to exchange the protocol version with host id '%s'", id)
 	}
 
-	if len(results) != int(signRequest.RequiredSignatures) {
-		return nil, fmt.Errorf("required number of signatures is not met")
+	var response multisig.StellarSignResponse
+	if version < 2 {
+		log.Info().Str("PeerID", id.String()).Msg("Calling SignerService Sign")
+		legacy := signRequest.Legacy()
+		err = s.client.CallContext(ctx, id, "SignerService", "Sign", &legacy, &response)
+	} else {
+		log.Info().Str("PeerID", id.String()).Str("kind", string(signRequest.Kind)).Msg("Calling SignerService SignTransaction")
+		err = s.client.CallContext(ctx, id, "SignerService", "SignTransaction", &signRequest, &response)
 	}
+	if err != nil {
+		if unknownMethod(err) {
+			s.forgetVersion(id)
+		}
+		return nil, err
+	}
+	s.seen(id)
 
-	return results, nil
+	return &response, nil
+}
+
+// SignMint asks the given cosigners to sign a mint transaction until the required signatures are collected.
+// The signatures are kept per transaction, so signing the same transaction again only asks the missing cosigners.
+func (s *SignersClient) SignMint(ctx context.Context, peers []peer.ID, signRequest SolanaRequest) ([]SolanaResponse, error) {
+	defer metrics.ObserveSince(metrics.SigningDuration.WithLabelValues(metrics.SignMint), time.Now())
+	txHash := sha256.Sum256([]byte(signRequest.Tx))
+	request := "mint/" + hex.EncodeToString(txHash[:])
+
+	signatures, outcomes, err := quorum.Collect(ctx, signingRound, s.store(), request, peers, int(signRequest.RequiredSignatures), func(ctx context.Context, id peer.ID) (SolanaResponse, error) {
+		response, err := s.signMint(ctx, id, signRequest)
+		if err != nil {
+			return SolanaResponse{}, err
+		}
+		return *response, nil
+	})
+	s.report(metrics.SignMint, outcomes)
+	return signatures, err
 }
 
 func (s *SignersClient) signMint(ctx context.Context, id peer.ID, signRequest SolanaRequest) (*SolanaResponse, error) {
@@ -291,79 +400,132 @@ func (s *SignersClient) signMint(ctx context.Context, id peer.ID, signRequest So
 	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
 		return nil, errors.Wrapf(err, "failed to connect to host id '%s'", id)
 	}
+	if _, err := s.version(ctx, id); err != nil {
+		return nil, errors.Wrapf(err, "failed to exchange the protocol version with host id '%s'", id)
+	}
 
 	var response SolanaResponse
 	if err := s.client.CallContext(ctx, id, "SignerService", "SignMint", &signRequest, &response); err != nil {
 		return nil, err
 	}
+	s.seen(id)
 
 	return &response, nil
 }
 
+// SolID asks the cosigners for their solana address until requiredPeers of them answered
 func (s *SignersClient) SolID(ctx context.Context, requiredPeers int) (map[peer.ID]solana.Address, error) {
-	// cancel context after 30 seconds
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+	type identified struct {
+		peer    peer.ID
+		address solana.Address
+	}
+	answers, outcomes, err := quorum.Collect(ctx, signingRound, nil, "", s.currentPeers(), requiredPeers, func(ctx context.Context, id peer.ID) (identified, error) {
+		response, err := s.solID(ctx, id)
+		if err != nil {
+			return identified{}, err
+		}
+		return identified{peer: id, address: response.ID}, nil
+	})
+	for _, outcome := range outcomes {
+		if outcome.Failed() {
+			log.Error().Err(outcome.Err).Str("peerID", outcome.Peer.String()).Int("attempts", outcome.Attempts).Msg("failed to get solana ID")
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "required number of peers not identified")
+	}
 
-	responseChannels := make([]chan idResponse, 0, len(s.peers))
-	for _, addr := range s.peers {
-		respCh := make(chan idResponse, 1)
-		responseChannels = append(responseChannels, respCh)
-		go func(peerID peer.ID, ch chan idResponse) {
-			defer close(ch)
-			answer, err := s.solID(ctxWithTimeout, peerID)
-
-			select {
-			case <-ctxWithTimeout.Done():
-			case ch <- idResponse{answer: answer, peer: peerID, err: err}:
-			}
-		}(addr, respCh)
+	results := make(map[peer.ID]solana.Address, len(answers))
+	for _, answer := range answers {
+		log.Info().Str("Peer ID", answer.peer.String()).Str("Solana ID", answer.address.String()).Msg("got a valid reply from a peer")
+		results[answer.peer] = answer.address
+	}
+	return results, nil
+}
 
+// MonitorCosigners polls the status of the cosigners every interval until the context is done.
+// The settings of the cosigners which differ from the ones in the status of this bridge are logged and reported in Cosigners.
+func (s *SignersClient) MonitorCosigners(ctx context.Context, interval time.Duration, own func() multisig.NodeStatus) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.pollStatus(ctx, own(), interval/2)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	results := make(map[peer.ID]solana.Address)
+// pollStatus asks all cosigners for their status and compares it with own
+func (s *SignersClient) pollStatus(ctx context.Context, own multisig.NodeStatus, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, id := range s.currentPeers() {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			status, err := s.nodeStatus(ctx, id)
+			s.updateNode(id, own, status, err)
+		}(id)
+	}
+	wg.Wait()
+}
 
-	for len(responseChannels) > 0 {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-		receivedFrom := -1
-	responsechannelsLoop:
-		for i, responseChannel := range responseChannels {
-			select {
-			case reply := <-responseChannel:
-				receivedFrom = i
-				if reply.err != nil {
-					log.Error().Err(reply.err).Str("peerID", reply.peer.String()).Msg("failed to get solana ID")
-				} else {
-					if reply.answer != nil {
-						log.Info().Str("Peer ID", reply.peer.String()).Str("Solana ID", reply.answer.ID.String()).Msg("got a valid reply from a peer")
-						results[reply.peer] = reply.answer.ID
-					}
-				}
-				break responsechannelsLoop
-			default: // don't block
-			}
-		}
-		if receivedFrom >= 0 {
-			// Remove the channel from the list
-			responseChannels[receivedFrom] = responseChannels[len(responseChannels)-1]
-			responseChannels = responseChannels[:len(responseChannels)-1]
-			// check if we have enough signatures
-			if len(results) == requiredPeers {
-				break
-			}
-		} else {
-			time.Sleep(time.Millisecond * 100)
-		}
+func (s *SignersClient) nodeStatus(ctx context.Context, id peer.ID) (status multisig.NodeStatus, err error) {
+	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the status is public")
+	arHost := s.host.(*autorelay.AutoRelayHost)
 
+	if err = client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
+		return status, errors.Wrapf(err, "failed to connect to host id '%s'", id)
+	}
+	err = s.client.CallContext(ctx, id, "SignerService", "Status", &multisig.StatusRequest{}, &status)
+	if unknownMethod(err) {
+		err = errStatusUnsupported
 	}
+	return status, err
+}
 
-	if len(results) < requiredPeers {
-		return nil, fmt.Errorf("required number of peers not identified")
+// updateNode keeps the outcome of the status poll of a cosigner and logs what changed
+func (s *SignersClient) updateNode(id peer.ID, own multisig.NodeStatus, status multisig.NodeStatus, err error) {
+	s.nodesLock.Lock()
+	defer s.nodesLock.Unlock()
+	previous, polled := s.nodes[id]
+	node := nodeStatus{status: previous.status, polled: time.Now(), err: err, mismatches: previous.mismatches}
+	if err != nil {
+		if !polled || previous.err == nil {
+			log.Warn().Err(err).Str("peerID", id.String()).Msg("cosigner did not answer the status poll")
+		}
+		s.nodes[id] = node
+		return
 	}
 
-	return results, nil
+	node.status = &status
+	node.mismatches = own.Mismatches(status)
+	log.Info().Str("peerID", id.String()).Str("version", status.Version).Uint64("slot", status.Height).Bool("synced", status.Synced).Str("stellarCursor", status.StellarCursor).Bool("paused", status.Pause.Paused).Msg("cosigner status")
+	if previous.err != nil {
+		log.Info().Str("peerID", id.String()).Msg("cosigner answers the status poll again")
+	}
+	switch {
+	case len(node.mismatches) > 0 && !slices.Equal(node.mismatches, previous.mismatches):
+		log.Warn().Str("peerID", id.String()).Strs("mismatches", node.mismatches).Msg("cosigner settings differ from this bridge, it might refuse to sign")
+	case len(node.mismatches) == 0 && len(previous.mismatches) > 0:
+		log.Info().Str("peerID", id.String()).Msg("cosigner settings match this bridge again")
+	}
+	s.nodes[id] = node
+}
+
+// callLease calls a method of the lease service of a signer
+func (s *SignersClient) callLease(ctx context.Context, id peer.ID, method string, request *lease.Request, response *lease.Grant) error {
+	ctx = network.WithUseTransient(ctx, "transient connection is allowed as the lease is granted to the peer id")
+	arHost := s.host.(*autorelay.AutoRelayHost)
+
+	if err := client.ConnectToPeer(ctx, arHost, s.router, s.relay, id); err != nil {
+		return errors.Wrapf(err, "failed to connect to host id '%s'", id)
+	}
+	return s.client.CallContext(ctx, id, lease.ServiceName, method, request, response)
 }
 
 func (s *SignersClient) solID(ctx context.Context, id peer.ID) (*IDResponse, error) {
@@ -378,6 +540,7 @@ func (s *SignersClient) solID(ctx context.Context, id peer.ID) (*IDResponse, err
 	if err := s.idClient.CallContext(ctx, id, "SolIDService", "ID", &SolanaRequest{}, &response); err != nil {
 		return nil, err
 	}
+	s.seen(id)
 
 	return &response, nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"sync"
+	"time"
 
 	gorpc "github.com/libp2p/go-libp2p-gorpc"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -12,11 +14,18 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/shopspring/decimal"
+	"github.com/stellar/go/amount"
 	"github.com/stellar/go/protocols/horizon/effects"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/policy"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
 )
 
@@ -29,9 +38,12 @@ var (
 	ErrTransactionAlreadyExists = errors.Wrap(ErrInvalidTransaction, "transaction already exists")
 	ErrAlreadyRefunded          = errors.Wrap(ErrInvalidTransaction, "The deposit was already refunded")
 	ErrInvalidFeePayment        = errors.Wrap(ErrInvalidTransaction, "Invalid fee payment")
+	ErrNotMaster                = errors.New("the request is not from the master")
 )
 
 type SolanaRequest struct {
+	// Version is the protocol version of the master, 0 for a master from before the version was sent
+	Version            int
 	Receiver           solana.Address
 	Amount             int64
 	TxID               string
@@ -50,10 +62,26 @@ type SignerService struct {
 	solWallet           *solana.Solana
 	stellarWallet       *stellar.Wallet
 	bridgeMasterAddress string
-	depositFee          int64 // deposit fee in TFT units TODO: maybe just pass part of the config
+	depositFee          fees.Schedule
+	withdrawFee         fees.Schedule
+	pause               *pause.Switch
+	// policy bounds the mints and withdrawals signed, signed keeps the volume counted against it
+	policy     policy.Policy
+	signed     *state.VolumeTracker
+	policyLock sync.Mutex
+	// audit records every request which is signed
+	audit *state.AuditLog
+	// leases is only set with failover, only requests of the signer holding the lease are signed
+	leases *lease.Service
+	// status returns the state of this bridge answered to the status poll
+	status func() multisig.NodeStatus
 }
 
-func NewSignerServer(host host.Host, bridgeMasterAddress string, solanaWallet *solana.Solana, stellarWallet *stellar.Wallet, depositFee int64) error {
+// NewSignerServer registers the signer service on the host.
+// The volume signed under the signing policy and the audit log are kept next to the persistency file.
+// If leases is not nil, it is served as well and only the requests of the signer holding the lease are signed.
+// status is answered to the status poll of the master.
+func NewSignerServer(host host.Host, bridgeMasterAddress string, solanaWallet *solana.Solana, stellarWallet *stellar.Wallet, depositFee, withdrawFee fees.Schedule, pauseSwitch *pause.Switch, signingPolicy policy.Policy, persistencyFile string, leases *lease.Service, status func() multisig.NodeStatus) error {
 	log.Info().Str("identity", host.ID().String()).Msg("server started")
 	partialMA, err := multiaddr.NewMultiaddr(fmt.Sprintf("/p2p/%s", host.ID()))
 	if err != nil {
@@ -65,6 +93,15 @@ func NewSignerServer(host host.Host, bridgeMasterAddress string, solanaWallet *s
 		log.Info().Str("address", full.String()).Msg("p2p node address")
 	}
 
+	signed, err := state.NewVolumeTracker(state.SignedVolumeFile(persistencyFile))
+	if err != nil {
+		return errors.Wrap(err, "failed to load the signed volume")
+	}
+	audit, err := state.NewAuditLog(state.AuditFile(persistencyFile))
+	if err != nil {
+		return err
+	}
+
 	server := gorpc.NewServer(host, Protocol)
 
 	signerService := SignerService{
@@ -72,13 +109,56 @@ func NewSignerServer(host host.Host, bridgeMasterAddress string, solanaWallet *s
 		stellarWallet:       stellarWallet,
 		bridgeMasterAddress: bridgeMasterAddress,
 		depositFee:          depositFee,
+		withdrawFee:         withdrawFee,
+		pause:               pauseSwitch,
+		policy:              signingPolicy,
+		signed:              signed,
+		audit:               audit,
+		leases:              leases,
+		status:              status,
+	}
+
+	if leases != nil {
+		if err = server.RegisterName(lease.ServiceName, leases); err != nil {
+			return err
+		}
 	}
-
 	return server.Register(&signerService)
 }
 
+// Hello tells the master which protocol version and request kinds this cosigner supports
+func (s *SignerService) Hello(ctx context.Context, request multisig.HelloRequest, response *multisig.HelloResponse) error {
+	log.Info().Str("peer", requestSender(ctx)).Int("version", request.Version).Msg("master connected")
+	if err := multisig.CheckVersion(request.Version); err != nil {
+		log.Warn().Err(err).Str("peer", requestSender(ctx)).Msg("Refusing master with an unsupported protocol version")
+		return err
+	}
+	response.Version = multisig.ProtocolVersion
+	response.Kinds = multisig.Kinds
+	return nil
+}
+
+// Status returns the state of this cosigner so the master can spot differences before a signing round fails
+// This is calable on the libp2p network with RPC
+func (s *SignerService) Status(ctx context.Context, request multisig.StatusRequest, response *multisig.NodeStatus) error {
+	*response = s.status()
+	return nil
+}
+
 func (s *SignerService) SignMint(ctx context.Context, request SolanaRequest, response *SolanaResponse) error {
 	log.Info().Str("request txid", request.TxID).Msg("sign mint request")
+	if err := multisig.CheckVersion(request.Version); err != nil {
+		log.Warn().Err(err).Str("request txid", request.TxID).Msg("Refusing unsupported mint request")
+		return err
+	}
+	if s.pause.Paused() {
+		log.Warn().Str("request txid", request.TxID).Msg("Refusing to sign while paused")
+		return pause.ErrPaused
+	}
+	if err := s.checkMaster(ctx); err != nil {
+		log.Warn().Err(err).Str("request txid", request.TxID).Msg("Refusing to sign for a signer without the master lease")
+		return err
+	}
 
 	solTx := new(solana.Transaction)
 	err := solTx.UnmarshalBase64(request.Tx)
@@ -130,9 +210,10 @@ func (s *SignerService) SignMint(ctx context.Context, request SolanaRequest, res
 	if err != nil {
 		return err
 	}
-	depositFeeBigInt := stellar.IntToStroops(s.depositFee)
+	deposited := depositedAmount
 	// Subtract fee from deposit amount
-	depositedAmount -= depositFeeBigInt
+	depositFee := s.depositFee.Fee(depositedAmount)
+	depositedAmount -= depositFee
 
 	log.Debug().Int64("embedded amount", amount).Int64("amount", depositedAmount).Int64("request amount", request.Amount).Msg("validating amount for sign tx")
 
@@ -148,13 +229,13 @@ func (s *SignerService) SignMint(ctx context.Context, request SolanaRequest, res
 	}
 
 	// Extract master address from stellar tx
-	addr, err := solana.AddressFromB64(tx.Memo)
+	owner, err := solana.AddressFromB64(tx.Memo)
 	if err != nil {
 		return err
 	}
 
 	// convert to ATA address, which is the one passed in the solana mint tx and the signing request
-	addr, err = s.solWallet.ATAFromMasterAddress(addr)
+	addr, err := s.solWallet.ATAFromMasterAddress(owner)
 	if err != nil {
 		return err
 	}
@@ -163,11 +244,31 @@ func (s *SignerService) SignMint(ctx context.Context, request SolanaRequest, res
 		return fmt.Errorf("deposit addresses do not match")
 	}
 
+	// the policy applies to the amount before fees and to the owner of the token account
+	if err = s.checkPolicy(state.VolumeMint, request.TxID, deposited, owner.String()); err != nil {
+		log.Warn().Err(err).Str("request txid", request.TxID).Msg("Mint refused by the signing policy")
+		return err
+	}
+
 	signature, idx, err := s.solWallet.CreateTokenSignature(*solTx)
 	if err != nil {
 		return err
 	}
 
+	err = s.audit.Record(state.AuditEntry{
+		Request:     metrics.TransferMint,
+		ID:          request.TxID,
+		Destination: request.Receiver.String(),
+		Amount:      request.Amount,
+		Fee:         depositFee,
+		Peer:        requestSender(ctx),
+		Signature:   signature.String(),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("request txid", request.TxID).Msg("Failed to write the audit log, not handing out the signature")
+		return errors.New("Error") // Internal errors should not be exposed externally
+	}
+
 	response.Signature = signature
 	response.SigIdx = idx
 	response.Who = s.solWallet.Address()
@@ -175,10 +276,32 @@ func (s *SignerService) SignMint(ctx context.Context, request SolanaRequest, res
 	return nil
 }
 
-// Sign signs a stellar sign request
+// Sign signs a stellar sign request of protocol version 1, what is signed is derived from the fields which are set.
 // This is calable on the libp2p network with RPC
 func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRequest, response *multisig.StellarSignResponse) error {
-	log.Info().Msg("got signing request")
+	return s.signTransaction(ctx, request.Typed(), response)
+}
+
+// SignTransaction signs a typed stellar sign request
+// This is calable on the libp2p network with RPC
+func (s *SignerService) SignTransaction(ctx context.Context, request multisig.SignRequest, response *multisig.StellarSignResponse) error {
+	if err := request.Validate(); err != nil {
+		log.Warn().Err(err).Str("kind", string(request.Kind)).Int("version", request.Version).Msg("Refusing unsupported sign request")
+		return err
+	}
+	return s.signTransaction(ctx, request, response)
+}
+
+func (s *SignerService) signTransaction(ctx context.Context, request multisig.SignRequest, response *multisig.StellarSignResponse) error {
+	log.Info().Str("kind", string(request.Kind)).Msg("got signing request")
+	if s.pause.Paused() {
+		log.Warn().Str("kind", string(request.Kind)).Msg("Refusing to sign while paused")
+		return pause.ErrPaused
+	}
+	if err := s.checkMaster(ctx); err != nil {
+		log.Warn().Err(err).Str("kind", string(request.Kind)).Msg("Refusing to sign for a signer without the master lease")
+		return err
+	}
 
 	loaded, err := txnbuild.TransactionFromXDR(request.TxnXDR)
 	if err != nil {
@@ -190,22 +313,24 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 		return fmt.Errorf("provided transaction is of wrong type")
 	}
 
-	var emptyAddr solana.Address
-	if request.Receiver != emptyAddr {
+	var transfer string
+	switch request.Kind {
+	case multisig.KindWithdrawal:
+		transfer = metrics.TransferWithdrawal
 		log.Info().Msg("Validating withdrawal signing request")
-		err = s.validateWithdrawal(ctx, request, txn)
+		err = s.validateWithdrawal(ctx, request.Withdrawal, txn)
 		if err != nil {
-			if errors.Is(err, ErrInvalidTransaction) {
+			if errors.Is(err, ErrInvalidTransaction) || policy.Violated(err) {
 				log.Warn().Err(err).Msg("Withdrawal validation error")
 				return err
 			}
 			log.Error().Err(err).Msg("An error occurred while validating a withdrawal signing request")
 			return errors.New("Error") // Internal errors should not be exposed externally
 		}
-	} else if request.Message != "" {
-		// If the signrequest has a message attached we know it's a refund transaction
-		log.Info().Str("deposit", request.Message).Msg("Validating refund signing request")
-		err = s.validateRefundTransaction(ctx, request, txn)
+	case multisig.KindRefund:
+		transfer = metrics.TransferRefund
+		log.Info().Str("deposit", request.Refund.Deposit).Msg("Validating refund signing request")
+		err = s.validateRefundTransaction(ctx, request.Refund.Deposit, txn)
 		if err != nil {
 			if errors.Is(err, ErrInvalidTransaction) {
 				log.Warn().Err(err).Msg("Refund validation error")
@@ -214,11 +339,10 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 			log.Error().Err(err).Msg("An error occurred while validating a refund signing request")
 			return errors.New("Error") // Internal errors should not be exposed externally
 		}
-	} else {
-		// If the signrequest is not a withdrawal request and a refund request
-		// then it's most likely a transfer to fee wallet transaction from a deposit
-		log.Info().Msg("Validating fee transfer signing request")
-		err = s.validateDepositFeeTransfer(ctx, request, txn)
+	case multisig.KindFeeTransfer:
+		transfer = metrics.TransferFee
+		log.Info().Str("deposit", request.FeeTransfer.Deposit).Msg("Validating fee transfer signing request")
+		err = s.validateDepositFeeTransfer(ctx, request.FeeTransfer.Deposit, txn)
 		if err != nil {
 			if errors.Is(err, ErrInvalidTransaction) {
 				log.Info().Err(err).Msg("Fee transfer validation error")
@@ -227,6 +351,8 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 			log.Error().Err(err).Msg("An error occurred while validating a deposit fee transfer signing request")
 			return errors.New("Error") // Internal errors should not be exposed externally
 		}
+	default:
+		return errors.Wrapf(multisig.ErrUnsupportedRequest, "unknown kind %q", request.Kind)
 	}
 
 	log.Info().Msg("Signing valid signing request")
@@ -241,13 +367,77 @@ func (s *SignerService) Sign(ctx context.Context, request multisig.StellarSignRe
 		return fmt.Errorf("invalid number of signatures on the transaction")
 	}
 
+	signature := base64.StdEncoding.EncodeToString(signatures[0].Signature)
+	if err = s.auditTransaction(ctx, transfer, txn, signature); err != nil {
+		log.Error().Err(err).Msg("Failed to write the audit log, not handing out the signature")
+		return errors.New("Error") // Internal errors should not be exposed externally
+	}
+
 	response.Address = s.stellarWallet.GetAddress()
-	response.Signature = base64.StdEncoding.EncodeToString(signatures[0].Signature)
+	response.Signature = signature
 	return nil
 }
 
+// auditTransaction records a signed Stellar transaction in the audit log
+func (s *SignerService) auditTransaction(ctx context.Context, transfer string, txn *txnbuild.Transaction, signature string) error {
+	entry := state.AuditEntry{
+		Request:   transfer,
+		Peer:      requestSender(ctx),
+		Signature: signature,
+	}
+	var err error
+	if entry.ID, err = stellar.ExtractMemoFromTx(txn); err != nil {
+		return err
+	}
+	if entry.Transaction, err = txn.Base64(); err != nil {
+		return err
+	}
+	for _, op := range txn.Operations() {
+		payment, ok := op.(*txnbuild.Payment)
+		if !ok {
+			continue
+		}
+		paid, err := amount.ParseInt64(payment.Amount)
+		if err != nil {
+			return err
+		}
+		if payment.Destination == s.stellarWallet.Config.StellarFeeWallet && transfer != metrics.TransferFee {
+			entry.Fee += paid
+			continue
+		}
+		entry.Destination = payment.Destination
+		entry.Amount += paid
+	}
+	return s.audit.Record(entry)
+}
+
+// requestSender returns the peer which sent the rpc request, empty if it is not known
+// checkMaster checks with failover that the request is sent by the signer this cosigner granted the master lease to.
+// A cosigner which did not grant the lease, or whose grant expired, signs nothing.
+func (s *SignerService) checkMaster(ctx context.Context) error {
+	if s.leases == nil {
+		return nil
+	}
+	holder := s.leases.Holder()
+	if holder == "" {
+		return errors.Wrap(ErrNotMaster, "the lease is not granted")
+	}
+	if requestSender(ctx) != holder.String() {
+		return errors.Wrapf(ErrNotMaster, "the lease is held by %s", holder)
+	}
+	return nil
+}
+
+func requestSender(ctx context.Context) string {
+	sender, err := gorpc.GetRequestSender(ctx)
+	if err != nil {
+		return ""
+	}
+	return sender.String()
+}
+
 // validates a withdrawal (burn on solana)
-func (s *SignerService) validateWithdrawal(ctx context.Context, request multisig.StellarSignRequest, txn *txnbuild.Transaction) error {
+func (s *SignerService) validateWithdrawal(ctx context.Context, request *multisig.WithdrawalRequest, txn *txnbuild.Transaction) error {
 	shortTxIDHash, err := stellar.ExtractTxHashMemoFromTx(txn)
 	if err != nil {
 		log.Warn().Err(err).Msg("Unable to extract the memo from the supplied transaction")
@@ -259,6 +449,10 @@ func (s *SignerService) validateWithdrawal(ctx context.Context, request multisig
 		return err
 	}
 
+	if withdraw.Caller() != request.Sender {
+		return errors.Wrapf(ErrInvalidTransaction, "the burn was not made by %s", request.Sender)
+	}
+
 	amount := int64(withdraw.RawAmount())
 	receiver := withdraw.Memo()
 	log.Info().Str("amount", stellar.StroopsToDecimal(amount).String()).Str("receiver", receiver).Str("tx", withdraw.TxID().String()).Msg("validating withdrawal")
@@ -270,9 +464,16 @@ func (s *SignerService) validateWithdrawal(ctx context.Context, request multisig
 		return errors.Wrap(ErrInvalidTransaction, "Withdrawal already executed")
 	}
 
-	amount -= WithdrawFee
-	if len(txn.Operations()) != 2 {
-		return errors.Wrap(ErrInvalidTransaction, "a withdraw tx needs to contain 2 payment operations")
+	fee := s.withdrawFee.Fee(amount)
+	amount -= fee
+	// the master only adds a fee payment if there is a fee and a fee wallet to pay it to
+	feePaymentExpected := fee > 0 && s.stellarWallet.Config.StellarFeeWallet != ""
+	expectedOperations := 1
+	if feePaymentExpected {
+		expectedOperations = 2
+	}
+	if len(txn.Operations()) != expectedOperations {
+		return errors.Wrapf(ErrInvalidTransaction, "a withdraw tx needs to contain %d payment operations", expectedOperations)
 	}
 	feePaymentPresent := false
 	for _, op := range txn.Operations() {
@@ -292,8 +493,8 @@ func (s *SignerService) validateWithdrawal(ctx context.Context, request multisig
 
 		acc := paymentOperation.Destination.ToAccountId()
 
-		if acc.Address() == s.stellarWallet.Config.StellarFeeWallet {
-			if int64(paymentOperation.Amount) != WithdrawFee {
+		if feePaymentExpected && acc.Address() == s.stellarWallet.Config.StellarFeeWallet {
+			if int64(paymentOperation.Amount) != fee {
 				return errors.Wrap(ErrInvalidTransaction, "the withdraw fee is incorrect")
 			}
 			feePaymentPresent = true
@@ -308,22 +509,38 @@ func (s *SignerService) validateWithdrawal(ctx context.Context, request multisig
 			return fmt.Errorf("amount is not correct, received %d, need %d", paymentOperation.Amount, xdr.Int64(amount))
 		}
 	}
-	if !feePaymentPresent {
+	if feePaymentExpected && !feePaymentPresent {
 		return errors.Wrap(ErrInvalidTransaction, "No withdraw fee payment")
 	}
 
-	return nil
+	// the policy is checked last so only valid withdrawals are counted in the signed volume
+	return s.checkPolicy(state.VolumeWithdraw, shortTxID.String(), int64(withdraw.RawAmount()), receiver)
+}
+
+// checkPolicy checks a mint or withdrawal against the signing policy and counts it in the signed volume.
+// A transfer which is signed again, for example when the master retries, is not checked or counted twice.
+func (s *SignerService) checkPolicy(direction string, id string, amount int64, destination string) error {
+	s.policyLock.Lock()
+	defer s.policyLock.Unlock()
+
+	if s.signed.Known(direction, id) {
+		return nil
+	}
+	if err := s.policy.Check(amount, destination, s.signed.Volume(direction, 24*time.Hour), time.Now()); err != nil {
+		return err
+	}
+	return s.signed.Record(direction, id, amount)
 }
 
-func (s *SignerService) validateRefundTransaction(ctx context.Context, request multisig.StellarSignRequest, txn *txnbuild.Transaction) error {
+func (s *SignerService) validateRefundTransaction(ctx context.Context, deposit string, txn *txnbuild.Transaction) error {
 	// check if a refund already happened
 	memo, err := stellar.ExtractMemoFromTx(txn)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to extract memo")
 		return ErrInvalidTransaction
 	}
-	if memo != request.Message {
-		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the signrequest message do not match")
+	if memo != deposit {
+		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the refunded deposit do not match")
 	}
 	alreadyRefunded, err := s.stellarWallet.TransactionStorage.TransactionWithMemoExists(ctx, memo)
 	if err != nil {
@@ -336,6 +553,7 @@ func (s *SignerService) validateRefundTransaction(ctx context.Context, request m
 	var destinationAccount string
 	var refundAmountWithoutPenalty int64
 	var penaltyPayment bool
+	var penalty int64
 	// There are 2 payment operations, 1 to the feewallet and 1 to the account that made the deposit
 	if len(txn.Operations()) > 2 {
 		return errors.Wrap(ErrInvalidTransaction, "The refund transaction has too many operations")
@@ -362,9 +580,7 @@ func (s *SignerService) validateRefundTransaction(ctx context.Context, request m
 				return errors.Wrap(ErrInvalidTransaction, "Multiple payments to the feewallet")
 			}
 			penaltyPayment = true
-			if paymentOperation.Amount != xdr.Int64(WithdrawFee) {
-				return errors.Wrapf(ErrInvalidFeePayment, "fee amount should be %d, but got %d", WithdrawFee, paymentOperation.Amount)
-			}
+			penalty = int64(paymentOperation.Amount)
 			continue
 		}
 		destinationAccount = operationDestinationAccount
@@ -400,20 +616,29 @@ func (s *SignerService) validateRefundTransaction(ctx context.Context, request m
 		}
 	}
 
-	if stellar.DecimalToStroops(depositedAmount) != (refundAmountWithoutPenalty + WithdrawFee) {
+	fee := s.withdrawFee.Fee(stellar.DecimalToStroops(depositedAmount))
+	if penalty != fee {
+		return errors.Wrapf(ErrInvalidFeePayment, "fee amount should be %d, but got %d", fee, penalty)
+	}
+	if stellar.DecimalToStroops(depositedAmount) != (refundAmountWithoutPenalty + fee) {
 		return errors.Wrapf(ErrInvalidTransaction, "The refunded amount %s does not match the deposit %s minus the penalty", stellar.StroopsToDecimal(refundAmountWithoutPenalty), depositedAmount)
 	}
 
 	return nil
 }
 
-func (s *SignerService) validateDepositFeeTransfer(ctx context.Context, request multisig.StellarSignRequest, txn *txnbuild.Transaction) (err error) {
+// validateDepositFeeTransfer validates the transfer of the fee of a deposit,
+// deposit is empty for a request of protocol version 1 where it is only in the memo.
+func (s *SignerService) validateDepositFeeTransfer(ctx context.Context, deposit string, txn *txnbuild.Transaction) (err error) {
 	// Check if a fee transfer for this already happened
 	memo, err := stellar.ExtractMemoFromTx(txn)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to extract memo")
 		return ErrInvalidTransaction
 	}
+	if deposit != "" && memo != deposit {
+		return errors.Wrap(ErrInvalidTransaction, "The transaction memo and the deposit do not match")
+	}
 	alreadyExists, err := s.stellarWallet.TransactionStorage.TransactionWithMemoExists(ctx, memo)
 	if err != nil {
 		return
@@ -448,16 +673,17 @@ func (s *SignerService) validateDepositFeeTransfer(ctx context.Context, request
 		return errors.Wrapf(ErrInvalidTransaction, "destination is not correct, got %s, need fee wallet %s", acc.Address(), s.stellarWallet.Config.StellarFeeWallet)
 	}
 
-	if int64(paymentOperation.Amount) != stellar.IntToStroops(s.depositFee) {
-		return errors.Wrapf(ErrInvalidTransaction, "amount is not correct, received %d, need %d", stellar.StroopsToDecimal(int64(paymentOperation.Amount)), s.depositFee)
-	}
 	// Validate the deposit transaction that triggered this deposit fee transfer
 	depositedAmount, _, err := s.stellarWallet.GetDepositAmountAndSender(memo, s.bridgeMasterAddress)
 	if err != nil {
 		return
 	}
-	if depositedAmount <= s.depositFee {
+	fee := s.depositFee.Fee(depositedAmount)
+	if depositedAmount <= fee {
 		return errors.Wrap(ErrInvalidFeePayment, "The amount of the deposit is smaller than the deposit fee")
 	}
+	if int64(paymentOperation.Amount) != fee {
+		return errors.Wrapf(ErrInvalidTransaction, "amount is not correct, received %s, need %s", stellar.StroopsToDecimal(int64(paymentOperation.Amount)), stellar.StroopsToDecimal(fee))
+	}
 	return
 }
@@ -0,0 +1,316 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gorpc "github.com/libp2p/go-libp2p-gorpc"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/lease"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/policy"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar/horizontest"
+)
+
+// localSigners hands the sign requests of a wallet to signer services in the same process
+type localSigners []*SignerService
+
+func (s localSigners) Sign(ctx context.Context, request multisig.SignRequest) ([]multisig.StellarSignResponse, error) {
+	responses := make([]multisig.StellarSignResponse, len(s))
+	for i, signer := range s {
+		if err := signer.SignTransaction(ctx, request, &responses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// Transaction returns nothing, the signer services sign every request in one round
+func (s localSigners) Transaction(kind multisig.RequestKind, memo string) string {
+	return ""
+}
+
+func newStellarKey(t *testing.T) (string, ed25519.PrivateKey) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	address, err := strkey.Encode(strkey.VersionByteAccountID, pub)
+	require.NoError(t, err)
+	return address, key
+}
+
+// newTestVault creates a vault with 1000 TFT on a fake horizon server which needs the signatures of the master and a cosigner.
+// The master wallet gets its signatures from the returned cosigner service.
+func newTestVault(t *testing.T, depositFee, withdrawFee fees.Schedule) (*stellar.Wallet, *SignerService, *horizontest.Server) {
+	server := horizontest.NewServer(keypair.MustRandom().Address())
+	t.Cleanup(server.Close)
+	stellar.SetNetwork("horizontest", stellar.Network{HorizonURL: server.URL, Passphrase: horizontest.Passphrase, Asset: server.Asset()})
+
+	vault, masterKey := newStellarKey(t)
+	cosignerAddress, cosignerKey := newStellarKey(t)
+	config := &stellar.StellarConfig{StellarNetwork: "horizontest", StellarFeeWallet: keypair.MustRandom().Address()}
+	server.CreateAccount(vault, 1000_0000000)
+	server.CreateAccount(config.StellarFeeWallet, 0)
+	server.SetSigners(vault, map[string]int32{vault: 1, cosignerAddress: 1}, 2)
+
+	cosignerWallet, err := stellar.NewWallet(config, keys.NewLocalEd25519Signer(cosignerKey), depositFee, withdrawFee, stellar.NewTransactionStorage(config.StellarNetwork, vault))
+	require.NoError(t, err)
+	cosignerWallet.SetHorizonClient(server.Client())
+	persistency := filepath.Join(t.TempDir(), "node.json")
+	signed, err := state.NewVolumeTracker(state.SignedVolumeFile(persistency))
+	require.NoError(t, err)
+	audit, err := state.NewAuditLog(state.AuditFile(persistency))
+	require.NoError(t, err)
+	cosigner := &SignerService{
+		stellarWallet:       cosignerWallet,
+		bridgeMasterAddress: vault,
+		depositFee:          depositFee,
+		withdrawFee:         withdrawFee,
+		pause:               pause.NewSwitch(""),
+		signed:              signed,
+		audit:               audit,
+	}
+
+	master, err := stellar.NewWallet(config, keys.NewLocalEd25519Signer(masterKey), depositFee, withdrawFee, stellar.NewTransactionStorage(config.StellarNetwork, vault))
+	require.NoError(t, err)
+	master.SetHorizonClient(server.Client())
+	master.SetSignerClient(localSigners{cosigner})
+	master.SetRequiredSignatures(2)
+	return master, cosigner, server
+}
+
+type payment struct {
+	destination string
+	stroops     int64
+}
+
+// paymentXDR builds an unsigned transaction paying TFT from the vault
+func paymentXDR(t *testing.T, vault string, memo txnbuild.Memo, payments ...payment) string {
+	code, issuer, err := stellar.GetTFTAsset("horizontest")
+	require.NoError(t, err)
+	var operations []txnbuild.Operation
+	for _, p := range payments {
+		operations = append(operations, &txnbuild.Payment{
+			Destination:   p.destination,
+			Amount:        amount.StringFromInt64(p.stroops),
+			Asset:         txnbuild.CreditAsset{Code: code, Issuer: issuer},
+			SourceAccount: vault,
+		})
+	}
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: vault, Sequence: 1},
+		IncrementSequenceNum: true,
+		Operations:           operations,
+		Memo:                 memo,
+		BaseFee:              stellar.Precision,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	})
+	require.NoError(t, err)
+	xdr, err := tx.Base64()
+	require.NoError(t, err)
+	return xdr
+}
+
+func TestSignerServiceRefund(t *testing.T) {
+	wallet, cosigner, server := newTestVault(t, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	vault, feeWallet := wallet.GetAddress(), wallet.Config.StellarFeeWallet
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+
+	refunded, err := server.Pay(user, vault, 5_0000000, txnbuild.MemoText("no address"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.CreateAndSubmitRefund(context.Background(), user, 4_0000000, refunded, 1_0000000))
+	assert.Equal(t, int64(99_0000000), server.Balance(user))
+	assert.Equal(t, int64(1_0000000), server.Balance(feeWallet))
+
+	open, err := server.Pay(user, vault, 5_0000000, txnbuild.MemoText("no address"))
+	require.NoError(t, err)
+	memoOf := func(deposit string) txnbuild.Memo {
+		hash, err := hex.DecodeString(deposit)
+		require.NoError(t, err)
+		return txnbuild.MemoReturn([32]byte(hash))
+	}
+	for name, test := range map[string]struct {
+		deposit  string
+		memo     txnbuild.Memo
+		payments []payment
+		err      error
+	}{
+		"already refunded": {refunded, memoOf(refunded), []payment{{user, 4_0000000}, {feeWallet, 1_0000000}}, ErrAlreadyRefunded},
+		"other memo":       {open, memoOf(refunded), []payment{{user, 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"no fee":           {open, memoOf(open), []payment{{user, 5_0000000}}, ErrInvalidFeePayment},
+		"too much":         {open, memoOf(open), []payment{{user, 5_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"other account":    {open, memoOf(open), []payment{{keypair.MustRandom().Address(), 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+		"two fees":         {open, memoOf(open), []payment{{feeWallet, 4_0000000}, {feeWallet, 1_0000000}}, ErrInvalidTransaction},
+	} {
+		request := multisig.SignRequest{
+			Version: multisig.ProtocolVersion,
+			Kind:    multisig.KindRefund,
+			TxnXDR:  paymentXDR(t, vault, test.memo, test.payments...),
+			Refund:  &multisig.RefundRequest{Deposit: test.deposit},
+		}
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), test.err, name)
+	}
+}
+
+func TestSignerServiceDepositFeeTransfer(t *testing.T) {
+	wallet, cosigner, server := newTestVault(t, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+	vault, feeWallet := wallet.GetAddress(), wallet.Config.StellarFeeWallet
+	user := keypair.MustRandom().Address()
+	server.CreateAccount(user, 100_0000000)
+	receiver := txnbuild.MemoHash(solana.Address{2})
+
+	hashOf := func(deposit string) [32]byte {
+		hash, err := hex.DecodeString(deposit)
+		require.NoError(t, err)
+		return [32]byte(hash)
+	}
+	transferred, err := server.Pay(user, vault, 10_0000000, receiver)
+	require.NoError(t, err)
+	require.NoError(t, wallet.CreateAndSubmitFeepayment(context.Background(), 1_0000000, hashOf(transferred)))
+	assert.Equal(t, int64(1_0000000), server.Balance(feeWallet))
+
+	open, err := server.Pay(user, vault, 10_0000000, receiver)
+	require.NoError(t, err)
+	small, err := server.Pay(user, vault, 1_0000000, receiver)
+	require.NoError(t, err)
+	for name, test := range map[string]struct {
+		deposit  string
+		payments []payment
+		err      error
+	}{
+		"already transferred": {transferred, []payment{{feeWallet, 1_0000000}}, ErrTransactionAlreadyExists},
+		"wrong amount":        {open, []payment{{feeWallet, 2_0000000}}, ErrInvalidTransaction},
+		"other account":       {open, []payment{{user, 1_0000000}}, ErrInvalidTransaction},
+		"two payments":        {open, []payment{{feeWallet, 1_0000000}, {user, 1_0000000}}, ErrInvalidTransaction},
+		"deposit below fee":   {small, []payment{{feeWallet, 1_0000000}}, ErrInvalidFeePayment},
+	} {
+		request := multisig.SignRequest{
+			Version:     multisig.ProtocolVersion,
+			Kind:        multisig.KindFeeTransfer,
+			TxnXDR:      paymentXDR(t, vault, txnbuild.MemoHash(hashOf(test.deposit)), test.payments...),
+			FeeTransfer: &multisig.FeeTransferRequest{Deposit: test.deposit},
+		}
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), test.err, name)
+	}
+
+	request := multisig.SignRequest{
+		Version:     multisig.ProtocolVersion,
+		Kind:        multisig.KindFeeTransfer,
+		TxnXDR:      paymentXDR(t, vault, txnbuild.MemoHash(hashOf(open)), payment{feeWallet, 1_0000000}),
+		FeeTransfer: &multisig.FeeTransferRequest{Deposit: small},
+	}
+	var response multisig.StellarSignResponse
+	assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), ErrInvalidTransaction, "other deposit")
+
+	request.FeeTransfer.Deposit = open
+	cosigner.pause.Pause("test")
+	assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), pause.ErrPaused)
+	require.NoError(t, cosigner.pause.Resume())
+	require.NoError(t, cosigner.SignTransaction(context.Background(), request, &response))
+	assert.Equal(t, cosigner.stellarWallet.GetAddress(), response.Address)
+
+	// the refused requests are not in the audit log
+	entries, err := cosigner.audit.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "fee", entries[1].Request)
+	assert.Equal(t, open, entries[1].ID)
+	assert.Equal(t, feeWallet, entries[1].Destination)
+	assert.Equal(t, int64(1_0000000), entries[1].Amount)
+	assert.Equal(t, response.Signature, entries[1].Signature)
+	assert.Equal(t, entries[0].Hash, entries[1].Previous)
+
+	// a master of protocol version 1 does not send the deposit
+	require.NoError(t, cosigner.Sign(context.Background(), multisig.StellarSignRequest{TxnXDR: request.TxnXDR}, &response))
+}
+
+func TestSignerServiceProtocol(t *testing.T) {
+	_, cosigner, _ := newTestVault(t, fees.NewFlat(1_0000000), fees.NewFlat(1_0000000))
+
+	var hello multisig.HelloResponse
+	require.NoError(t, cosigner.Hello(context.Background(), multisig.HelloRequest{Version: multisig.ProtocolVersion}, &hello))
+	assert.Equal(t, multisig.ProtocolVersion, hello.Version)
+	assert.Equal(t, multisig.Kinds, hello.Kinds)
+	assert.ErrorIs(t, cosigner.Hello(context.Background(), multisig.HelloRequest{Version: multisig.ProtocolVersion + 1}, &hello), multisig.ErrUnsupportedRequest)
+
+	deposit := &multisig.FeeTransferRequest{Deposit: "00"}
+	for name, request := range map[string]multisig.SignRequest{
+		"unknown kind":     {Version: multisig.ProtocolVersion, Kind: "burn", FeeTransfer: deposit},
+		"mint":             {Version: multisig.ProtocolVersion, Kind: multisig.KindMint, FeeTransfer: deposit},
+		"no payload":       {Version: multisig.ProtocolVersion, Kind: multisig.KindFeeTransfer},
+		"other payload":    {Version: multisig.ProtocolVersion, Kind: multisig.KindRefund, FeeTransfer: deposit},
+		"two payloads":     {Version: multisig.ProtocolVersion, Kind: multisig.KindRefund, Refund: &multisig.RefundRequest{Deposit: "00"}, FeeTransfer: deposit},
+		"too new":          {Version: multisig.ProtocolVersion + 1, Kind: multisig.KindFeeTransfer, FeeTransfer: deposit},
+		"negative version": {Version: -1, Kind: multisig.KindFeeTransfer, FeeTransfer: deposit},
+	} {
+		var response multisig.StellarSignResponse
+		assert.ErrorIs(t, cosigner.SignTransaction(context.Background(), request, &response), multisig.ErrUnsupportedRequest, name)
+	}
+
+	var response SolanaResponse
+	assert.ErrorIs(t, cosigner.SignMint(context.Background(), SolanaRequest{Version: multisig.ProtocolVersion + 1}, &response), multisig.ErrUnsupportedRequest)
+}
+
+func TestSignerServicePolicy(t *testing.T) {
+	_, cosigner, _ := newTestVault(t, fees.NewFlat(0), fees.NewFlat(0))
+	destination, blocked := keypair.MustRandom().Address(), keypair.MustRandom().Address()
+	cosigner.policy = policy.Policy{MaxTransfer: 100_0000000, DailyVolume: 150_0000000, Blocked: map[string]bool{blocked: true}}
+
+	assert.ErrorIs(t, cosigner.checkPolicy(state.VolumeWithdraw, "a", 101_0000000, destination), policy.ErrAboveMaximum)
+	assert.ErrorIs(t, cosigner.checkPolicy(state.VolumeWithdraw, "b", 10_0000000, blocked), policy.ErrBlockedDestination)
+	require.NoError(t, cosigner.checkPolicy(state.VolumeWithdraw, "c", 100_0000000, destination))
+	require.NoError(t, cosigner.checkPolicy(state.VolumeWithdraw, "c", 100_0000000, destination), "a retried request is not counted twice")
+	assert.ErrorIs(t, cosigner.checkPolicy(state.VolumeWithdraw, "d", 60_0000000, destination), policy.ErrDailyVolumeExceeded)
+	require.NoError(t, cosigner.checkPolicy(state.VolumeWithdraw, "e", 50_0000000, destination))
+
+	// the volume is kept per direction
+	assert.Equal(t, int64(150_0000000), cosigner.signed.Volume(state.VolumeWithdraw, 24*time.Hour))
+	require.NoError(t, cosigner.checkPolicy(state.VolumeMint, "f", 100_0000000, destination))
+	assert.Equal(t, int64(100_0000000), cosigner.signed.Volume(state.VolumeMint, 24*time.Hour))
+}
+
+func TestSignerServiceMasterLease(t *testing.T) {
+	cfg := lease.Config{Duration: 100 * time.Millisecond}
+	leases := lease.NewService("cosigner", cfg)
+	signer := &SignerService{leases: leases}
+	from := func(sender peer.ID) context.Context {
+		return context.WithValue(context.Background(), gorpc.ContextKeyRequestSender, sender)
+	}
+	master, other := peer.ID("master"), peer.ID("other")
+
+	// nothing is signed while the lease is not granted
+	assert.ErrorIs(t, signer.checkMaster(from(master)), ErrNotMaster)
+
+	// a restarted signer grants the lease once an earlier grant expired
+	time.Sleep(cfg.Duration)
+	var grant lease.Grant
+	require.NoError(t, leases.Acquire(from(master), lease.Request{}, &grant))
+	require.True(t, grant.Granted)
+	assert.NoError(t, signer.checkMaster(from(master)))
+	assert.ErrorIs(t, signer.checkMaster(from(other)), ErrNotMaster)
+
+	// an expired lease is not granted to anyone, the previous master is refused as well
+	time.Sleep(cfg.Duration)
+	assert.ErrorIs(t, signer.checkMaster(from(master)), ErrNotMaster)
+	assert.ErrorIs(t, signer.checkMaster(from(other)), ErrNotMaster)
+}
@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/p2p"
+)
+
+// signersInterval is how often the signers of the bridge account and the mint multisig are checked for changes
+const signersInterval = time.Minute
+
+// signerSet are the signers of an account or multisig and the number of signatures it requires
+type signerSet struct {
+	signers  []string
+	required int64
+}
+
+func newSignerSet(signers []string, required int64) signerSet {
+	sorted := slices.Clone(signers)
+	slices.Sort(sorted)
+	return signerSet{signers: sorted, required: required}
+}
+
+// diff returns the signers which are added and removed compared to previous
+func (s signerSet) diff(previous signerSet) (added, removed []string) {
+	for _, signer := range s.signers {
+		if !slices.Contains(previous.signers, signer) {
+			added = append(added, signer)
+		}
+	}
+	for _, signer := range previous.signers {
+		if !slices.Contains(s.signers, signer) {
+			removed = append(removed, signer)
+		}
+	}
+	return
+}
+
+// changed checks if the signers or the required signatures differ from previous
+func (s signerSet) changed(previous signerSet) bool {
+	return s.required != previous.required || !slices.Equal(s.signers, previous.signers)
+}
+
+// watchSigners checks the signers of the bridge account and the mint multisig until the context is done.
+// The cosigners and the required Stellar signatures follow the signers of the bridge account,
+// the signers of the multisig are read for every mint so their changes are only logged.
+func (bridge *Bridge) watchSigners(ctx context.Context) {
+	ticker := time.NewTicker(signersInterval)
+	defer ticker.Stop()
+	for {
+		if err := bridge.refreshStellarSigners(); err != nil {
+			log.Warn().Err(err).Msg("failed to check the signers of the bridge account")
+		}
+		if err := bridge.refreshMintSigners(ctx); err != nil {
+			log.Warn().Err(err).Msg("failed to check the signers of the mint multisig")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshStellarSigners updates the cosigners, the lease peers and the required signatures if the signers of the bridge account changed
+func (bridge *Bridge) refreshStellarSigners() error {
+	cosigners, requiredSignatures, err := bridge.wallet.GetSigningRequirements()
+	if err != nil {
+		return err
+	}
+	current := newSignerSet(cosigners, int64(requiredSignatures))
+	if !current.changed(bridge.stellarSigners) {
+		return nil
+	}
+	cosignerPeerIDs, err := p2p.GetPeerIDsFromStellarAddresses(cosigners)
+	if err != nil {
+		return err
+	}
+
+	added, removed := current.diff(bridge.stellarSigners)
+	log.Warn().Strs("added", added).Strs("removed", removed).Int("signatures", requiredSignatures).Int64("previous", bridge.stellarSigners.required).Msg("The signers of the bridge account changed")
+	bridge.wallet.SetRequiredSignatures(requiredSignatures)
+	bridge.signersClient.SetPeers(cosignerPeerIDs)
+	if bridge.elector != nil {
+		bridge.elector.SetPeers(cosignerPeerIDs)
+	}
+	bridge.stellarSigners = current
+	return nil
+}
+
+// refreshMintSigners logs the changes of the signers of the multisig which is the mint authority of the token
+func (bridge *Bridge) refreshMintSigners(ctx context.Context) error {
+	addresses, err := bridge.solanaWallet.GetSigners(ctx)
+	if err != nil {
+		return err
+	}
+	requiredSignatures, err := bridge.solanaWallet.GetRequiresSignatureCount(ctx)
+	if err != nil {
+		return err
+	}
+	signers := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		signers = append(signers, address.String())
+	}
+	current := newSignerSet(signers, requiredSignatures)
+	if !current.changed(bridge.mintSigners) {
+		return nil
+	}
+
+	// the signers of the multisig are not known before the first check
+	if bridge.mintSigners.signers == nil {
+		log.Info().Strs("signers", current.signers).Int64("signatures", current.required).Msg("Signers of the mint multisig")
+	} else {
+		added, removed := current.diff(bridge.mintSigners)
+		log.Warn().Strs("added", added).Strs("removed", removed).Int64("signatures", current.required).Int64("previous", bridge.mintSigners.required).Msg("The signers of the mint multisig changed")
+	}
+	if !slices.Contains(addresses, bridge.solanaWallet.Address()) {
+		log.Error().Str("address", bridge.solanaWallet.Address().String()).Msg("This bridge is not a signer of the mint multisig, its mints will fail")
+	}
+	bridge.mintSigners = current
+	return nil
+}
@@ -0,0 +1,42 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/p2p"
+)
+
+func TestSignerSetDiff(t *testing.T) {
+	previous := newSignerSet([]string{"c", "a", "b"}, 2)
+	assert.False(t, newSignerSet([]string{"a", "b", "c"}, 2).changed(previous), "the order of the signers does not matter")
+
+	current := newSignerSet([]string{"b", "d", "c"}, 3)
+	require.True(t, current.changed(previous))
+	added, removed := current.diff(previous)
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"a"}, removed)
+	assert.True(t, newSignerSet([]string{"a", "b", "c"}, 3).changed(previous), "a new threshold is a change")
+}
+
+func TestRefreshStellarSigners(t *testing.T) {
+	wallet, _, server := newTestVault(t, fees.NewFlat(0), fees.NewFlat(0))
+	vault := wallet.BridgeAccount()
+	cosigner, _ := newStellarKey(t)
+	bridge := &Bridge{wallet: wallet, signersClient: &SignersClient{}}
+	bridge.stellarSigners = newSignerSet([]string{cosigner}, 2)
+	server.SetSigners(vault, map[string]int32{vault: 1, cosigner: 1}, 2)
+	require.NoError(t, bridge.refreshStellarSigners())
+	assert.Empty(t, bridge.signersClient.currentPeers(), "the peers are only replaced if the signers changed")
+
+	added, _ := newStellarKey(t)
+	server.SetSigners(vault, map[string]int32{vault: 1, cosigner: 1, added: 1}, 3)
+	require.NoError(t, bridge.refreshStellarSigners())
+	expected, err := p2p.GetPeerIDsFromStellarAddresses([]string{cosigner, added})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, bridge.signersClient.currentPeers())
+	assert.Equal(t, int64(3), bridge.stellarSigners.required)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
+)
+
+// auditCommand verifies the audit log of a cosigner and exports it as json or csv
+func auditCommand(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	persistencyFile := fs.String("persistency", "./node.json", "persistency file of the cosigner, the audit log is audit.jsonl next to it")
+	file := fs.String("file", "", "audit log, overrides the one next to the persistency file")
+	format := fs.String("format", "json", "export format, json or csv")
+	out := fs.String("out", "", "file to export to, stdout if empty")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge audit verify|export [--persistency <file>] [--file <audit log>] [--format json|csv] [--out <file>]")
+		fmt.Fprintln(os.Stderr, "verify checks the hash chain of the audit log of a cosigner, export verifies it and writes the entries.")
+		fmt.Fprintln(os.Stderr, "The exit code is 1 if the audit log is broken.")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || (args[0] != "verify" && args[0] != "export") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+	if *format != "json" && *format != "csv" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	location := *file
+	if location == "" {
+		location = state.AuditFile(*persistencyFile)
+	}
+
+	entries, err := state.ReadAuditLog(location)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read the audit log %s: %s\n", location, err)
+		os.Exit(1)
+	}
+	if err = state.VerifyAuditLog(entries); err != nil {
+		fmt.Fprintf(os.Stderr, "audit log %s is broken: %s\n", location, err)
+		os.Exit(1)
+	}
+	if command == "verify" {
+		fmt.Fprintf(os.Stderr, "audit log %s is valid, %d entries\n", location, len(entries))
+		return
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" {
+		err = writeAuditCSV(w, entries)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if entries == nil {
+			entries = []state.AuditEntry{}
+		}
+		err = encoder.Encode(entries)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeAuditCSV(w io.Writer, entries []state.AuditEntry) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"sequence", "at", "request", "id", "destination", "amount", "fee", "peer", "signature", "transaction", "previous", "hash"})
+	for _, e := range entries {
+		writer.Write([]string{
+			strconv.FormatUint(e.Sequence, 10),
+			e.At.Format(time.RFC3339Nano),
+			e.Request,
+			e.ID,
+			e.Destination,
+			strconv.FormatInt(e.Amount, 10),
+			strconv.FormatInt(e.Fee, 10),
+			e.Peer,
+			e.Signature,
+			e.Transaction,
+			e.Previous,
+			e.Hash,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,69 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tft = int64(1e7)
+
+func TestFlat(t *testing.T) {
+	s, err := Parse("50")
+	require.NoError(t, err)
+	assert.Equal(t, 50*tft, s.Fee(10*tft))
+	assert.Equal(t, 50*tft, s.Fee(1000000*tft))
+	assert.Equal(t, "50", s.String())
+
+	s, err = Parse("0.5")
+	require.NoError(t, err)
+	assert.Equal(t, tft/2, s.Fee(tft))
+}
+
+func TestPercentage(t *testing.T) {
+	s, err := Parse("0.5%,min=10,max=500")
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), s.BasisPoints)
+	assert.Equal(t, 10*tft, s.Fee(100*tft), "the minimum applies")
+	assert.Equal(t, 25*tft, s.Fee(5000*tft))
+	assert.Equal(t, 500*tft, s.Fee(1000000*tft), "the maximum applies")
+	assert.Equal(t, "0.5%,min=10,max=500", s.String())
+
+	s, err = Parse("1%")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), s.Fee(99), "fees are rounded down")
+	assert.Equal(t, int64(9e16), s.Fee(9e18), "large amounts do not overflow")
+
+	_, err = Parse("1%,min=10,max=5")
+	assert.Error(t, err)
+	_, err = Parse("101%")
+	assert.Error(t, err)
+}
+
+func TestTiers(t *testing.T) {
+	s, err := Parse("tiers:10000=20,1000=5,*=50")
+	require.NoError(t, err)
+	assert.Equal(t, 5*tft, s.Fee(1000*tft))
+	assert.Equal(t, 20*tft, s.Fee(1000*tft+1))
+	assert.Equal(t, 50*tft, s.Fee(20000*tft))
+	assert.Equal(t, "tiers:1000=5,10000=20,*=50", s.String())
+
+	s, err = Parse("tiers:1000=5")
+	require.NoError(t, err)
+	assert.Equal(t, 5*tft, s.Fee(20000*tft), "the last tier applies to larger amounts")
+
+	_, err = Parse("tiers:1000")
+	assert.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, spec := range []string{"1", "0.0000001", "2.5%", "0.01%,max=1", "tiers:*=3"} {
+		s, err := Parse(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, spec, s.String())
+		var parsed Schedule
+		require.NoError(t, parsed.Set(s.String()))
+		assert.Equal(t, s, parsed)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	solanago "github.com/gagliardetto/solana-go"
+	"github.com/rs/zerolog/log"
+	flag "github.com/spf13/pflag"
+	"github.com/stellar/go/strkey"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+)
+
+// keystoreCommand encrypts a Stellar secret or a solana keyfile into an ed25519 keystore file
+func keystoreCommand(args []string) {
+	fs := flag.NewFlagSet("keystore", flag.ExitOnError)
+	out := fs.String("out", "", "keystore file to create")
+	passwordFile := fs.String("password-file", "", "file containing the password to encrypt the key with")
+	solanaKeyFile := fs.String("solana-key", "", "solana keyfile to encrypt instead of a stellar secret")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge keystore --out <file> --password-file <file> [--solana-key <file>] < secret")
+		fmt.Fprintln(os.Stderr, "Stores a stellar secret read from stdin or a solana keyfile encrypted in an ed25519 keystore file")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *out == "" || *passwordFile == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var key ed25519.PrivateKey
+	var err error
+	if *solanaKeyFile != "" {
+		key, err = keys.SolanaKeygenFile(*solanaKeyFile)
+	} else {
+		var secret string
+		secret, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && secret == "" {
+			panic(err)
+		}
+		key, err = keys.StellarSeed(strings.TrimSpace(secret))
+	}
+	if err != nil {
+		panic(err)
+	}
+	password, err := os.ReadFile(*passwordFile)
+	if err != nil {
+		panic(err)
+	}
+	content, err := keys.EncryptEd25519Key(key, strings.TrimSpace(string(password)))
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(*out, content, 0600); err != nil {
+		panic(err)
+	}
+	if *solanaKeyFile != "" {
+		fmt.Println(solanago.PublicKeyFromBytes(keys.NewLocalEd25519Signer(key).PublicKey()))
+		return
+	}
+	address, err := strkey.Encode(strkey.VersionByteAccountID, keys.NewLocalEd25519Signer(key).PublicKey())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(address)
+}
+
+// remoteSignerCommand runs the reference remote signer
+func remoteSignerCommand(args []string) {
+	fs := flag.NewFlagSet("remotesigner", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8900", "address to listen on")
+	tokenFile := fs.String("token-file", "", "file containing the token clients need to authenticate with")
+	var keyOptions keys.Options
+	fs.StringVar(&keyOptions.PasswordFile, "keystore-password-file", "", "file containing the password of the keystore files")
+	ed25519Keys := fs.StringArray("key", nil, "key to serve as <name>=<key specification>, the raw key is a stellar secret or a solana keyfile")
+	fs.Parse(args)
+
+	token := ""
+	if *tokenFile != "" {
+		content, err := os.ReadFile(*tokenFile)
+		if err != nil {
+			panic(err)
+		}
+		token = strings.TrimSpace(string(content))
+	} else {
+		log.Warn().Msg("No token file provided, the remote signer accepts requests from everyone who can reach it")
+	}
+
+	server := keys.NewRemoteSignerServer(token)
+	for _, key := range *ed25519Keys {
+		name, spec, found := strings.Cut(key, "=")
+		if !found {
+			panic(fmt.Sprintf("invalid key %q, expected <name>=<key specification>", key))
+		}
+		signer, err := keys.NewEd25519Signer(spec, keyOptions, rawEd25519Key)
+		if err != nil {
+			panic(err)
+		}
+		server.AddEd25519(name, signer)
+		log.Info().Str("name", name).Str("url", fmt.Sprintf("http://%s/keys/%s", *listen, name)).Msg("Serving ed25519 key")
+	}
+
+	if err := http.ListenAndServe(*listen, server); err != nil {
+		panic(err)
+	}
+}
+
+// rawEd25519Key parses a stellar secret, anything else is considered to be a solana keyfile
+func rawEd25519Key(raw string) (ed25519.PrivateKey, error) {
+	if key, err := keys.StellarSeed(raw); err == nil {
+		return key, nil
+	}
+	return keys.SolanaKeygenFile(raw)
+}
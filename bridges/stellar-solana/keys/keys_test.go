@@ -0,0 +1,58 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSeed = "SBVM45L3DA4QA4GRGOZVOKEMRI6LGJXBGOFGHUTCWL3LW6H7KSHCYUTS"
+
+func TestEd25519Keystore(t *testing.T) {
+	key, err := StellarSeed(testSeed)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	content, err := EncryptEd25519Key(key, "secret")
+	require.NoError(t, err)
+	keystoreFile := filepath.Join(dir, "stellar.json")
+	require.NoError(t, os.WriteFile(keystoreFile, content, 0600))
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+
+	signer, err := NewEd25519Signer("keystore:"+keystoreFile, Options{PasswordFile: passwordFile}, StellarSeed)
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), signer.PublicKey())
+
+	_, err = DecryptEd25519Key(content, "wrong")
+	assert.Error(t, err)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, err := StellarSeed(testSeed)
+	require.NoError(t, err)
+
+	server := NewRemoteSignerServer("token")
+	server.AddEd25519("stellar", NewLocalEd25519Signer(key))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	_, err = NewRemoteEd25519Signer(ts.URL+"/keys/stellar", "wrong")
+	assert.Error(t, err, "a request with an invalid token should be refused")
+	_, err = NewRemoteEd25519Signer(ts.URL+"/keys/unknown", "token")
+	assert.Error(t, err)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token"), 0600))
+
+	signer, err := NewEd25519Signer("remote:"+ts.URL+"/keys/stellar", Options{RemoteTokenFile: tokenFile}, StellarSeed)
+	require.NoError(t, err)
+	signature, err := signer.Sign([]byte("message"))
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(key.Public().(ed25519.PublicKey), []byte("message"), signature))
+}
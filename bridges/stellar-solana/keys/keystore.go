@@ -0,0 +1,137 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ed25519KeystoreVersion = 1
+	ed25519KeystoreType    = "ed25519"
+
+	// scrypt parameters, the same as the standard geth keystore
+	scryptN      = 1 << 18
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// Ed25519Keystore is an ed25519 key encrypted with a password.
+// The seed of the key is encrypted with AES-256-GCM using a key derived from the password with scrypt.
+type Ed25519Keystore struct {
+	Version   int    `json:"version"`
+	Type      string `json:"type"`
+	PublicKey string `json:"publicKey"`
+	Crypto    struct {
+		KDF       string `json:"kdf"`
+		N         int    `json:"n"`
+		R         int    `json:"r"`
+		P         int    `json:"p"`
+		Salt      string `json:"salt"`
+		Cipher    string `json:"cipher"`
+		Nonce     string `json:"nonce"`
+		Encrypted string `json:"ciphertext"`
+	} `json:"crypto"`
+}
+
+// EncryptEd25519Key encrypts an ed25519 key with a password and returns the keystore file content
+func EncryptEd25519Key(key ed25519.PrivateKey, password string) ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := keystoreCipher(password, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	pub := key.Public().(ed25519.PublicKey)
+
+	var ks Ed25519Keystore
+	ks.Version = ed25519KeystoreVersion
+	ks.Type = ed25519KeystoreType
+	ks.PublicKey = hex.EncodeToString(pub)
+	ks.Crypto.KDF = "scrypt"
+	ks.Crypto.N, ks.Crypto.R, ks.Crypto.P = scryptN, scryptR, scryptP
+	ks.Crypto.Salt = hex.EncodeToString(salt)
+	ks.Crypto.Cipher = "aes-256-gcm"
+	ks.Crypto.Nonce = hex.EncodeToString(nonce)
+	// the public key is authenticated so it can not be swapped in the file
+	ks.Crypto.Encrypted = hex.EncodeToString(gcm.Seal(nil, nonce, key.Seed(), pub))
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptEd25519Key decrypts the content of an ed25519 keystore file
+func DecryptEd25519Key(content []byte, password string) (ed25519.PrivateKey, error) {
+	var ks Ed25519Keystore
+	if err := json.Unmarshal(content, &ks); err != nil {
+		return nil, err
+	}
+	if ks.Version != ed25519KeystoreVersion || ks.Type != ed25519KeystoreType {
+		return nil, fmt.Errorf("unsupported keystore version %d of type %q", ks.Version, ks.Type)
+	}
+	if ks.Crypto.KDF != "scrypt" || ks.Crypto.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported keystore encryption %s/%s", ks.Crypto.KDF, ks.Crypto.Cipher)
+	}
+	pub, err := hex.DecodeString(ks.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(ks.Crypto.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(ks.Crypto.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := hex.DecodeString(ks.Crypto.Encrypted)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := keystoreCipher(password, salt, ks.Crypto.N, ks.Crypto.R, ks.Crypto.P)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := gcm.Open(nil, nonce, encrypted, pub)
+	if err != nil {
+		return nil, errors.New("could not decrypt key with given password")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid seed size '%d' expecting '%d'", len(seed), ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// LoadEd25519Keystore reads and decrypts an ed25519 keystore file
+func LoadEd25519Keystore(path string, password string) (ed25519.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptEd25519Key(content, password)
+}
+
+func keystoreCipher(password string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(password), salt, n, r, p, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,216 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// KeyTypeEd25519 is the type of an ed25519 key served by a remote signer
+	KeyTypeEd25519 = "ed25519"
+
+	remoteSignerTimeout  = time.Second * 30
+	maxRemoteRequestSize = 1 << 20
+)
+
+// RemoteKeyInfo is returned by a GET on the url of a key of a remote signer
+type RemoteKeyInfo struct {
+	Type string `json:"type"`
+	// PublicKey is the hex encoded public key
+	PublicKey string `json:"publicKey"`
+}
+
+// RemoteSignRequest is POSTed to <key url>/sign
+type RemoteSignRequest struct {
+	// Data is the message to sign
+	Data []byte `json:"data"`
+}
+
+// RemoteSignResponse is the answer to a RemoteSignRequest
+type RemoteSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type remoteClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (c *remoteClient) do(method string, path string, body interface{}, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func newRemoteClient(url string, token string, keyType string) (*remoteClient, []byte, error) {
+	c := &remoteClient{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: remoteSignerTimeout},
+	}
+	var info RemoteKeyInfo
+	if err := c.do(http.MethodGet, "", nil, &info); err != nil {
+		return nil, nil, fmt.Errorf("failed to get the key from the remote signer: %w", err)
+	}
+	if info.Type != keyType {
+		return nil, nil, fmt.Errorf("remote signer key is of type %q, expected %q", info.Type, keyType)
+	}
+	pub, err := hex.DecodeString(info.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, pub, nil
+}
+
+func (c *remoteClient) sign(data []byte) ([]byte, error) {
+	var resp RemoteSignResponse
+	if err := c.do(http.MethodPost, "/sign", RemoteSignRequest{Data: data}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+type remoteEd25519Signer struct {
+	client    *remoteClient
+	publicKey ed25519.PublicKey
+}
+
+// NewRemoteEd25519Signer returns an Ed25519Signer for an ed25519 key of a remote signer
+func NewRemoteEd25519Signer(url string, token string) (Ed25519Signer, error) {
+	client, pub, err := newRemoteClient(url, token, KeyTypeEd25519)
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size '%d' expecting '%d'", len(pub), ed25519.PublicKeySize)
+	}
+	return &remoteEd25519Signer{client: client, publicKey: pub}, nil
+}
+
+func (s *remoteEd25519Signer) PublicKey() ed25519.PublicKey {
+	return s.publicKey
+}
+
+func (s *remoteEd25519Signer) Sign(message []byte) ([]byte, error) {
+	signature, err := s.client.sign(message)
+	if err != nil {
+		return nil, err
+	}
+	// never pass on a signature of another key
+	if !ed25519.Verify(s.publicKey, message, signature) {
+		return nil, errors.New("remote signer returned an invalid signature")
+	}
+	return signature, nil
+}
+
+// RemoteSignerServer is a reference implementation of a remote signer.
+// Every key is served under /keys/<name>:
+//
+//	GET  /keys/<name>       returns the RemoteKeyInfo of the key
+//	POST /keys/<name>/sign  signs the RemoteSignRequest and returns a RemoteSignResponse
+//
+// If a token is configured, requests need to have it as a bearer token in the Authorization header.
+type RemoteSignerServer struct {
+	token      string
+	ed25519    map[string]Ed25519Signer
+	publicKeys map[string]RemoteKeyInfo
+}
+
+// NewRemoteSignerServer creates a RemoteSignerServer without keys
+func NewRemoteSignerServer(token string) *RemoteSignerServer {
+	return &RemoteSignerServer{
+		token:      token,
+		ed25519:    make(map[string]Ed25519Signer),
+		publicKeys: make(map[string]RemoteKeyInfo),
+	}
+}
+
+// AddEd25519 serves an ed25519 key under the given name
+func (s *RemoteSignerServer) AddEd25519(name string, signer Ed25519Signer) {
+	s.ed25519[name] = signer
+	s.publicKeys[name] = RemoteKeyInfo{Type: KeyTypeEd25519, PublicKey: hex.EncodeToString(signer.PublicKey())}
+}
+
+func (s *RemoteSignerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/keys/")
+	if path == r.URL.Path {
+		http.NotFound(w, r)
+		return
+	}
+	name, action, _ := strings.Cut(path, "/")
+	info, found := s.publicKeys[name]
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, info)
+	case action == "sign" && r.Method == http.MethodPost:
+		var req RemoteSignRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRemoteRequestSize)).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		signature, err := s.ed25519[name].Sign(req.Data)
+		if err != nil {
+			log.Error().Err(err).Str("key", name).Msg("failed to sign")
+			http.Error(w, "failed to sign", http.StatusInternalServerError)
+			return
+		}
+		log.Info().Str("key", name).Str("remote", r.RemoteAddr).Msg("Signed request")
+		writeJSON(w, RemoteSignResponse{Signature: signature})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
@@ -0,0 +1,332 @@
+// Package lease elects the master among the signers of the bridge account.
+//
+// Every signer grants the lease to one candidate at a time until it expires. A candidate is master
+// while a majority of the signers, itself included, granted it the lease, so no two candidates can be
+// master at the same time. The master renews the lease well before it expires, when it dies the grants
+// expire and another candidate takes over.
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	gorpc "github.com/libp2p/go-libp2p-gorpc"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/quorum"
+)
+
+// ServiceName is the name the Service is registered with on the rpc server of the signers
+const ServiceName = "LeaseService"
+
+// ErrHeld is returned if a signer granted the lease to another candidate
+var ErrHeld = errors.New("the lease is held by another candidate")
+
+// Config are the timings of the election, they should be the same on all signers
+type Config struct {
+	// Duration is how long a grant is valid
+	Duration time.Duration
+	// Renew is the interval at which the master renews the lease and the other candidates try to take it
+	Renew time.Duration
+	// Handover is the time a new master waits after the lease of the previous one ended before it acts
+	Handover time.Duration
+}
+
+// DefaultConfig renews the lease twice before it expires, it does not wait for a handover
+var DefaultConfig = Config{Duration: 30 * time.Second, Renew: 10 * time.Second}
+
+// Request asks a signer to grant or release the lease, the candidate is the peer sending it
+type Request struct{}
+
+// Grant is the answer of a signer to a request for the lease
+type Grant struct {
+	Granted bool
+	// Holder is the peer the lease is granted to
+	Holder string
+	// Duration is how long the lease is granted for
+	Duration time.Duration
+	// Wait is the remaining handover time after the lease of another candidate ended
+	Wait time.Duration
+}
+
+// Service grants the lease to the candidates, it is served by every signer taking part in the election
+type Service struct {
+	self peer.ID
+	cfg  Config
+
+	lock    sync.Mutex
+	started time.Time
+	holder  peer.ID
+	expires time.Time
+	// previous is the last holder other than the current one and ended when its lease ended.
+	// After a restart the previous holder is not known, the lease is considered to end at the start.
+	previous peer.ID
+	ended    time.Time
+}
+
+// NewService creates the lease service of the signer with the given peer id
+func NewService(self peer.ID, cfg Config) *Service {
+	return newService(self, cfg, time.Now())
+}
+
+func newService(self peer.ID, cfg Config, started time.Time) *Service {
+	return &Service{self: self, cfg: cfg, started: started, ended: started}
+}
+
+// Acquire grants or renews the lease for the peer sending the request
+// This is calable on the libp2p network with RPC
+func (s *Service) Acquire(ctx context.Context, request Request, response *Grant) error {
+	sender, err := gorpc.GetRequestSender(ctx)
+	if err != nil {
+		return err
+	}
+	*response = s.grant(sender, time.Now())
+	return nil
+}
+
+// Release ends the lease of the peer sending the request
+// This is calable on the libp2p network with RPC
+func (s *Service) Release(ctx context.Context, request Request, response *Grant) error {
+	sender, err := gorpc.GetRequestSender(ctx)
+	if err != nil {
+		return err
+	}
+	s.release(sender, time.Now())
+	return nil
+}
+
+// Holder returns the peer the lease is granted to, empty if it is not granted or expired
+func (s *Service) Holder() peer.ID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if time.Now().After(s.expires) {
+		return ""
+	}
+	return s.holder
+}
+
+func (s *Service) grant(candidate peer.ID, now time.Time) Grant {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// A restarted signer does not know who it granted the lease to, it waits until that grant expired
+	if now.Before(s.started.Add(s.cfg.Duration)) {
+		return Grant{}
+	}
+	if s.holder != candidate {
+		if s.holder != "" && now.Before(s.expires) {
+			return Grant{Holder: s.holder.String()}
+		}
+		if s.holder != "" {
+			s.previous, s.ended = s.holder, s.expires
+		}
+		s.holder = candidate
+	}
+	s.expires = now.Add(s.cfg.Duration)
+
+	grant := Grant{Granted: true, Holder: candidate.String(), Duration: s.cfg.Duration}
+	if s.previous != candidate {
+		if wait := s.ended.Add(s.cfg.Handover).Sub(now); wait > 0 {
+			grant.Wait = wait
+		}
+	}
+	return grant
+}
+
+func (s *Service) release(candidate peer.ID, now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.holder != candidate || now.After(s.expires) {
+		return
+	}
+	s.previous, s.ended = s.holder, now
+	s.holder, s.expires = "", time.Time{}
+}
+
+// Caller calls a method of the lease service of a signer
+type Caller func(ctx context.Context, id peer.ID, method string, request *Request, response *Grant) error
+
+// Status is the state of the election as reported by the admin server
+type Status struct {
+	// Holder is the peer this signer granted the lease to
+	Holder  string `json:"holder,omitempty"`
+	Leading bool   `json:"leading"`
+}
+
+// Elector campaigns for the lease
+type Elector struct {
+	service *Service
+	call    Caller
+	cfg     Config
+
+	lock    sync.Mutex
+	peers   []peer.ID
+	leading bool
+	until   time.Time
+}
+
+// NewElector creates an elector for the signer of the service, peers are the other signers
+func NewElector(service *Service, peers []peer.ID, call Caller) *Elector {
+	return &Elector{service: service, peers: peers, call: call, cfg: service.cfg}
+}
+
+// SetPeers replaces the other signers when the signers of the bridge account change,
+// the majority is counted on the new signers from the next renewal of the lease
+func (e *Elector) SetPeers(peers []peer.ID) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.peers = peers
+}
+
+func (e *Elector) currentPeers() []peer.ID {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.peers
+}
+
+// Leading reports if this signer holds the lease and the handover is over
+func (e *Elector) Leading() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.leading && time.Now().Before(e.until)
+}
+
+// Status returns the state of the election
+func (e *Elector) Status() Status {
+	return Status{Holder: e.service.Holder().String(), Leading: e.Leading()}
+}
+
+// Run campaigns for the lease until the context is done.
+// Once the lease is held and the handover is over, lead is called with a context which is cancelled when the lease is lost.
+func (e *Elector) Run(ctx context.Context, lead func(context.Context)) {
+	var (
+		holding  bool
+		leadFrom time.Time
+		stop     context.CancelFunc
+	)
+	resign := func() {
+		if stop != nil {
+			stop()
+			stop = nil
+		}
+		e.lock.Lock()
+		e.leading = false
+		e.lock.Unlock()
+	}
+	defer func() {
+		resign()
+		if holding {
+			e.release(e.currentPeers())
+		}
+	}()
+
+	for {
+		until, wait, err := e.acquire(ctx)
+		switch {
+		case err != nil && holding:
+			log.Warn().Err(err).Msg("Lost the master lease")
+			resign()
+			holding = false
+		case err != nil:
+			log.Debug().Err(err).Msg("Master lease not acquired")
+		case !holding:
+			holding = true
+			leadFrom = time.Now().Add(wait)
+			log.Info().Dur("handover", wait.Round(time.Second)).Msg("Acquired the master lease")
+		}
+		if holding {
+			e.lock.Lock()
+			e.until = until
+			e.lock.Unlock()
+			if stop == nil && !time.Now().Before(leadFrom) {
+				log.Info().Msg("Acting as master")
+				stop = e.startLeading(ctx, lead)
+			}
+		}
+
+		delay := e.cfg.Renew
+		if !holding {
+			// spread the candidates so they do not split the grants
+			delay += time.Duration(rand.Int63n(int64(e.cfg.Renew)))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// startLeading calls lead with a context which is cancelled by the returned function
+func (e *Elector) startLeading(ctx context.Context, lead func(context.Context)) context.CancelFunc {
+	leadCtx, stop := context.WithCancel(ctx)
+	e.lock.Lock()
+	e.leading = true
+	e.lock.Unlock()
+	go lead(leadCtx)
+	return stop
+}
+
+// acquire asks the signers for the lease and returns until when it is held and the handover time.
+// The grants are released if no majority granted it.
+func (e *Elector) acquire(ctx context.Context) (until time.Time, wait time.Duration, err error) {
+	start := time.Now()
+	own := e.service.grant(e.service.self, start)
+	if !own.Granted {
+		return time.Time{}, 0, fmt.Errorf("%w %s", ErrHeld, own.Holder)
+	}
+
+	peers := e.currentPeers()
+	round := quorum.Config{Timeout: e.cfg.Renew / 2, Attempts: 1}
+	required := (len(peers) + 1) / 2
+	grants, outcomes, err := quorum.Collect(ctx, round, nil, "", peers, required, func(ctx context.Context, id peer.ID) (Grant, error) {
+		var grant Grant
+		if err := e.call(ctx, id, "Acquire", &Request{}, &grant); err != nil {
+			return grant, err
+		}
+		if !grant.Granted {
+			return grant, fmt.Errorf("%w %s", ErrHeld, grant.Holder)
+		}
+		return grant, nil
+	})
+	if err != nil {
+		var granted []peer.ID
+		for _, outcome := range outcomes {
+			if outcome.Answered {
+				granted = append(granted, outcome.Peer)
+			}
+		}
+		e.release(granted)
+		return time.Time{}, 0, err
+	}
+
+	duration, wait := own.Duration, own.Wait
+	for _, grant := range grants {
+		duration = min(duration, grant.Duration)
+		wait = max(wait, grant.Wait)
+	}
+	// the grants are counted from when the signers received the request, which is after the start
+	return start.Add(duration), wait, nil
+}
+
+// release gives the lease back on this signer and the given peers
+func (e *Elector) release(peers []peer.ID) {
+	e.service.release(e.service.self, time.Now())
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.Renew/2)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, id := range peers {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			if err := e.call(ctx, id, "Release", &Request{}, &Grant{}); err != nil {
+				log.Debug().Err(err).Str("peerID", id.String()).Msg("failed to release the master lease")
+			}
+		}(id)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,128 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceGrant(t *testing.T) {
+	cfg := Config{Duration: 30 * time.Second, Renew: 10 * time.Second, Handover: 5 * time.Minute}
+	started := time.Now()
+	s := newService("self", cfg, started)
+
+	// a restarted signer does not grant the lease until an earlier grant expired
+	assert.False(t, s.grant("a", started.Add(time.Second)).Granted)
+
+	now := started.Add(cfg.Duration)
+	grant := s.grant("a", now)
+	require.True(t, grant.Granted)
+	assert.Equal(t, cfg.Duration, grant.Duration)
+	// the holder before the restart is not known, a is not master before the handover
+	assert.Equal(t, started.Add(cfg.Handover).Sub(now), grant.Wait)
+
+	refused := s.grant("b", now.Add(time.Second))
+	assert.False(t, refused.Granted)
+	assert.Equal(t, peer.ID("a").String(), refused.Holder)
+
+	// the lease of a expires and b takes over after the handover
+	now = now.Add(cfg.Duration + time.Second)
+	grant = s.grant("b", now)
+	require.True(t, grant.Granted)
+	assert.Equal(t, cfg.Handover-time.Second, grant.Wait)
+
+	// a released lease can be taken over at once, but the handover is still waited for
+	s.release("b", now.Add(time.Second))
+	assert.Equal(t, peer.ID(""), s.Holder())
+	grant = s.grant("a", now.Add(2*time.Second))
+	require.True(t, grant.Granted)
+	assert.Equal(t, cfg.Handover-time.Second, grant.Wait)
+}
+
+// signers routes the lease requests of the electors to the services of the signers
+type signers struct {
+	lock     sync.Mutex
+	services map[peer.ID]*Service
+	down     map[peer.ID]bool
+}
+
+func (s *signers) caller(from peer.ID) Caller {
+	return func(ctx context.Context, id peer.ID, method string, request *Request, response *Grant) error {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.down[from] || s.down[id] {
+			return errors.New("unreachable")
+		}
+		switch method {
+		case "Acquire":
+			*response = s.services[id].grant(from, time.Now())
+		case "Release":
+			s.services[id].release(from, time.Now())
+		}
+		return nil
+	}
+}
+
+func TestElectorFailover(t *testing.T) {
+	cfg := Config{Duration: 200 * time.Millisecond, Renew: 50 * time.Millisecond}
+	ids := []peer.ID{"a", "b", "c"}
+	started := time.Now().Add(-cfg.Duration)
+	net := &signers{services: map[peer.ID]*Service{}, down: map[peer.ID]bool{}}
+	electors := map[peer.ID]*Elector{}
+	for _, id := range ids {
+		net.services[id] = newService(id, cfg, started)
+	}
+	for _, id := range ids {
+		var others []peer.ID
+		for _, other := range ids {
+			if other != id {
+				others = append(others, other)
+			}
+		}
+		electors[id] = NewElector(net.services[id], others, net.caller(id))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var lock sync.Mutex
+	led := map[peer.ID]context.Context{}
+	for _, id := range ids {
+		id := id
+		go electors[id].Run(ctx, func(ctx context.Context) {
+			lock.Lock()
+			defer lock.Unlock()
+			led[id] = ctx
+		})
+	}
+
+	leaders := func() (leading []peer.ID) {
+		for _, id := range ids {
+			if electors[id].Leading() {
+				leading = append(leading, id)
+			}
+		}
+		return
+	}
+	require.Eventually(t, func() bool { return len(leaders()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	master := leaders()[0]
+
+	// the master can no longer reach the other signers, it steps down and another one takes over
+	net.lock.Lock()
+	net.down[master] = true
+	net.lock.Unlock()
+	require.Eventually(t, func() bool {
+		leading := leaders()
+		return len(leading) == 1 && leading[0] != master
+	}, 2*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Error(t, led[master].Err(), "the old master is told to stop")
+	assert.Len(t, led, 2)
+}
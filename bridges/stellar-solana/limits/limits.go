@@ -0,0 +1,174 @@
+// Package limits bounds the amounts the bridge mints and withdraws
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// precisionDigits is the amount of decimals of TFT, all amounts are in stroops
+const precisionDigits = 7
+
+var (
+	// ErrBelowMinimum is returned for a transfer smaller than the minimum
+	ErrBelowMinimum = errors.New("amount is below the minimum")
+	// ErrAboveMaximum is returned for a transfer larger than the maximum
+	ErrAboveMaximum = errors.New("amount is above the maximum")
+	// ErrCapExceeded is returned if a transfer would exceed the hourly or daily volume cap
+	ErrCapExceeded = errors.New("volume cap exceeded")
+)
+
+// Limits bounds single transfers and the volume transferred in a rolling hour and day.
+// All amounts are in stroops, a limit of 0 is not enforced.
+//
+// Limits are written as a comma separated list of TFT amounts, for example
+//
+//	min=100,max=100000,hourly=250000,daily=1000000
+type Limits struct {
+	Min    int64
+	Max    int64
+	Hourly int64
+	Daily  int64
+}
+
+// Volume is the amount transferred in the last hour and in the last day, in stroops
+type Volume struct {
+	Hour int64 `json:"hour"`
+	Day  int64 `json:"day"`
+}
+
+// CheckAmount checks the amount of a single transfer against the minimum and the maximum.
+// An amount larger than the hourly or daily cap never fits the caps, it is above the maximum as well.
+func (l Limits) CheckAmount(amount int64) error {
+	if l.Min > 0 && amount < l.Min {
+		return fmt.Errorf("%w of %s TFT", ErrBelowMinimum, formatAmount(l.Min))
+	}
+	if l.Max > 0 && amount > l.Max {
+		return fmt.Errorf("%w of %s TFT", ErrAboveMaximum, formatAmount(l.Max))
+	}
+	if l.Hourly > 0 && amount > l.Hourly {
+		return fmt.Errorf("%w: the hourly cap is %s TFT", ErrAboveMaximum, formatAmount(l.Hourly))
+	}
+	if l.Daily > 0 && amount > l.Daily {
+		return fmt.Errorf("%w: the daily cap is %s TFT", ErrAboveMaximum, formatAmount(l.Daily))
+	}
+	return nil
+}
+
+// CheckVolume checks if the amount fits in the volume caps given what is already transferred
+func (l Limits) CheckVolume(amount int64, volume Volume) error {
+	if l.Hourly > 0 && volume.Hour+amount > l.Hourly {
+		return fmt.Errorf("%w: %s TFT transferred in the last hour, the cap is %s TFT", ErrCapExceeded, formatAmount(volume.Hour), formatAmount(l.Hourly))
+	}
+	if l.Daily > 0 && volume.Day+amount > l.Daily {
+		return fmt.Errorf("%w: %s TFT transferred in the last day, the cap is %s TFT", ErrCapExceeded, formatAmount(volume.Day), formatAmount(l.Daily))
+	}
+	return nil
+}
+
+// Exceeded returns true if the error is returned because a transfer does not fit the limits
+func Exceeded(err error) bool {
+	return errors.Is(err, ErrBelowMinimum) || errors.Is(err, ErrAboveMaximum) || errors.Is(err, ErrCapExceeded)
+}
+
+// Validate checks if the limits are consistent
+func (l Limits) Validate() error {
+	if l.Min < 0 || l.Max < 0 || l.Hourly < 0 || l.Daily < 0 {
+		return errors.New("limits can not be negative")
+	}
+	if l.Max > 0 && l.Max < l.Min {
+		return errors.New("the maximum is lower than the minimum")
+	}
+	if l.Hourly > 0 && l.Daily > 0 && l.Daily < l.Hourly {
+		return errors.New("the daily cap is lower than the hourly cap")
+	}
+	// a transfer between a cap and the maximum would be held forever
+	if l.Max > 0 && ((l.Hourly > 0 && l.Hourly < l.Max) || (l.Daily > 0 && l.Daily < l.Max)) {
+		return errors.New("the hourly and daily caps can not be lower than the maximum")
+	}
+	return nil
+}
+
+// Parse parses limits as described on Limits, an empty spec sets no limits
+func Parse(spec string) (Limits, error) {
+	var l Limits
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return l, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return Limits{}, fmt.Errorf("invalid limits %q: expected <limit>=<amount>, got %q", spec, part)
+		}
+		amount, err := parseAmount(value)
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid limits %q: %w", spec, err)
+		}
+		switch key {
+		case "min":
+			l.Min = amount
+		case "max":
+			l.Max = amount
+		case "hourly":
+			l.Hourly = amount
+		case "daily":
+			l.Daily = amount
+		default:
+			return Limits{}, fmt.Errorf("invalid limits %q: unknown limit %q", spec, key)
+		}
+	}
+	if err := l.Validate(); err != nil {
+		return Limits{}, fmt.Errorf("invalid limits %q: %w", spec, err)
+	}
+	return l, nil
+}
+
+// parseAmount parses a TFT amount to stroops
+func parseAmount(value string) (int64, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	stroops := amount.Shift(precisionDigits)
+	if !stroops.IsInteger() {
+		return 0, fmt.Errorf("%s has more than %d decimals", value, precisionDigits)
+	}
+	return stroops.IntPart(), nil
+}
+
+func formatAmount(stroops int64) string {
+	return decimal.New(stroops, -precisionDigits).String()
+}
+
+// String formats the limits in the format Parse accepts
+func (l Limits) String() string {
+	var parts []string
+	for _, limit := range []struct {
+		key    string
+		amount int64
+	}{{"min", l.Min}, {"max", l.Max}, {"hourly", l.Hourly}, {"daily", l.Daily}} {
+		if limit.amount > 0 {
+			parts = append(parts, limit.key+"="+formatAmount(limit.amount))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set implements pflag.Value
+func (l *Limits) Set(spec string) error {
+	parsed, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// Type implements pflag.Value
+func (l *Limits) Type() string {
+	return "limits"
+}
@@ -2,28 +2,56 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	flag "github.com/spf13/pflag"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/api/bridge"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/config"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/policy"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
 )
 
 var Version = "development"
 
+// envPrefix is the prefix of the environment variables the settings are read from
+const envPrefix = "BRIDGE"
+
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "version" {
 		fmt.Println(Version)
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "keystore" {
+		keystoreCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "remotesigner" {
+		remoteSignerCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		reconcileCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		auditCommand(os.Args[2:])
+		return
+	}
 
 	var bridgeCfg bridge.BridgeConfig
 	var stellarCfg stellar.StellarConfig
@@ -32,8 +60,14 @@ func main() {
 
 	flag.StringVar(&bridgeCfg.PersistencyFile, "persistency", "./node.json", "file where last seen blockheight and stellar account cursor is stored")
 
-	flag.StringVar(&stellarCfg.StellarSeed, "secret", "", "stellar secret")
-	flag.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet or production")
+	var keyOptions keys.Options
+	flag.StringVar(&stellarCfg.StellarSeed, "secret", "", "stellar account key: keystore:<ed25519 keystore file>, remote:<remote signer key url> or the stellar secret")
+	flag.StringVar(&keyOptions.PasswordFile, "keystore-password-file", "", "file containing the password of the keystore files")
+	flag.StringVar(&keyOptions.RemoteTokenFile, "remote-signer-token-file", "", "file containing the token to authenticate to the remote signer")
+	flag.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet, production or the name of a network configured with the horizon url, passphrase and asset issuer")
+	flag.StringVar(&stellarCfg.HorizonURL, "horizon-url", "", "horizon url, overrides the horizon of the stellar network")
+	flag.StringVar(&stellarCfg.NetworkPassphrase, "network-passphrase", "", "stellar network passphrase, overrides the passphrase of the stellar network")
+	flag.StringVar(&stellarCfg.AssetIssuer, "asset-issuer", "", "issuer of the TFT asset, overrides the issuer on the stellar network")
 	// Stellar account where fees are sent to
 	flag.StringVar(&stellarCfg.StellarFeeWallet, "feewallet", "", "stellar fee wallet address")
 
@@ -43,15 +77,34 @@ func main() {
 
 	flag.BoolVar(&bridgeCfg.Follower, "follower", false, "if true then the bridge will run in follower mode meaning that it will not submit mint transactions to the multisig contract, if false the bridge will also submit transactions")
 
+	flag.BoolVar(&bridgeCfg.Failover, "failover", false, "elect the master among the signers of the bridge account, the bridge acts as master while it holds the lease and as follower otherwise, --follower is ignored")
+
 	flag.StringVar(&bridgeMasterAddress, "master", "", "master stellar public address")
-	flag.Int64Var(&bridgeCfg.DepositFee, "depositFee", 50, "sets the depositfee in TFT")
+	bridgeCfg.DepositFee = fees.NewFlat(50 * stellar.Precision)
+	flag.Var(&bridgeCfg.DepositFee, "depositFee", "deposit fee schedule in TFT: a flat fee (50), a percentage (0.5%,min=10,max=500) or tiers (tiers:1000=5,10000=20,*=50)")
+	bridgeCfg.WithdrawFee = fees.NewFlat(1 * stellar.Precision)
+	flag.Var(&bridgeCfg.WithdrawFee, "withdrawFee", "withdraw fee schedule in TFT, in the same format as the deposit fee")
+	flag.Var(&bridgeCfg.MintLimits, "mintLimits", "limits on the deposited amounts in TFT: min=100,max=100000,hourly=250000,daily=1000000, all optional")
+	flag.Var(&bridgeCfg.WithdrawLimits, "withdrawLimits", "limits on the withdrawn amounts in TFT, in the same format as the mint limits")
 
 	// P2P Configuration
 	flag.StringVar(&bridgeCfg.Psk, "psk", "", "psk for the relay")
 	flag.StringVar(&bridgeCfg.Relay, "relay", "", "relay address")
 
+	flag.BoolVar(&bridgeCfg.DryRun, "dry-run", false, "build and validate the transactions of the master but record them in decisions.jsonl next to the persistency file instead of submitting them")
+	flag.BoolVar(&bridgeCfg.DryRunSignatures, "dry-run-signatures", false, "request the cosigner signatures for the transactions in dry-run mode")
+
+	var policyFile string
+	flag.StringVar(&policyFile, "policy", "", "yaml file with the signing policy a follower applies to the mints and withdrawals it signs")
+
+	var pauseFile string
+	flag.StringVar(&pauseFile, "pause-file", "", "the bridge is paused while this file exists, it can also be paused through the admin API or with SIGUSR1 and resumed with SIGUSR2")
+
+	flag.StringVar(&bridgeCfg.AdminAddr, "admin-addr", "", "address for the admin http server with the status, metrics, liveness and readiness endpoints, disabled if empty")
+	flag.StringVar(&bridgeCfg.AdminToken, "admin-token", "", "bearer token needed to pause and resume the bridge through the admin server, pausing through the admin server is refused if empty")
+
 	// Solana stuff
-	flag.StringVar(&solCfg.KeyFile, "solana-key", "", "path to the solana keyfile containing the private key used to sign")
+	flag.StringVar(&solCfg.KeyFile, "solana-key", "", "solana account key: keystore:<ed25519 keystore file>, remote:<remote signer key url> or the path to the solana keyfile containing the private key used to sign")
 	flag.StringVar(&solCfg.NetworkName, "solana-network", "", "the solana network to connect to")
 	flag.StringVar(&solCfg.TokenAddress, "solana-token-address", "", "the solana token address to bridge for")
 	flag.StringVar(&solCfg.Endpoint, "solana-rpc-url", "", "custom url to use for solana rpc and ws connections, overrides solana-network provided built-in urls")
@@ -59,15 +112,46 @@ func main() {
 	var debug bool
 	flag.BoolVar(&debug, "debug", false, "sets debug level log output")
 
+	flag.String(config.FileFlag, "", "yaml config file with flag names as keys, settings can also be given as BRIDGE_<FLAG> environment variables and read from files with <flag>_file or BRIDGE_<FLAG>_FILE")
+
 	flag.Parse()
+	bridgeCfg.Version = Version
 
-	if err := stellarCfg.Validate(); err != nil {
-		panic(err)
+	// the limits and fees are validated when they are parsed, their errors are in configErr
+	configErr := config.Load(flag.CommandLine, envPrefix)
+	var failoverErr error
+	if bridgeCfg.Failover && bridgeMasterAddress == "" {
+		failoverErr = errors.New("--failover needs the bridge account as --master")
 	}
-
-	if err := solCfg.Validate(); err != nil {
-		panic(err)
+	signingPolicy, policyErr := policy.Load(policyFile)
+	var relayErr error
+	if _, err := peer.AddrInfoFromString(bridgeCfg.Relay); err != nil {
+		relayErr = fmt.Errorf("invalid --relay: %w", err)
 	}
+	var adminErr error
+	if bridgeCfg.AdminAddr != "" {
+		if _, _, err := net.SplitHostPort(bridgeCfg.AdminAddr); err != nil {
+			adminErr = fmt.Errorf("invalid --admin-addr: %w", err)
+		}
+	}
+	// a missing secret is reported by the stellar config
+	var stellarSigner keys.Ed25519Signer
+	var keyErr error
+	if stellarCfg.StellarSeed != "" {
+		var err error
+		if stellarSigner, err = keys.NewEd25519Signer(stellarCfg.StellarSeed, keyOptions, keys.StellarSeed); err != nil {
+			keyErr = fmt.Errorf("failed to load the stellar key: %w", err)
+		}
+	}
+	solSigner, err := keys.NewEd25519Signer(solCfg.KeyFile, keyOptions, keys.SolanaKeygenFile)
+	if err != nil {
+		keyErr = errors.Join(keyErr, fmt.Errorf("failed to load the solana key: %w", err))
+	}
+	if err := errors.Join(configErr, stellarCfg.Validate(), solCfg.Validate(), failoverErr, policyErr, relayErr, adminErr, keyErr); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+	stellarCfg.SetNetwork()
 
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	if debug {
@@ -76,18 +160,26 @@ func main() {
 
 	log.Info().Str("network", solCfg.NetworkName).Msg("solana network configured")
 
+	if err := run(&bridgeCfg, &stellarCfg, &solCfg, bridgeMasterAddress, stellarSigner, solSigner, signingPolicy, pauseFile); err != nil {
+		log.Error().Err(err).Msg("Bridge stopped")
+		os.Exit(1)
+	}
+}
+
+// run starts the bridge with the validated configuration and blocks until it gets SIGINT or SIGTERM or the admin server fails
+func run(bridgeCfg *bridge.BridgeConfig, stellarCfg *stellar.StellarConfig, solCfg *solana.SolanaConfig, bridgeMasterAddress string, stellarSigner, solSigner keys.Ed25519Signer, signingPolicy policy.Policy, pauseFile string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	host, router, err := bridge.NewHost(ctx, stellarCfg.StellarSeed, bridgeCfg.Relay, bridgeCfg.Psk)
+	host, router, err := bridge.NewHost(ctx, stellarSigner, bridgeCfg.Relay, bridgeCfg.Psk)
 	if err != nil {
-		fmt.Println("failed to create host")
-		panic(err)
+		return fmt.Errorf("failed to create host: %w", err)
 	}
+	defer host.Close()
 
 	partialMA, err := multiaddr.NewMultiaddr(fmt.Sprintf("/p2p/%s", host.ID()))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	for _, addr := range host.Addrs() {
@@ -98,41 +190,54 @@ func main() {
 	txStorage := stellar.NewTransactionStorage(stellarCfg.StellarNetwork, bridgeMasterAddress)
 	err = txStorage.ScanBridgeAccount(ctx)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to scan the bridge account: %w", err)
 	}
 
-	stellarWallet, err := stellar.NewWallet(&stellarCfg, bridgeCfg.DepositFee, bridge.WithdrawFee, txStorage)
+	stellarWallet, err := stellar.NewWallet(stellarCfg, stellarSigner, bridgeCfg.DepositFee, bridgeCfg.WithdrawFee, txStorage)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create the stellar wallet: %w", err)
 	}
 	log.Info().Str("network", stellarCfg.StellarNetwork).Str("wallet", stellarWallet.GetAddress()).Msg("Stellar wallet loaded")
 
-	sol, err := solana.New(ctx, &solCfg)
+	sol, err := solana.New(ctx, solCfg, solSigner)
 	if err != nil {
-		fmt.Println(err)
-		panic(err)
+		return fmt.Errorf("failed to connect to solana: %w", err)
 	}
 
-	br, err := bridge.NewBridge(ctx, stellarWallet, sol, &bridgeCfg, host, router)
+	pauseSwitch := pause.NewSwitch(pauseFile)
+	go pauseSwitch.HandleSignals(ctx)
+
+	br, err := bridge.NewBridge(ctx, stellarWallet, sol, bridgeCfg, host, router, pauseSwitch)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create the bridge: %w", err)
 	}
 
 	err = br.Start(ctx)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to start the bridge: %w", err)
+	}
+
+	failed := make(chan error, 1)
+	if bridgeCfg.AdminAddr != "" {
+		adminHandler := br.AdminHandler()
+		adminHandler.Handle("/metrics", metrics.Handler())
+		go func() {
+			if err := bridge.ServeAdmin(ctx, bridgeCfg.AdminAddr, adminHandler); err != nil {
+				failed <- fmt.Errorf("admin server failed: %w", err)
+			}
+		}()
 	}
 
-	// Start the signer server
-	if bridgeCfg.Follower {
+	// Start the signer server, with failover every bridge can be a follower
+	if bridgeCfg.Follower || bridgeCfg.Failover {
 		err = bridge.NewSolIDServer(host, sol.Address())
 		if err != nil {
-			panic(err)
+			return errors.Join(fmt.Errorf("failed to start the solana id server: %w", err), br.Close())
 		}
 		log.Info().Msg("Registered SolIDService")
-		err = bridge.NewSignerServer(host, bridgeMasterAddress, sol, stellarWallet, bridgeCfg.DepositFee)
+		err = bridge.NewSignerServer(host, bridgeMasterAddress, sol, stellarWallet, bridgeCfg.DepositFee, bridgeCfg.WithdrawFee, pauseSwitch, signingPolicy, bridgeCfg.PersistencyFile, br.LeaseService(), br.NodeStatus)
 		if err != nil {
-			panic(err)
+			return errors.Join(fmt.Errorf("failed to start the signer server: %w", err), br.Close())
 		}
 		log.Info().Msg("Registered SignerService")
 	}
@@ -142,15 +247,15 @@ func main() {
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 	log.Info().Msg("awaiting signal")
-	sig := <-sigs
-	log.Info().Str("signal", sig.String()).Msg("signal")
-	cancel()
-	err = br.Close()
-	if err != nil {
-		panic(err)
+	select {
+	case sig := <-sigs:
+		log.Info().Str("signal", sig.String()).Msg("signal")
+	case err = <-failed:
 	}
+	cancel()
+	err = errors.Join(err, br.Close())
 
-	host.Close()
 	log.Info().Msg("exiting")
 	time.Sleep(time.Second * 5)
+	return err
 }
@@ -0,0 +1,148 @@
+// Package metrics holds the prometheus metrics of the bridge
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "bridge"
+
+	// stroopsPerTFT is the precision of the TFT amounts
+	stroopsPerTFT = 1e7
+)
+
+// Transfer types
+const (
+	TransferDeposit    = "deposit"
+	TransferMint       = "mint"
+	TransferRefund     = "refund"
+	TransferFee        = "fee"
+	TransferWithdrawal = "withdrawal"
+)
+
+// Operations which are retried next to the transfers
+const (
+	RetryStellarTransactions = "stellar_transactions"
+)
+
+// Services the bridge talks to
+const (
+	ServiceHorizon = "horizon"
+	ServiceSolana  = "solana"
+)
+
+// Signing requests sent to the cosigners
+const (
+	SignStellar = "stellar"
+	SignMint    = "mint"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	transfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Number of deposits, mints, refunds, fee transfers and withdrawals handled by the bridge.",
+	}, []string{"type"})
+	transferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transferred_tft_total",
+		Help:      "Amount of TFT deposited, minted, refunded, transferred as fee and withdrawn.",
+	}, []string{"type"})
+
+	// Retries counts the retries of failed operations
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Number of retries of failed operations.",
+	}, []string{"operation"})
+
+	// SigningDuration is the time it takes to collect the signatures of the cosigners
+	SigningDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "signing_round_duration_seconds",
+		Help:      "Duration of the signing rounds with the cosigners.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30},
+	}, []string{"request"})
+	// SigningFailures counts the failed sign requests per cosigner
+	SigningFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signing_failures_total",
+		Help:      "Number of sign requests a cosigner failed to answer.",
+	}, []string{"request", "peer"})
+
+	// HeadLag is the amount of slots the last processed burn was behind the chain tip
+	HeadLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "head_lag_slots",
+		Help:      "Slots between the chain tip and the slot of the last processed burn when it was processed.",
+	})
+
+	rpcErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Number of failed requests to horizon and the solana rpc node.",
+	}, []string{"service"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		transfers,
+		transferred,
+		Retries,
+		SigningDuration,
+		SigningFailures,
+		HeadLag,
+		rpcErrors,
+	)
+}
+
+// Transfer records a transfer of the given type, the amount is in stroops
+func Transfer(transferType string, amount int64) {
+	transfers.WithLabelValues(transferType).Inc()
+	transferred.WithLabelValues(transferType).Add(float64(amount) / stroopsPerTFT)
+}
+
+// ObserveSince observes the seconds since start
+func ObserveSince(observer prometheus.Observer, start time.Time) {
+	observer.Observe(time.Since(start).Seconds())
+}
+
+// RPCError records a failed request to a service
+func RPCError(service string) {
+	rpcErrors.WithLabelValues(service).Inc()
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// InstrumentHTTP returns an http client which records the failed requests to the service.
+// Transport errors and responses with a 5xx or 429 status are failures, other statuses
+// are answers of the service.
+func InstrumentHTTP(service string) *http.Client {
+	return &http.Client{Transport: &transport{service: service, next: http.DefaultTransport}}
+}
+
+type transport struct {
+	service string
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		RPCError(t.service)
+	}
+	return resp, err
+}
@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tft = int64(1e7)
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`
+maxTransfer: 100000
+dailyVolume: 500000.5
+blocked:
+  - GBLOCKED
+  - " 9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin"
+largeTransfer: 50000
+businessHours:
+  days: [mon, tue, wed, thu, Fri]
+  hours: "08:00-18:30"
+  timezone: Europe/Brussels
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 100000*tft, p.MaxTransfer)
+	assert.Equal(t, 500000*tft+tft/2, p.DailyVolume)
+	assert.Equal(t, 50000*tft, p.LargeTransfer)
+	assert.Equal(t, map[string]bool{"GBLOCKED": true, "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin": true}, p.Blocked)
+	require.NotNil(t, p.BusinessHours)
+	assert.Equal(t, [7]bool{false, true, true, true, true, true, false}, p.BusinessHours.Days)
+	assert.Equal(t, 8*time.Hour, p.BusinessHours.Start)
+	assert.Equal(t, 18*time.Hour+30*time.Minute, p.BusinessHours.End)
+	assert.Equal(t, "Europe/Brussels", p.BusinessHours.Location.String())
+
+	p, err = Parse(nil)
+	require.NoError(t, err)
+	assert.Equal(t, Policy{}, p)
+
+	for _, invalid := range []string{
+		"maxTransfer: -5",
+		"maxTransfer: 0.00000001",
+		"maxTransfer: 100\ndailyVolume: 50",
+		"weeklyVolume: 5",
+		"largeTransfer: 5",
+		"businessHours:\n  hours: 08:00-18:00",
+		"businessHours:\n  days: [monday]\n  hours: 08:00-18:00",
+		"businessHours:\n  days: [mon]\n  hours: 8-18",
+		"businessHours:\n  days: [mon]\n  hours: 08:00-08:00",
+		"businessHours:\n  days: [mon]\n  hours: 08:00-18:00\n  timezone: Nowhere/Town",
+	} {
+		_, err = Parse([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := Policy{
+		MaxTransfer:   1000 * tft,
+		DailyVolume:   2000 * tft,
+		Blocked:       map[string]bool{"GBLOCKED": true, "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin": true},
+		LargeTransfer: 500 * tft,
+		BusinessHours: &BusinessHours{Days: [7]bool{time.Monday: true}, Start: 8 * time.Hour, End: 18 * time.Hour, Location: time.UTC},
+	}
+
+	assert.NoError(t, p.Check(1000*tft, "GDESTINATION", 1000*tft, monday))
+	assert.ErrorIs(t, p.Check(1001*tft, "GDESTINATION", 0, monday), ErrAboveMaximum)
+	assert.ErrorIs(t, p.Check(100*tft, "GDESTINATION", 1901*tft, monday), ErrDailyVolumeExceeded)
+	assert.ErrorIs(t, p.Check(1, "GBLOCKED", 0, monday), ErrBlockedDestination)
+	assert.ErrorIs(t, p.Check(1, "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin", 0, monday), ErrBlockedDestination)
+	assert.NoError(t, p.Check(1, "9XQEWVG816BUX9EPJHMAT23YVVM2ZWBRRPZB9PUSVFIN", 0, monday), "solana addresses are case sensitive")
+
+	// only large transfers wait for the business hours
+	assert.NoError(t, p.Check(499*tft, "GDESTINATION", 0, monday.Add(-6*time.Hour)))
+	assert.ErrorIs(t, p.Check(500*tft, "GDESTINATION", 0, monday.Add(-6*time.Hour)), ErrOutsideBusinessHours)
+	assert.ErrorIs(t, p.Check(500*tft, "GDESTINATION", 0, monday.Add(6*time.Hour)), ErrOutsideBusinessHours)
+	assert.ErrorIs(t, p.Check(500*tft, "GDESTINATION", 0, monday.Add(24*time.Hour)), ErrOutsideBusinessHours)
+	assert.True(t, Violated(p.Check(500*tft, "GDESTINATION", 0, monday.Add(24*time.Hour))))
+
+	overnight := BusinessHours{Days: [7]bool{true, true, true, true, true, true, true}, Start: 22 * time.Hour, End: 6 * time.Hour, Location: time.UTC}
+	assert.True(t, overnight.Contains(monday.Add(11*time.Hour)))
+	assert.True(t, overnight.Contains(monday.Add(-7*time.Hour)))
+	assert.False(t, overnight.Contains(monday))
+
+	assert.NoError(t, Policy{}.Check(1e18, "GBLOCKED", 1e18, monday))
+}
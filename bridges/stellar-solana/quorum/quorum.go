@@ -0,0 +1,185 @@
+// Package quorum asks a set of peers the same question until enough of them answered
+package quorum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ErrNoQuorum is returned if not enough peers answered in a round
+var ErrNoQuorum = errors.New("not enough peers answered")
+
+// errNotAnswered is the outcome of a peer which was still being asked when the round ended
+var errNotAnswered = errors.New("no answer before the end of the round")
+
+// Config bounds a round
+type Config struct {
+	// Timeout is the maximum duration of a round
+	Timeout time.Duration
+	// Attempts is the number of times a peer is asked in a round
+	Attempts int
+	// Backoff is the wait before the second attempt, it doubles for every next attempt
+	Backoff time.Duration
+	// Retry decides if a failed attempt is tried again, nil retries every failure
+	Retry func(error) bool
+}
+
+// DefaultConfig is used for the rounds of the signers client
+var DefaultConfig = Config{Timeout: 30 * time.Second, Attempts: 3, Backoff: time.Second}
+
+// Store keeps the answers of the peers per request across rounds
+type Store interface {
+	// Get returns the json encoded answers of a request by peer id
+	Get(request string) map[string]json.RawMessage
+	// Add stores the json encoded answer of a peer for a request
+	Add(request string, peer string, answer json.RawMessage) error
+}
+
+// Outcome is what happened with a peer in a round
+type Outcome struct {
+	Peer peer.ID
+	// Attempts is the number of times the peer was asked in this round
+	Attempts int
+	// Answered is true if the peer answered, in this round or in an earlier one if Stored is true
+	Answered bool
+	Stored   bool
+	// Err is the error of the last attempt if the peer did not answer
+	Err error
+	// StoreErr is set if the answer could not be kept for a later round
+	StoreErr error
+}
+
+// Failed checks if the peer was asked and did not answer
+func (o Outcome) Failed() bool {
+	return !o.Answered && o.Err != nil
+}
+
+type reply[T any] struct {
+	index   int
+	answer  T
+	outcome Outcome
+}
+
+// Collect asks the peers concurrently until required of them answered and returns the answers and the outcome of every peer.
+// Once enough peers answered, the others are no longer waited for.
+//
+// If store is not nil, the answers are kept under the request id so a later round for the same request
+// only asks the peers which did not answer yet.
+func Collect[T any](ctx context.Context, cfg Config, store Store, request string, peers []peer.ID, required int, ask func(context.Context, peer.ID) (T, error)) ([]T, []Outcome, error) {
+	if store != nil && request == "" {
+		store = nil
+	}
+	var answers []T
+	outcomes := make([]Outcome, len(peers))
+	var stored map[string]json.RawMessage
+	if store != nil {
+		stored = store.Get(request)
+	}
+	for i, id := range peers {
+		outcomes[i].Peer = id
+		data, found := stored[id.String()]
+		if !found {
+			continue
+		}
+		var answer T
+		if err := json.Unmarshal(data, &answer); err != nil {
+			continue
+		}
+		outcomes[i].Answered, outcomes[i].Stored = true, true
+		answers = append(answers, answer)
+	}
+	if len(answers) >= required {
+		return answers[:required], outcomes, nil
+	}
+
+	roundCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	replies := make(chan reply[T], len(peers))
+	pending := 0
+	for i, id := range peers {
+		if outcomes[i].Stored {
+			continue
+		}
+		pending++
+		go func(i int, id peer.ID) {
+			answer, outcome := askWithRetries(roundCtx, cfg, id, ask)
+			replies <- reply[T]{index: i, answer: answer, outcome: outcome}
+		}(i, id)
+	}
+
+	for pending > 0 && len(answers) < required {
+		select {
+		case r := <-replies:
+			pending--
+			outcomes[r.index] = r.outcome
+			if !r.outcome.Answered {
+				continue
+			}
+			answers = append(answers, r.answer)
+			if store != nil {
+				data, err := json.Marshal(r.answer)
+				if err == nil {
+					err = store.Add(request, r.outcome.Peer.String(), data)
+				}
+				outcomes[r.index].StoreErr = err
+			}
+		case <-roundCtx.Done():
+			pending = 0
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, outcomes, ctx.Err()
+	}
+	if len(answers) < required {
+		for i := range outcomes {
+			if !outcomes[i].Answered && outcomes[i].Err == nil {
+				outcomes[i].Err = errNotAnswered
+			}
+		}
+		return nil, outcomes, fmt.Errorf("%w, %d of %d: %s", ErrNoQuorum, len(answers), required, Summary(outcomes))
+	}
+	return answers, outcomes, nil
+}
+
+// askWithRetries asks a peer until it answers, the attempts are used up or the round ends
+func askWithRetries[T any](ctx context.Context, cfg Config, id peer.ID, ask func(context.Context, peer.ID) (T, error)) (answer T, outcome Outcome) {
+	outcome.Peer = id
+	backoff := cfg.Backoff
+	for {
+		outcome.Attempts++
+		answer, outcome.Err = ask(ctx, id)
+		if outcome.Err == nil {
+			outcome.Answered = true
+			return
+		}
+		if outcome.Attempts >= cfg.Attempts || (cfg.Retry != nil && !cfg.Retry(outcome.Err)) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Summary describes the peers which did not answer
+func Summary(outcomes []Outcome) string {
+	var failures []string
+	for _, outcome := range outcomes {
+		if outcome.Failed() {
+			failures = append(failures, fmt.Sprintf("%s after %d attempts: %s", outcome.Peer, outcome.Attempts, outcome.Err))
+		}
+	}
+	if len(failures) == 0 {
+		return "no failures"
+	}
+	return strings.Join(failures, "; ")
+}
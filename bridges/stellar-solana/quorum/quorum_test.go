@@ -0,0 +1,106 @@
+package quorum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string]map[string]json.RawMessage
+
+func (m memoryStore) Get(request string) map[string]json.RawMessage {
+	return m[request]
+}
+
+func (m memoryStore) Add(request string, peer string, answer json.RawMessage) error {
+	if m[request] == nil {
+		m[request] = make(map[string]json.RawMessage)
+	}
+	m[request][peer] = answer
+	return nil
+}
+
+var errRefused = errors.New("refused")
+
+func TestCollect(t *testing.T) {
+	peers := []peer.ID{"a", "b", "c"}
+	cfg := Config{Timeout: time.Second, Attempts: 3, Backoff: time.Millisecond, Retry: func(err error) bool { return !errors.Is(err, errRefused) }}
+	store := memoryStore{}
+
+	var lock sync.Mutex
+	asked := map[peer.ID]int{}
+	unreachable := errors.New("unreachable")
+	ask := func(ctx context.Context, id peer.ID) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		asked[id]++
+		switch {
+		case id == "b" && asked[id] < 3:
+			return "", unreachable
+		case id == "c":
+			return "", errRefused
+		}
+		return "signature " + string(id), nil
+	}
+
+	// b answers at the third attempt, c refuses and is not asked again
+	answers, outcomes, err := Collect(context.Background(), cfg, store, "request", peers, 2, ask)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"signature a", "signature b"}, answers)
+	assert.Equal(t, map[peer.ID]int{"a": 1, "b": 3, "c": 1}, asked)
+	assert.Equal(t, Outcome{Peer: "b", Attempts: 3, Answered: true}, outcomes[1])
+	assert.True(t, outcomes[2].Failed())
+	assert.ErrorIs(t, outcomes[2].Err, errRefused)
+
+	// the answers are kept, so the next round of the same request does not ask again
+	answers, outcomes, err = Collect(context.Background(), cfg, store, "request", peers, 2, ask)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"signature a", "signature b"}, answers)
+	assert.Equal(t, map[peer.ID]int{"a": 1, "b": 3, "c": 1}, asked)
+	assert.True(t, outcomes[0].Stored)
+
+	// only the missing peer is asked if more answers are required
+	_, outcomes, err = Collect(context.Background(), cfg, store, "request", peers, 3, ask)
+	assert.ErrorIs(t, err, ErrNoQuorum)
+	assert.ErrorContains(t, err, "2 of 3")
+	assert.Equal(t, map[peer.ID]int{"a": 1, "b": 3, "c": 2}, asked)
+	assert.False(t, outcomes[0].Failed())
+	assert.True(t, outcomes[2].Failed())
+}
+
+func TestCollectSlowPeer(t *testing.T) {
+	peers := []peer.ID{"fast", "slow"}
+	cfg := Config{Timeout: 50 * time.Millisecond, Attempts: 1}
+	ask := func(ctx context.Context, id peer.ID) (int, error) {
+		if id == "slow" {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return 1, nil
+	}
+
+	// the slow peer is not waited for once enough peers answered
+	start := time.Now()
+	answers, _, err := Collect(context.Background(), cfg, nil, "", peers, 1, ask)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, answers)
+	assert.Less(t, time.Since(start), cfg.Timeout)
+
+	answers, outcomes, err := Collect(context.Background(), cfg, nil, "", peers, 2, ask)
+	assert.ErrorIs(t, err, ErrNoQuorum)
+	assert.Nil(t, answers)
+	assert.True(t, outcomes[0].Answered)
+	assert.True(t, outcomes[1].Failed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Collect(ctx, cfg, nil, "", peers, 2, ask)
+	assert.ErrorIs(t, err, context.Canceled)
+}
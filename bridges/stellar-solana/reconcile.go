@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/rs/zerolog/log"
+	flag "github.com/spf13/pflag"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/reconcile"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/stellar"
+)
+
+// reconcileCommand replays the transfers of both chains in a range and prints the ones which do not add up as json
+func reconcileCommand(args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	var solCfg solana.SolanaConfig
+	fs.StringVar(&solCfg.NetworkName, "solana-network", "", "the solana network to connect to")
+	fs.StringVar(&solCfg.TokenAddress, "solana-token-address", "", "the solana token address to bridge for")
+	fs.StringVar(&solCfg.Endpoint, "solana-rpc-url", "", "custom url to use for solana rpc and ws connections, overrides solana-network provided built-in urls")
+	fromSlot := fs.Uint64("from-slot", 0, "first solana slot to reconcile")
+	toSlot := fs.Uint64("to-slot", 0, "last solana slot to reconcile, the latest finalized slot if 0")
+	var stellarCfg stellar.StellarConfig
+	fs.StringVar(&stellarCfg.StellarNetwork, "network", "testnet", "stellar network, testnet, production or the name of a network configured with the horizon url, passphrase and asset issuer")
+	fs.StringVar(&stellarCfg.HorizonURL, "horizon-url", "", "horizon url, overrides the horizon of the stellar network")
+	fs.StringVar(&stellarCfg.NetworkPassphrase, "network-passphrase", "", "stellar network passphrase, overrides the passphrase of the stellar network")
+	fs.StringVar(&stellarCfg.AssetIssuer, "asset-issuer", "", "issuer of the TFT asset, overrides the issuer on the stellar network")
+	vault := fs.String("vault", "", "stellar address of the bridge account")
+	feeWallet := fs.String("feewallet", "", "stellar fee wallet address")
+	fromLedger := fs.Int32("from-ledger", 0, "first stellar ledger to reconcile")
+	toLedger := fs.Int32("to-ledger", 0, "last stellar ledger to reconcile, the latest ledger if 0")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge reconcile --vault <address> --feewallet <address> --solana-token-address <address> [--from-ledger <ledger>] [--from-slot <slot>]")
+		fmt.Fprintln(os.Stderr, "Pairs the deposits on the bridge account with their mints, refunds and fee transfers and the burns with their payments.")
+		fmt.Fprintln(os.Stderr, "The report is printed as json, the exit code is 1 if there are issues.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !stellar.IsValidStellarAddress(*vault) || solCfg.TokenAddress == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	stellarCfg.SetNetwork()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	log.Info().Str("vault", *vault).Int32("from", *fromLedger).Int32("to", *toLedger).Msg("Loading the stellar transactions")
+	deposits, payments, err := stellar.VaultTransfers(ctx, stellarCfg.StellarNetwork, *vault, *feeWallet, *fromLedger, *toLedger)
+	if err != nil {
+		panic(err)
+	}
+	log.Info().Str("network", solCfg.NetworkName).Uint64("from", *fromSlot).Uint64("to", *toSlot).Msg("Loading the solana transactions")
+	sol, err := solana.NewReadOnly(ctx, &solCfg)
+	if err != nil {
+		panic(err)
+	}
+	defer sol.Close()
+	mints, withdrawals, err := sol.Transfers(ctx, *fromSlot, *toSlot)
+	if err != nil {
+		panic(err)
+	}
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+
+	report := reconcile.Match(reconcile.Input{
+		Vault:       *vault,
+		FeeWallet:   *feeWallet,
+		Deposits:    deposits,
+		Mints:       mints,
+		Withdrawals: withdrawals,
+		Payments:    payments,
+	})
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		panic(err)
+	}
+	if len(report.Issues) > 0 {
+		os.Exit(1)
+	}
+}
@@ -29,6 +29,9 @@ type Burn struct {
 
 	// signature of the transaction, which is also the txId
 	signature Signature
+
+	// slot the transaction was included in
+	slot uint64
 }
 
 // Memo associated with the token burn
@@ -51,6 +54,11 @@ func (b Burn) ShortTxID() ShortTxID {
 	return shortenTxID(b.signature)
 }
 
+// Slot the burn transaction was included in
+func (b Burn) Slot() uint64 {
+	return b.slot
+}
+
 // Caller of the burn operation
 func (b Burn) Caller() Address {
 	return b.caller
@@ -0,0 +1,71 @@
+package solana
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/reconcile"
+)
+
+// signaturesPageLimit is the maximum amount of signatures returned by a single getSignaturesForAddress call
+const signaturesPageLimit = 1000
+
+// Transfers returns the mints and burns of the token in the given (inclusive) slot range.
+// If toSlot is 0, the transactions up to the latest finalized slot are returned.
+func (sol *Solana) Transfers(ctx context.Context, fromSlot uint64, toSlot uint64) (mints []reconcile.Mint, withdrawals []reconcile.Withdrawal, err error) {
+	limit := signaturesPageLimit
+	opts := rpc.GetSignaturesForAddressOpts{Limit: &limit, Commitment: rpc.CommitmentFinalized}
+	for {
+		sigs, err := sol.rpcClient.GetSignaturesForAddressWithOpts(ctx, sol.tokenAddress, &opts)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not load token signatures")
+		}
+		// the signatures are returned newest first
+		for _, sig := range sigs {
+			if sig.Slot < fromSlot {
+				return mints, withdrawals, nil
+			}
+			if (toSlot > 0 && sig.Slot > toSlot) || sig.Err != nil {
+				continue
+			}
+			txRes, err := sol.GetTransaction(ctx, sig.Signature)
+			if err != nil {
+				return nil, nil, err
+			}
+			tx, err := txRes.Transaction.GetTransaction()
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to decode tranasction")
+			}
+
+			if burn, err := burnFromTransaction(*tx); err == nil {
+				withdrawals = append(withdrawals, reconcile.Withdrawal{
+					// the payment of a withdrawal carries the short tx id as memo hash
+					ID:          burn.ShortTxID().String(),
+					TxHash:      sig.Signature.String(),
+					Block:       txRes.Slot,
+					Destination: burn.Memo(),
+					Amount:      int64(burn.RawAmount()),
+				})
+				continue
+			}
+			amount, memo, receiver, err := ExtractMintvalues(*tx)
+			if err != nil {
+				log.Debug().Err(err).Str("signature", sig.Signature.String()).Msg("Skipping token transaction which is not a burn or a mint")
+				continue
+			}
+			mints = append(mints, reconcile.Mint{
+				DepositID: memo,
+				TxHash:    sig.Signature.String(),
+				Block:     txRes.Slot,
+				Receiver:  receiver.String(),
+				Amount:    amount,
+			})
+		}
+		if len(sigs) < limit {
+			return mints, withdrawals, nil
+		}
+		opts.Before = sigs[len(sigs)-1].Signature
+	}
+}
@@ -0,0 +1,47 @@
+package solana
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
+)
+
+// metricsRPCClient records the failed rpc calls in the metrics
+type metricsRPCClient struct {
+	client rpc.JSONRPCClient
+}
+
+var _ rpc.JSONRPCClient = metricsRPCClient{}
+
+func (c metricsRPCClient) record(ctx context.Context, err error) error {
+	// calls aborted by the bridge itself are not failures of the rpc node
+	if err != nil && ctx.Err() == nil {
+		metrics.RPCError(metrics.ServiceSolana)
+	}
+	return err
+}
+
+func (c metricsRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return c.record(ctx, c.client.CallForInto(ctx, out, method, params))
+}
+
+func (c metricsRPCClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return c.record(ctx, c.client.CallWithCallback(ctx, method, params, callback))
+}
+
+func (c metricsRPCClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	responses, err := c.client.CallBatch(ctx, requests)
+	return responses, c.record(ctx, err)
+}
+
+// Close closes the wrapped client, rpc.Client.Close only closes clients implementing io.Closer
+func (c metricsRPCClient) Close() error {
+	if closer, ok := c.client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
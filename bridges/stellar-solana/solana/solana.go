@@ -17,6 +17,8 @@ import (
 	"github.com/gagliardetto/solana-go/rpc/ws"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/time/rate"
 )
@@ -67,7 +69,8 @@ type Solana struct {
 	rpcClient *rpc.Client
 	wsClient  *ws.Client
 
-	account solana.PrivateKey
+	signer  keys.Ed25519Signer
+	account solana.PublicKey
 
 	// The address of the token to use
 	tokenAddress solana.PublicKey
@@ -76,13 +79,20 @@ type Solana struct {
 	txCache *transactionCache
 }
 
-// New Solana client connected to the provided network
-func New(ctx context.Context, cfg *SolanaConfig) (*Solana, error) {
-	account, err := solana.PrivateKeyFromSolanaKeygenFile(cfg.KeyFile)
+// New Solana client connected to the provided network, the transactions are signed with signer
+func New(ctx context.Context, cfg *SolanaConfig, signer keys.Ed25519Signer) (*Solana, error) {
+	sol, err := NewReadOnly(ctx, cfg)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not load solana key file")
+		return nil, err
 	}
+	sol.signer = signer
+	sol.account = solana.PublicKeyFromBytes(signer.PublicKey())
 
+	return sol, nil
+}
+
+// NewReadOnly Solana client connected to the provided network without a key, it can only read from the network
+func NewReadOnly(ctx context.Context, cfg *SolanaConfig) (*Solana, error) {
 	parsedTokenAddress, err := solana.PublicKeyFromBase58(cfg.TokenAddress)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not parse token address")
@@ -102,12 +112,26 @@ func New(ctx context.Context, cfg *SolanaConfig) (*Solana, error) {
 
 	txCache := newTransactionCache()
 
-	return &Solana{network: cfg.NetworkName, rpcClient: rpcClient, wsClient: wsClient, account: account, tokenAddress: parsedTokenAddress, txCache: txCache}, nil
+	return &Solana{network: cfg.NetworkName, rpcClient: rpcClient, wsClient: wsClient, tokenAddress: parsedTokenAddress, txCache: txCache}, nil
 }
 
 // Address of the solana wallet
 func (sol *Solana) Address() Address {
-	return sol.account.PublicKey()
+	return sol.account
+}
+
+// Balance of the solana wallet in lamports
+func (sol *Solana) Balance(ctx context.Context) (uint64, error) {
+	res, err := sol.rpcClient.GetBalance(ctx, sol.account, rpc.CommitmentFinalized)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get the balance of the solana wallet")
+	}
+	return res.Value, nil
+}
+
+// CurrentSlot returns the slot of the chain tip
+func (sol *Solana) CurrentSlot(ctx context.Context) (uint64, error) {
+	return sol.rpcClient.GetSlot(ctx, rpc.CommitmentConfirmed)
 }
 
 // GetTransaction loads the transaction for a given signature. If the tx exists, it is added to a cache to avoid future network calls for this sig.
@@ -240,39 +264,28 @@ func (sol *Solana) IsValidReceiver(ctx context.Context, receiver Address) (bool,
 }
 
 func (sol *Solana) CreateTokenSignature(tx Transaction) (Signature, int, error) {
-	// First clear possible existing signatures so we can isolate the signature we generated
-	tx.Signatures = nil
-	sigs, err := tx.PartialSign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if sol.account.PublicKey().Equals(key) {
-				return &sol.account
-			}
-
-			return nil
-		})
-	if err != nil {
-		return Signature{}, 0, errors.Wrap(err, "could not sign transaction")
-	}
-
-	sigCount := 0
-	idx := 0
-	signature := Signature{}
-	for i, sig := range sigs {
-		if sig != [64]byte{} {
-			signature = sig
-			sigCount++
+	// The signature index is the index of our key in the required signers of the transaction
+	idx := -1
+	for i, key := range tx.Message.Signers() {
+		if key.Equals(sol.account) {
 			idx = i
+			break
 		}
 	}
+	if idx < 0 {
+		return Signature{}, 0, errors.New("account is not a signer of the transaction")
+	}
 
-	switch sigCount {
-	case 0:
-		return Signature{}, 0, errors.New("no transaction signatures generated")
-	case 1:
-		return signature, idx, nil
-	default:
-		return Signature{}, 0, errors.New("generated more than 1 transaction signature")
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return Signature{}, 0, errors.Wrap(err, "could not encode transaction message")
 	}
+	sig, err := sol.signer.Sign(message)
+	if err != nil {
+		return Signature{}, 0, errors.Wrap(err, "could not sign transaction")
+	}
+
+	return solana.SignatureFromBytes(sig), idx, nil
 }
 
 // GetBurnTransaction on the solona network with the provided txId
@@ -296,6 +309,7 @@ func (sol *Solana) GetBurnTransaction(ctx context.Context, txID ShortTxID) (Burn
 			if err != nil {
 				return Burn{}, errors.Wrap(err, "failed to parse burn transaction")
 			}
+			burn.slot = txRes.Slot
 			return burn, nil
 		}
 	}
@@ -427,10 +441,10 @@ func (sol *Solana) PrepareMintTx(ctx context.Context, info MintInfo) (*Transacti
 	tx, err := solana.NewTransaction([]solana.Instruction{
 		// TODO: Compute actual limit
 		budget.NewSetComputeUnitLimitInstruction(50000).Build(),
-		CustomMemoInstruction(txID, sol.account.PublicKey()),
-		// memo.NewMemoInstruction(txID, sol.account.PublicKey()).Build(),
+		CustomMemoInstruction(txID, sol.account),
+		// memo.NewMemoInstruction(txID, sol.account).Build(),
 		token.NewMintToCheckedInstruction(info.Amount, mint.Decimals, sol.tokenAddress, to, *mint.MintAuthority, filteredSigners).Build(),
-	}, recent.Value.Blockhash, solana.TransactionPayer(sol.account.PublicKey()))
+	}, recent.Value.Blockhash, solana.TransactionPayer(sol.account))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create mint transaction")
 	}
@@ -547,7 +561,7 @@ func (sol *Solana) SubscribeTokenBurns(ctx context.Context) (<-chan Burn, error)
 		}
 
 		if memoText != "" && burnAmount != 0 && !illegalOp {
-			return &Burn{amount: burnAmount, decimals: tokenDecimals, memo: memoText, caller: source, signature: sig}
+			return &Burn{amount: burnAmount, decimals: tokenDecimals, memo: memoText, caller: source, signature: sig, slot: res.Slot}
 		}
 
 		return nil
@@ -568,6 +582,7 @@ func (sol *Solana) SubscribeTokenBurns(ctx context.Context) (<-chan Burn, error)
 			sub, err := sol.wsClient.LogsSubscribeMentions(sol.tokenAddress, rpc.CommitmentFinalized)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to open solana log subscription")
+				metrics.RPCError(metrics.ServiceSolana)
 				// Reconnect the websocket, in case the websocket itself is closed.
 				sol.wsClient.Close()
 				sol.wsClient, err = newSolanaWsClient(ctx, sol.network)
@@ -584,6 +599,9 @@ func (sol *Solana) SubscribeTokenBurns(ctx context.Context) (<-chan Burn, error)
 				got, err := sub.Recv(ctx)
 				if err != nil {
 					log.Error().Err(err).Msg("Failed to get new tx logs from subscription")
+					if ctx.Err() == nil {
+						metrics.RPCError(metrics.ServiceSolana)
+					}
 					break
 				}
 
@@ -630,7 +648,7 @@ func (sol *Solana) Close() error {
 
 // getSolanaClientCustomEndpoint gets an RPC client and websocket client which connects to a custom URL endpoint
 func getSolanaClientCustomEndpoint(ctx context.Context, endpoint string) (*rpc.Client, *ws.Client, error) {
-	rpcClient := rpc.NewWithCustomRPCClient(rpc.NewWithLimiter(fmt.Sprintf("https://%s", endpoint), rate.Every(time.Second), 10))
+	rpcClient := rpc.NewWithCustomRPCClient(metricsRPCClient{client: rpc.NewWithLimiter(fmt.Sprintf("https://%s", endpoint), rate.Every(time.Second), 10)})
 
 	wsClient, err := ws.Connect(ctx, fmt.Sprintf("wss://%s", endpoint))
 	if err != nil {
@@ -648,7 +666,7 @@ func getSolanaClient(ctx context.Context, network string) (*rpc.Client, *ws.Clie
 		return nil, nil, err
 	}
 
-	rpcClient := rpc.NewWithCustomRPCClient(rpc.NewWithLimiter(config.RPC, rate.Every(time.Second), 10))
+	rpcClient := rpc.NewWithCustomRPCClient(metricsRPCClient{client: rpc.NewWithLimiter(config.RPC, rate.Every(time.Second), 10)})
 
 	wsClient, err := ws.Connect(ctx, config.WS)
 	if err != nil {
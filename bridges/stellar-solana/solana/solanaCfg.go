@@ -1,9 +1,15 @@
 package solana
 
-import "github.com/gagliardetto/solana-go"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
 
 type SolanaConfig struct {
-	// KeyFile path for the account to sign solana transaction with.
+	// KeyFile is the key specification of the account to sign solana transaction with,
+	// a plain path is a file created by solana-keygen.
 	KeyFile string
 	// NetworkName of the solana network to connect to
 	NetworkName string
@@ -13,23 +19,16 @@ type SolanaConfig struct {
 	Endpoint string
 }
 
-// Validate the Solana config
+// Validate the Solana config, the network is only checked if no custom endpoint is set
 func (cfg SolanaConfig) Validate() error {
-	var err error
-	if cfg.Endpoint != "" {
-		switch cfg.NetworkName {
-		case "local":
-		case "devnet":
-		case "testnet":
-		case "production":
-		default:
-			err = ErrSolanaNetworkNotSupported
+	var errs []error
+	if cfg.Endpoint == "" {
+		if _, err := getNetworkConfig(cfg.NetworkName); err != nil {
+			errs = append(errs, err)
 		}
 	}
-
-	if err != nil {
-		_, err = solana.PublicKeyFromBase58(cfg.TokenAddress)
+	if _, err := solana.PublicKeyFromBase58(cfg.TokenAddress); err != nil {
+		errs = append(errs, fmt.Errorf("could not parse token address: %w", err))
 	}
-
-	return err
+	return errors.Join(errs...)
 }
@@ -0,0 +1,141 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HeldMint is a deposit which is not minted yet because it does not fit the mint volume caps
+type HeldMint struct {
+	// TxID is the hash of the deposit transaction
+	TxID string `json:"txId"`
+	// Memo is the memo of the deposit with the receiver of the mint
+	Memo   string `json:"memo"`
+	Sender string `json:"sender"`
+	// Amount is the deposited amount in stroops
+	Amount int64     `json:"amount"`
+	Reason string    `json:"reason,omitempty"`
+	HeldAt time.Time `json:"heldAt"`
+}
+
+// HeldMints keeps the deposits which wait for the mint volume to free up,
+// so they do not block the deposits after them and survive a restart.
+type HeldMints struct {
+	location string
+	mints    map[string]*HeldMint
+
+	lock sync.Mutex
+}
+
+// HeldMintsFile returns the location of the held mints which are stored
+// next to the given ChainPersistency file.
+func HeldMintsFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "held-mints.json")
+}
+
+// NewHeldMints loads the HeldMints stored at the given location.
+// If the file does not exist yet, no mints are held.
+func NewHeldMints(location string) (*HeldMints, error) {
+	h := &HeldMints{
+		location: location,
+		mints:    make(map[string]*HeldMint),
+	}
+
+	file, err := os.ReadFile(location)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var mints []*HeldMint
+	if err = json.Unmarshal(file, &mints); err != nil {
+		return nil, err
+	}
+	for _, m := range mints {
+		h.mints[m.TxID] = m
+	}
+	return h, nil
+}
+
+// Hold stores a deposit as held because of cause.
+// It returns false if the deposit was already held for the same reason, in which case nothing is written.
+func (h *HeldMints) Hold(m HeldMint, cause error) (changed bool, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	previous, known := h.mints[m.TxID]
+	if known && previous.Reason == cause.Error() {
+		return false, nil
+	}
+	m.Reason = cause.Error()
+	m.HeldAt = time.Now()
+	if known {
+		m.HeldAt = previous.HeldAt
+	}
+	h.mints[m.TxID] = &m
+	if err = h.save(); err != nil {
+		if known {
+			h.mints[m.TxID] = previous
+		} else {
+			delete(h.mints, m.TxID)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release removes a deposit once it is minted or refunded, releasing a deposit which is not held does nothing
+func (h *HeldMints) Release(txID string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	previous, known := h.mints[txID]
+	if !known {
+		return nil
+	}
+	delete(h.mints, txID)
+	if err := h.save(); err != nil {
+		h.mints[txID] = previous
+		return err
+	}
+	return nil
+}
+
+// All returns the held deposits in the order they were held
+func (h *HeldMints) All() []HeldMint {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	mints := make([]HeldMint, 0, len(h.mints))
+	for _, m := range h.mints {
+		mints = append(mints, *m)
+	}
+	sort.Slice(mints, func(i, j int) bool {
+		return mints[i].HeldAt.Before(mints[j].HeldAt)
+	})
+	return mints
+}
+
+func (h *HeldMints) save() error {
+	mints := make([]*HeldMint, 0, len(h.mints))
+	for _, m := range h.mints {
+		mints = append(mints, m)
+	}
+	sort.Slice(mints, func(i, j int) bool {
+		return mints[i].HeldAt.Before(mints[j].HeldAt)
+	})
+	data, err := json.MarshalIndent(mints, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := h.location + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.location)
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeldMints(t *testing.T) {
+	location := HeldMintsFile(filepath.Join(t.TempDir(), "node.json"))
+	held, err := NewHeldMints(location)
+	require.NoError(t, err)
+	capped, later := errors.New("hourly cap"), errors.New("daily cap")
+
+	changed, err := held.Hold(HeldMint{TxID: "first", Memo: "memo", Sender: "sender", Amount: 100}, capped)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = held.Hold(HeldMint{TxID: "second", Amount: 50}, capped)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = held.Hold(HeldMint{TxID: "first", Memo: "memo", Sender: "sender", Amount: 100}, capped)
+	require.NoError(t, err)
+	assert.False(t, changed, "holding again for the same reason is not a change")
+	changed, err = held.Hold(HeldMint{TxID: "first", Memo: "memo", Sender: "sender", Amount: 100}, later)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	reloaded, err := NewHeldMints(location)
+	require.NoError(t, err)
+	mints := reloaded.All()
+	require.Len(t, mints, 2)
+	assert.Equal(t, "first", mints[0].TxID, "a mint keeps its place when it is held again")
+	assert.Equal(t, "memo", mints[0].Memo)
+	assert.Equal(t, "sender", mints[0].Sender)
+	assert.Equal(t, int64(100), mints[0].Amount)
+	assert.Equal(t, "daily cap", mints[0].Reason)
+
+	require.NoError(t, reloaded.Release("first"))
+	require.NoError(t, reloaded.Release("unknown"))
+	reloaded, err = NewHeldMints(location)
+	require.NoError(t, err)
+	mints = reloaded.All()
+	require.Len(t, mints, 1)
+	assert.Equal(t, "second", mints[0].TxID)
+}
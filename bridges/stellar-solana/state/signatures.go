@@ -0,0 +1,136 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// signatureRetention is how long the signatures of a request are kept, after that all cosigners are asked again
+const signatureRetention = 24 * time.Hour
+
+// partialRequest are the signatures collected for a request
+type partialRequest struct {
+	Created time.Time `json:"created"`
+	// Payload is what the signatures are for, for a Stellar transaction its xdr
+	Payload string `json:"payload,omitempty"`
+	// Signatures are the json encoded answers by peer id
+	Signatures map[string]json.RawMessage `json:"signatures"`
+}
+
+// PartialSignatures keeps the signatures collected from the cosigners per request,
+// so a request which did not reach the required signatures only asks the missing cosigners when it is retried.
+// The payload the signatures are for is kept with them, so the same payload can be signed again after a restart.
+type PartialSignatures struct {
+	location string
+	requests map[string]*partialRequest
+
+	lock sync.Mutex
+}
+
+// SignaturesFile returns the location of the partial signatures which are stored
+// next to the given ChainPersistency file.
+func SignaturesFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "signatures.json")
+}
+
+// NewPartialSignatures loads the PartialSignatures stored at the given location.
+// If the file does not exist yet, an empty store is returned.
+func NewPartialSignatures(location string) (*PartialSignatures, error) {
+	p := &PartialSignatures{
+		location: location,
+		requests: make(map[string]*partialRequest),
+	}
+
+	file, err := os.ReadFile(location)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(file, &p.requests); err != nil {
+		return nil, err
+	}
+	p.prune()
+	return p, nil
+}
+
+// Get returns the signatures collected for a request by peer id
+func (p *PartialSignatures) Get(request string) map[string]json.RawMessage {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	partial, found := p.requests[request]
+	if !found || time.Since(partial.Created) > signatureRetention {
+		return nil
+	}
+	signatures := make(map[string]json.RawMessage, len(partial.Signatures))
+	for peer, signature := range partial.Signatures {
+		signatures[peer] = signature
+	}
+	return signatures
+}
+
+// Payload returns the payload the signatures of a request are collected for
+func (p *PartialSignatures) Payload(request string) string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	partial, found := p.requests[request]
+	if !found || time.Since(partial.Created) > signatureRetention {
+		return ""
+	}
+	return partial.Payload
+}
+
+// SetPayload sets the payload the signatures of a request are collected for.
+// If it differs from the payload before, the signatures collected for that one are dropped.
+func (p *PartialSignatures) SetPayload(request string, payload string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if partial, found := p.requests[request]; found && partial.Payload == payload {
+		return nil
+	}
+	p.requests[request] = &partialRequest{Created: time.Now(), Payload: payload, Signatures: make(map[string]json.RawMessage)}
+	p.prune()
+	return p.save()
+}
+
+// Add stores the signature of a peer for a request
+func (p *PartialSignatures) Add(request string, peer string, signature json.RawMessage) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	partial, found := p.requests[request]
+	if !found {
+		partial = &partialRequest{Created: time.Now(), Signatures: make(map[string]json.RawMessage)}
+		p.requests[request] = partial
+	}
+	partial.Signatures[peer] = signature
+	p.prune()
+	return p.save()
+}
+
+func (p *PartialSignatures) prune() {
+	for request, partial := range p.requests {
+		if time.Since(partial.Created) > signatureRetention {
+			delete(p.requests, request)
+		}
+	}
+}
+
+func (p *PartialSignatures) save() error {
+	data, err := json.MarshalIndent(p.requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p.location + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.location)
+}
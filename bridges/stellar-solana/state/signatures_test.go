@@ -0,0 +1,59 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialSignatures(t *testing.T) {
+	location := SignaturesFile(filepath.Join(t.TempDir(), "node.json"))
+
+	// signatures of a previous run, the first request is expired
+	old := map[string]partialRequest{
+		"old":     {Created: time.Now().Add(-25 * time.Hour), Signatures: map[string]json.RawMessage{"a": json.RawMessage(`"old"`)}},
+		"earlier": {Created: time.Now().Add(-time.Hour), Signatures: map[string]json.RawMessage{"a": json.RawMessage(`"earlier"`)}},
+	}
+	data, err := json.Marshal(old)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(location, data, 0644))
+
+	signatures, err := NewPartialSignatures(location)
+	require.NoError(t, err)
+	assert.Nil(t, signatures.Get("old"))
+	require.NoError(t, signatures.Add("earlier", "b", json.RawMessage(`"b"`)))
+	require.NoError(t, signatures.Add("new", "a", json.RawMessage(`{"signature":"a"}`)))
+
+	reloaded, err := NewPartialSignatures(location)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]json.RawMessage{"a": json.RawMessage(`"earlier"`), "b": json.RawMessage(`"b"`)}, reloaded.Get("earlier"))
+	assert.JSONEq(t, `{"signature":"a"}`, string(reloaded.Get("new")["a"]))
+	assert.Nil(t, reloaded.Get("unknown"))
+}
+
+func TestPartialSignaturesPayload(t *testing.T) {
+	location := SignaturesFile(filepath.Join(t.TempDir(), "node.json"))
+	signatures, err := NewPartialSignatures(location)
+	require.NoError(t, err)
+
+	require.NoError(t, signatures.SetPayload("request", "first"))
+	require.NoError(t, signatures.Add("request", "a", json.RawMessage(`"a"`)))
+	// setting the same payload again keeps the signatures
+	require.NoError(t, signatures.SetPayload("request", "first"))
+
+	reloaded, err := NewPartialSignatures(location)
+	require.NoError(t, err)
+	assert.Equal(t, "first", reloaded.Payload("request"))
+	assert.Len(t, reloaded.Get("request"), 1)
+	assert.Empty(t, reloaded.Payload("unknown"))
+
+	// the signatures of another payload are of no use anymore
+	require.NoError(t, reloaded.SetPayload("request", "second"))
+	assert.Equal(t, "second", reloaded.Payload("request"))
+	assert.Empty(t, reloaded.Get("request"))
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Transfer directions tracked by the VolumeTracker
+const (
+	VolumeMint     = "mint"
+	VolumeWithdraw = "withdraw"
+)
+
+// volumeRetention is the longest window volumes are asked for, older transfers are forgotten
+const volumeRetention = 24 * time.Hour
+
+// TrackedTransfer is a mint or withdrawal counted in the transferred volume
+type TrackedTransfer struct {
+	Direction string    `json:"direction"`
+	ID        string    `json:"id"`
+	Amount    int64     `json:"amount"`
+	At        time.Time `json:"at"`
+}
+
+// VolumeTracker keeps the transfers of the last day so the volume caps survive a restart
+type VolumeTracker struct {
+	location  string
+	transfers map[string]*TrackedTransfer
+
+	lock sync.Mutex
+}
+
+// VolumeFile returns the location of the volume tracker which is stored
+// next to the given ChainPersistency file.
+func VolumeFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "volume.json")
+}
+
+// SignedVolumeFile returns the location of the volume a cosigner signed, which is stored
+// next to the given ChainPersistency file.
+func SignedVolumeFile(persistencyFile string) string {
+	return filepath.Join(filepath.Dir(persistencyFile), "signed-volume.json")
+}
+
+// NewVolumeTracker loads the VolumeTracker stored at the given location.
+// If the file does not exist yet, an empty tracker is returned.
+func NewVolumeTracker(location string) (*VolumeTracker, error) {
+	t := &VolumeTracker{
+		location:  location,
+		transfers: make(map[string]*TrackedTransfer),
+	}
+
+	file, err := os.ReadFile(location)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var transfers []*TrackedTransfer
+	if err = json.Unmarshal(file, &transfers); err != nil {
+		return nil, err
+	}
+	for _, transfer := range transfers {
+		if time.Since(transfer.At) > volumeRetention {
+			continue
+		}
+		t.transfers[transferKey(transfer.Direction, transfer.ID)] = transfer
+	}
+	return t, nil
+}
+
+func transferKey(direction, id string) string {
+	return direction + "/" + id
+}
+
+// Record adds a transfer to the volume of its direction.
+// Recording the same transfer again does not count it twice.
+func (t *VolumeTracker) Record(direction string, id string, amount int64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := transferKey(direction, id)
+	if _, known := t.transfers[key]; known {
+		return nil
+	}
+	t.transfers[key] = &TrackedTransfer{Direction: direction, ID: id, Amount: amount, At: time.Now()}
+	t.prune()
+	if err := t.save(); err != nil {
+		delete(t.transfers, key)
+		return err
+	}
+	return nil
+}
+
+// Known checks if a transfer is recorded in the last day
+func (t *VolumeTracker) Known(direction string, id string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	transfer, known := t.transfers[transferKey(direction, id)]
+	return known && time.Since(transfer.At) <= volumeRetention
+}
+
+// Volume returns the amount transferred in the given direction during the last window
+func (t *VolumeTracker) Volume(direction string, window time.Duration) (volume int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, transfer := range t.transfers {
+		if transfer.Direction == direction && time.Since(transfer.At) <= window {
+			volume += transfer.Amount
+		}
+	}
+	return
+}
+
+func (t *VolumeTracker) prune() {
+	for key, transfer := range t.transfers {
+		if time.Since(transfer.At) > volumeRetention {
+			delete(t.transfers, key)
+		}
+	}
+}
+
+func (t *VolumeTracker) save() error {
+	transfers := make([]*TrackedTransfer, 0, len(t.transfers))
+	for _, transfer := range t.transfers {
+		transfers = append(transfers, transfer)
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].At.Before(transfers[j].At)
+	})
+	data, err := json.MarshalIndent(transfers, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := t.location + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.location)
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolumeTracker(t *testing.T) {
+	location := VolumeFile(filepath.Join(t.TempDir(), "node.json"))
+
+	// transfers of a previous run, the first one is outside every window
+	old := []TrackedTransfer{
+		{Direction: VolumeMint, ID: "old", Amount: 1000, At: time.Now().Add(-25 * time.Hour)},
+		{Direction: VolumeMint, ID: "earlier", Amount: 50, At: time.Now().Add(-2 * time.Hour)},
+	}
+	data, err := json.Marshal(old)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(location, data, 0644))
+
+	tracker, err := NewVolumeTracker(location)
+	require.NoError(t, err)
+	require.NoError(t, tracker.Record(VolumeMint, "a", 100))
+	require.NoError(t, tracker.Record(VolumeMint, "a", 100), "recording twice should not fail")
+	require.NoError(t, tracker.Record(VolumeWithdraw, "a", 7))
+
+	reloaded, err := NewVolumeTracker(location)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), reloaded.Volume(VolumeMint, time.Hour))
+	assert.Equal(t, int64(150), reloaded.Volume(VolumeMint, 24*time.Hour))
+	assert.Equal(t, int64(7), reloaded.Volume(VolumeWithdraw, 24*time.Hour))
+	assert.True(t, reloaded.Known(VolumeMint, "earlier"))
+	assert.False(t, reloaded.Known(VolumeMint, "old"))
+	assert.False(t, reloaded.Known(VolumeWithdraw, "earlier"))
+}
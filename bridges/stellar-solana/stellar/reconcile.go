@@ -0,0 +1,135 @@
+package stellar
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/reconcile"
+)
+
+// VaultTransfers returns the TFT deposits to the vault and the TFT payments made by the vault
+// in the given (inclusive) ledger range. If toLedger is 0, the transactions up to the latest ledger are returned.
+func VaultTransfers(ctx context.Context, network string, vault string, feeWallet string, fromLedger int32, toLedger int32) (deposits []reconcile.Deposit, payments []reconcile.Payment, err error) {
+	client, err := GetHorizonClient(network)
+	if err != nil {
+		return
+	}
+	assetCode, issuer, err := GetTFTAsset(network)
+	if err != nil {
+		return
+	}
+	tft, err := txnbuild.CreditAsset{Code: assetCode, Issuer: issuer}.ToXDR()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	handler := func(tx hProtocol.Transaction) {
+		if ctx.Err() != nil {
+			return
+		}
+		if toLedger > 0 && tx.Ledger > toLedger {
+			cancel()
+			return
+		}
+		if !tx.Successful {
+			return
+		}
+		if tx.Account == vault {
+			payment, ok, txErr := vaultPayment(tx, tft, feeWallet)
+			if txErr != nil {
+				err = txErr
+				cancel()
+				return
+			}
+			if ok {
+				payments = append(payments, payment)
+			}
+			return
+		}
+		deposited, txErr := depositedAmount(client, tx.Hash, vault, assetCode, issuer)
+		if txErr != nil {
+			err = txErr
+			cancel()
+			return
+		}
+		if deposited > 0 {
+			deposits = append(deposits, reconcile.Deposit{TxHash: tx.Hash, Ledger: tx.Ledger, Amount: deposited})
+		}
+	}
+	cursor := toid.New(fromLedger, 0, 0).String()
+	if fetchErr := fetchTransactions(ctx, client, vault, cursor, handler); fetchErr != nil && err == nil {
+		err = fetchErr
+	}
+	return
+}
+
+// vaultPayment converts a transaction of the vault to a payment,
+// the second payment operation is the fee if it pays the fee wallet
+func vaultPayment(tx hProtocol.Transaction, tft xdr.Asset, feeWallet string) (payment reconcile.Payment, ok bool, err error) {
+	var envelope xdr.TransactionEnvelope
+	if err = xdr.SafeUnmarshalBase64(tx.EnvelopeXdr, &envelope); err != nil {
+		return payment, false, errors.Wrapf(err, "invalid envelope for transaction %s", tx.Hash)
+	}
+	payment = reconcile.Payment{
+		TxHash: tx.Hash,
+		Ledger: tx.Ledger,
+		Return: tx.MemoType == "return",
+	}
+	// only hash and return memos are base64 encoded
+	if tx.MemoType == "hash" || tx.MemoType == "return" {
+		memo, err := base64.StdEncoding.DecodeString(tx.Memo)
+		if err != nil {
+			return payment, false, errors.Wrapf(err, "invalid memo for transaction %s", tx.Hash)
+		}
+		payment.Memo = hex.EncodeToString(memo)
+	}
+	for i, op := range envelope.Operations() {
+		p, isPayment := op.Body.GetPaymentOp()
+		if !isPayment || !p.Asset.Equals(tft) {
+			continue
+		}
+		destination := p.Destination.ToAccountId()
+		if i == 1 && destination.Address() == feeWallet {
+			payment.Fee += int64(p.Amount)
+			continue
+		}
+		if payment.Destination == "" {
+			payment.Destination = destination.Address()
+		}
+		payment.Amount += int64(p.Amount)
+		ok = true
+	}
+	return payment, ok, nil
+}
+
+// depositedAmount returns the amount of TFT in stroops credited to the vault by a transaction
+func depositedAmount(client *horizonclient.Client, txHash string, vault string, assetCode string, issuer string) (deposited int64, err error) {
+	page, err := client.Effects(horizonclient.EffectRequest{ForTransaction: txHash, Limit: 200})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get the effects of transaction %s", txHash)
+	}
+	for _, effect := range page.Embedded.Records {
+		credited, ok := effect.(effects.AccountCredited)
+		if !ok || credited.Account != vault || credited.Asset.Code != assetCode || credited.Asset.Issuer != issuer {
+			continue
+		}
+		parsed, err := amount.ParseInt64(credited.Amount)
+		if err != nil {
+			continue
+		}
+		deposited += parsed
+	}
+	return deposited, nil
+}
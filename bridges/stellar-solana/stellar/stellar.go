@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -17,6 +19,7 @@ import (
 	"github.com/stellar/go/xdr"
 
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
 )
 
 const (
@@ -31,28 +34,92 @@ const (
 // ErrTxMemoNotTxHash is returned when trying to parse a tx memo as a tx hash while it is not
 var ErrTxMemoNotTxHash = errors.New("transaction memo is not of type hash")
 
+// Network holds the settings of a Stellar network
+type Network struct {
+	HorizonURL string
+	Passphrase string
+	// Asset is the TFT asset as CODE:ISSUER
+	Asset string
+}
+
+var (
+	networksLock sync.RWMutex
+	networks     = map[string]Network{
+		"testnet": {
+			HorizonURL: horizonclient.DefaultTestNetClient.HorizonURL,
+			Passphrase: network.TestNetworkPassphrase,
+			Asset:      TFTTest,
+		},
+		"production": {
+			HorizonURL: horizonclient.DefaultPublicNetClient.HorizonURL,
+			Passphrase: network.PublicNetworkPassphrase,
+			Asset:      TFTMainnet,
+		},
+	}
+	// the horizon clients record their failed requests in the metrics
+	horizonClients = make(map[string]*horizonclient.Client)
+)
+
+// IsBuiltinNetwork returns if the settings of a network are known without configuring them
+func IsBuiltinNetwork(name string) bool {
+	return name == "testnet" || name == "production"
+}
+
+// GetNetwork returns the settings of a network
+func GetNetwork(name string) (Network, error) {
+	networksLock.RLock()
+	defer networksLock.RUnlock()
+	n, ok := networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("stellar network %s is not supported", name)
+	}
+	return n, nil
+}
+
+// SetNetwork adds a network, like a standalone or private network, or overrides the settings of a built-in one
+func SetNetwork(name string, n Network) {
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	networks[name] = n
+	delete(horizonClients, name)
+}
+
 // GetHorizonClient gets an horizon client for a specific network
 func GetHorizonClient(network string) (*horizonclient.Client, error) {
-	switch network {
-	case "testnet":
-		return horizonclient.DefaultTestNetClient, nil
-	case "production":
-		return horizonclient.DefaultPublicNetClient, nil
-	default:
-		return nil, errors.New("network is not supported")
+	n, err := GetNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	client, ok := horizonClients[network]
+	if !ok {
+		client = &horizonclient.Client{
+			HorizonURL: n.HorizonURL,
+			HTTP:       metrics.InstrumentHTTP(metrics.ServiceHorizon),
+		}
+		horizonClients[network] = client
 	}
+	return client, nil
 }
 
 // GetNetworkPassPhrase gets the Stellar network passphrase based on a network input
-func GetNetworkPassPhrase(ntwrk string) string {
-	switch ntwrk {
-	case "testnet":
-		return network.TestNetworkPassphrase
-	case "production":
-		return network.PublicNetworkPassphrase
-	default:
-		return network.TestNetworkPassphrase
+func GetNetworkPassPhrase(network string) (string, error) {
+	n, err := GetNetwork(network)
+	return n.Passphrase, err
+}
+
+// GetTFTAsset returns the code and issuer of the TFT asset on a network
+func GetTFTAsset(network string) (assetCode, issuer string, err error) {
+	n, err := GetNetwork(network)
+	if err != nil {
+		return "", "", err
+	}
+	assetCode, issuer, found := strings.Cut(n.Asset, ":")
+	if !found {
+		return "", "", fmt.Errorf("invalid TFT asset %s for stellar network %s", n.Asset, network)
 	}
+	return assetCode, issuer, nil
 }
 
 // IntToStroops converts units to stroops (1 TFT = 1000000 stroops)
@@ -105,6 +172,7 @@ func fetchTransactions(ctx context.Context, client *horizonclient.Client, addres
 			case <-ctx.Done():
 				return nil
 			case <-time.After(5 * time.Second):
+				metrics.Retries.WithLabelValues(metrics.RetryStellarTransactions).Inc()
 				continue
 			}
 
@@ -5,21 +5,58 @@ import "errors"
 type StellarConfig struct {
 	// network for the stellar config
 	StellarNetwork string
+	// HorizonURL overrides the horizon server of the network
+	HorizonURL string
+	// NetworkPassphrase overrides the passphrase of the network
+	NetworkPassphrase string
+	// AssetIssuer overrides the issuer of the TFT asset on the network
+	AssetIssuer string
 	// seed for the stellar bridge wallet
 	StellarSeed string
 	// stellar fee wallet address
 	StellarFeeWallet string
 }
 
-func (c *StellarConfig) Validate() (err error) {
-	if c.StellarNetwork != "testnet" && c.StellarNetwork != "production" {
-		return errors.New("The Stellar network has to be testnet or production")
-	}
+func (c *StellarConfig) Validate() error {
+	var errs []error
+	errs = append(errs, c.validateNetwork())
 	if c.StellarSeed == "" {
-		return errors.New("A Stellar secret is required")
+		errs = append(errs, errors.New("A Stellar secret is required"))
 	}
 	if c.StellarFeeWallet == "" {
-		return errors.New("A Fee wallet is required")
+		errs = append(errs, errors.New("A Fee wallet is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *StellarConfig) validateNetwork() error {
+	var errs []error
+	if c.StellarNetwork == "" {
+		errs = append(errs, errors.New("A Stellar network is required"))
+	} else if !IsBuiltinNetwork(c.StellarNetwork) && (c.HorizonURL == "" || c.NetworkPassphrase == "" || c.AssetIssuer == "") {
+		errs = append(errs, errors.New("The Stellar network has to be testnet or production, other networks need a horizon url, network passphrase and asset issuer"))
+	}
+	if c.AssetIssuer != "" && !IsValidStellarAddress(c.AssetIssuer) {
+		errs = append(errs, errors.New("Invalid TFT asset issuer"))
+	}
+	return errors.Join(errs...)
+}
+
+// SetNetwork makes the configured horizon url, passphrase and asset issuer available under the name of the network,
+// the settings which are not configured keep the ones of the built-in network.
+func (c *StellarConfig) SetNetwork() {
+	if c.HorizonURL == "" && c.NetworkPassphrase == "" && c.AssetIssuer == "" {
+		return
+	}
+	n, _ := GetNetwork(c.StellarNetwork)
+	if c.HorizonURL != "" {
+		n.HorizonURL = c.HorizonURL
+	}
+	if c.NetworkPassphrase != "" {
+		n.Passphrase = c.NetworkPassphrase
+	}
+	if c.AssetIssuer != "" {
+		n.Asset = "TFT:" + c.AssetIssuer
 	}
-	return
+	SetNetwork(c.StellarNetwork, n)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/hex"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stellar/go/clients/horizonclient"
@@ -21,7 +22,11 @@ type TransactionStorage struct {
 	// sentTransactionMemos keeps the memo's of outgoing transactions of the addressToScan account
 	// this is used to check if a withdraw, refund or feetransfer for a deposit has already occurred
 	sentTransactionMemos map[string]bool
-	stellarCursor        string
+	// stellarCursor is the paging token of the last scanned transaction, it is read by Cursor while scanning
+	stellarCursor string
+	cursorLock    sync.Mutex
+	// horizon overrides the horizon client of the network if set
+	horizon *horizonclient.Client
 }
 
 var ErrTransactionNotFound = errors.New("transaction not found")
@@ -66,7 +71,11 @@ func (s *TransactionStorage) TransactionExists(ctx context.Context, txn *txnbuil
 	}
 
 	// check if the actual transaction already happened or not
-	hash, err := txn.HashHex(GetNetworkPassPhrase(s.network))
+	passphrase, err := GetNetworkPassPhrase(s.network)
+	if err != nil {
+		return false, err
+	}
+	hash, err := txn.HashHex(passphrase)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to get transaction hash")
 	}
@@ -125,7 +134,9 @@ func (s *TransactionStorage) ScanBridgeAccount(ctx context.Context) error {
 
 	transactionHandler := func(tx hProtocol.Transaction) {
 		s.StoreTransaction(tx)
+		s.cursorLock.Lock()
 		s.stellarCursor = tx.PagingToken()
+		s.cursorLock.Unlock()
 	}
 
 	client, err := s.getHorizonClient()
@@ -133,11 +144,26 @@ func (s *TransactionStorage) ScanBridgeAccount(ctx context.Context) error {
 		return err
 	}
 
-	log.Debug().Str("account", s.addressToScan).Str("cursor", s.stellarCursor).Msg("start fetching stellar transactions")
-	return fetchTransactions(ctx, client, s.addressToScan, s.stellarCursor, transactionHandler)
+	log.Debug().Str("account", s.addressToScan).Str("cursor", s.Cursor()).Msg("start fetching stellar transactions")
+	return fetchTransactions(ctx, client, s.addressToScan, s.Cursor(), transactionHandler)
+}
+
+// SetHorizonClient makes the transaction storage use the given horizon client instead of the one of its network
+func (s *TransactionStorage) SetHorizonClient(client *horizonclient.Client) {
+	s.horizon = client
 }
 
 // GetHorizonClient gets the horizon client based on the transaction storage's network
 func (s *TransactionStorage) getHorizonClient() (*horizonclient.Client, error) {
+	if s.horizon != nil {
+		return s.horizon, nil
+	}
 	return GetHorizonClient(s.network)
 }
+
+// Cursor returns the paging token of the last transaction of the scanned account
+func (s *TransactionStorage) Cursor() string {
+	s.cursorLock.Lock()
+	defer s.cursorLock.Unlock()
+	return s.stellarCursor
+}
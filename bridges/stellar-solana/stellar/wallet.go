@@ -5,7 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
-	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,7 +14,13 @@ import (
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/keypair"
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/fees"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/keys"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/metrics"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/multisig"
+	"github.com/threefoldfoundation/tft/bridges/stellar-solana/pause"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/solana"
 	"github.com/threefoldfoundation/tft/bridges/stellar-solana/state"
 
@@ -27,29 +33,43 @@ import (
 // Wallet is the bridge wallet
 // Payments will be funded and fees will be taken with this wallet
 type Wallet struct {
-	keypair            *keypair.Full
+	signer             keys.Ed25519Signer
+	keypair            *keypair.FromAddress
 	Config             *StellarConfig // TODO: should this be public?
 	TransactionStorage *TransactionStorage
-	depositFee         int64
-	withdrawFee        int64
+	depositFee         fees.Schedule
+	withdrawFee        fees.Schedule
+	// pause is only set on the master, it holds back the deposit processing while the bridge is paused
+	pause *pause.Switch
+	// decisions is only set in dry-run mode, transactions are recorded in it instead of submitted
+	decisions *state.DecisionLog
+	// dryRunSignatures requests the cosigner signatures in dry-run mode
+	dryRunSignatures bool
+	// horizon overrides the horizon client of the network if set
+	horizon *horizonclient.Client
 	signerWallet
 }
 type signersClient interface {
-	Sign(ctx context.Context, signRequest multisig.StellarSignRequest) ([]multisig.StellarSignResponse, error)
+	Sign(ctx context.Context, signRequest multisig.SignRequest) ([]multisig.StellarSignResponse, error)
+	// Transaction returns the transaction the signatures for kind and memo are collected for, if any
+	Transaction(kind multisig.RequestKind, memo string) string
 }
 type signerWallet struct {
-	client         signersClient
-	signatureCount int
+	client signersClient
+	// signatureCount is the number of cosigner signatures required, it changes with the thresholds of the bridge account
+	signatureCount atomic.Int32
 }
 
-func NewWallet(config *StellarConfig, depositFee int64, withdrawFee int64, stellarTransactionStorage *TransactionStorage) (*Wallet, error) {
-	kp, err := keypair.ParseFull(config.StellarSeed)
+// NewWallet creates the bridge wallet, the transactions are signed with the given signer
+func NewWallet(config *StellarConfig, signer keys.Ed25519Signer, depositFee, withdrawFee fees.Schedule, stellarTransactionStorage *TransactionStorage) (*Wallet, error) {
+	address, err := strkey.Encode(strkey.VersionByteAccountID, signer.PublicKey())
 	if err != nil {
 		return nil, err
 	}
 
 	w := &Wallet{
-		keypair:            kp,
+		signer:             signer,
+		keypair:            keypair.MustParseAddress(address),
 		Config:             config,
 		TransactionStorage: stellarTransactionStorage,
 		depositFee:         depositFee,
@@ -63,6 +83,11 @@ func (w *Wallet) GetAddress() string {
 	return w.keypair.Address()
 }
 
+// BridgeAccount returns the account of the bridge, the signer is one of its signers
+func (w *Wallet) BridgeAccount() string {
+	return w.TransactionStorage.addressToScan
+}
+
 func (w *Wallet) GetSigningRequirements() (cosigners []string, requiredSignatures int, err error) {
 	account, err := w.getAccountDetails()
 	if err != nil {
@@ -83,44 +108,111 @@ func (w *Wallet) GetSigningRequirements() (cosigners []string, requiredSignature
 	return
 }
 
+// AccountBalance is the balance of a single asset of the bridge account
+type AccountBalance struct {
+	// Asset is "native" for XLM or code:issuer
+	Asset   string `json:"asset"`
+	Balance string `json:"balance"`
+}
+
+// GetBalances returns the balances of the bridge account
+func (w *Wallet) GetBalances() ([]AccountBalance, error) {
+	account, err := w.getAccountDetails()
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]AccountBalance, 0, len(account.Balances))
+	for _, balance := range account.Balances {
+		asset := balance.Asset.Type
+		if asset != "native" {
+			asset = balance.Asset.Code + ":" + balance.Asset.Issuer
+		}
+		balances = append(balances, AccountBalance{Asset: asset, Balance: balance.Balance})
+	}
+	return balances, nil
+}
+
 func (w *Wallet) SetRequiredSignatures(requiredSignatures int) {
-	w.signatureCount = requiredSignatures - 1
+	w.signatureCount.Store(int32(requiredSignatures - 1))
+}
+
+// requiredSignatures returns the number of cosigner signatures required
+func (w *Wallet) requiredSignatures() int {
+	return int(w.signatureCount.Load())
 }
 
 func (w *Wallet) SetSignerClient(client signersClient) {
 	w.client = client
 }
 
+// SetPauseSwitch makes the deposit processing wait while the bridge is paused
+func (w *Wallet) SetPauseSwitch(s *pause.Switch) {
+	w.pause = s
+}
+
+// SetDryRun records the transactions in the decision log instead of submitting them,
+// the cosigner signatures are only requested if signatures is true
+func (w *Wallet) SetDryRun(decisions *state.DecisionLog, signatures bool) {
+	w.decisions = decisions
+	w.dryRunSignatures = signatures
+}
+
+// waitResumed blocks while the bridge is paused, it returns false if the context is done first
+func (w *Wallet) waitResumed(ctx context.Context) bool {
+	if w.pause == nil {
+		return true
+	}
+	return w.pause.Wait(ctx) == nil
+}
+
 // Sign returns a new Transaction instance which extends the current instance
 // with a signature from this wallet.
 func (w *Wallet) Sign(tx *txnbuild.Transaction) (*txnbuild.Transaction, error) {
-	return tx.Sign(w.GetNetworkPassPhrase(), w.keypair)
+	passphrase, err := w.GetNetworkPassPhrase()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := tx.Hash(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.signer.Sign(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.AddSignatureDecorated(xdr.DecoratedSignature{
+		Hint:      w.keypair.Hint(),
+		Signature: xdr.Signature(signature),
+	})
 }
 
-func (w *Wallet) CreateAndSubmitPayment(ctx context.Context, target string, amount uint64, receiver solana.Address, txHash solana.ShortTxID, message string, includeWithdrawFee bool) (err error) {
+// CreateAndSubmitPayment pays out a withdrawal, withdrawFee is paid to the fee wallet in the same transaction if it is not 0
+func (w *Wallet) CreateAndSubmitPayment(ctx context.Context, target string, amount uint64, receiver solana.Address, txHash solana.ShortTxID, withdrawFee int64) (err error) {
 	if !IsValidStellarAddress(target) {
 		log.Warn().Str("address", target).Msg("Invalid address, skipping payment")
 		return
 	}
-	txnBuild, err := w.generatePaymentOperation(amount, target, includeWithdrawFee)
+	txnBuild, err := w.generatePaymentOperation(amount, target, withdrawFee)
 	if err != nil {
 		return
 	}
 
 	txnBuild.Memo = txnbuild.MemoHash(txHash.Hash())
 
-	signReq := multisig.StellarSignRequest{
-		RequiredSignatures: w.signatureCount,
-		Receiver:           receiver,
-		Message:            message,
+	signReq := multisig.SignRequest{
+		Version:            multisig.ProtocolVersion,
+		Kind:               multisig.KindWithdrawal,
+		RequiredSignatures: w.requiredSignatures(),
+		Withdrawal:         &multisig.WithdrawalRequest{Sender: receiver},
 	}
 
-	return w.signAndSubmitTransaction(ctx, txnBuild, signReq)
+	return w.signAndSubmitTransaction(ctx, txnBuild, signReq, metrics.TransferWithdrawal, amount)
 }
 
 // CreateAndSubmitRefund refunds a deposit for the transaction txToRefund ( hexadecimal representation of the transaction hash)
-func (w *Wallet) CreateAndSubmitRefund(ctx context.Context, target string, amount uint64, txToRefund string, includeWithdrawFee bool) (err error) {
-	txnBuild, err := w.generatePaymentOperation(amount, target, includeWithdrawFee)
+// withdrawFee is paid to the fee wallet in the same transaction if it is not 0
+func (w *Wallet) CreateAndSubmitRefund(ctx context.Context, target string, amount uint64, txToRefund string, withdrawFee int64) (err error) {
+	txnBuild, err := w.generatePaymentOperation(amount, target, withdrawFee)
 	if err != nil {
 		return
 	}
@@ -135,32 +227,37 @@ func (w *Wallet) CreateAndSubmitRefund(ctx context.Context, target string, amoun
 
 	txnBuild.Memo = txnbuild.MemoReturn([32]byte(txToRefundAsBytes))
 
-	signReq := multisig.StellarSignRequest{
-		RequiredSignatures: w.signatureCount,
-		Message:            txToRefund,
+	signReq := multisig.SignRequest{
+		Version:            multisig.ProtocolVersion,
+		Kind:               multisig.KindRefund,
+		RequiredSignatures: w.requiredSignatures(),
+		Refund:             &multisig.RefundRequest{Deposit: txToRefund},
 	}
 
-	return w.signAndSubmitTransaction(ctx, txnBuild, signReq)
+	return w.signAndSubmitTransaction(ctx, txnBuild, signReq, metrics.TransferRefund, amount)
 }
 
 // CreateAndSubmitFeepayment creates and submites a payment to the fee wallet
 // only an amount and hash needs to be specified
 func (w *Wallet) CreateAndSubmitFeepayment(ctx context.Context, amount uint64, txHash [32]byte) error {
-	txnBuild, err := w.generatePaymentOperation(amount, w.Config.StellarFeeWallet, false)
+	txnBuild, err := w.generatePaymentOperation(amount, w.Config.StellarFeeWallet, 0)
 	if err != nil {
 		return errors.Wrap(err, "failed to generate payment operation")
 	}
 
 	txnBuild.Memo = txnbuild.MemoHash(txHash)
 
-	signReq := multisig.StellarSignRequest{
-		RequiredSignatures: w.signatureCount,
+	signReq := multisig.SignRequest{
+		Version:            multisig.ProtocolVersion,
+		Kind:               multisig.KindFeeTransfer,
+		RequiredSignatures: w.requiredSignatures(),
+		FeeTransfer:        &multisig.FeeTransferRequest{Deposit: hex.EncodeToString(txHash[:])},
 	}
 
-	return w.signAndSubmitTransaction(ctx, txnBuild, signReq)
+	return w.signAndSubmitTransaction(ctx, txnBuild, signReq, metrics.TransferFee, amount)
 }
 
-func (w *Wallet) generatePaymentOperation(amount uint64, destination string, includeWithdrawFee bool) (txnbuild.TransactionParams, error) {
+func (w *Wallet) generatePaymentOperation(amount uint64, destination string, withdrawFee int64) (txnbuild.TransactionParams, error) {
 	// if amount is zero, do nothing
 	if amount == 0 {
 		return txnbuild.TransactionParams{}, errors.New("invalid amount")
@@ -171,7 +268,10 @@ func (w *Wallet) generatePaymentOperation(amount uint64, destination string, inc
 		return txnbuild.TransactionParams{}, errors.Wrap(err, "failed to get source account")
 	}
 
-	assetCode, issuer := w.GetAssetCodeAndIssuer()
+	assetCode, issuer, err := w.GetAssetCodeAndIssuer()
+	if err != nil {
+		return txnbuild.TransactionParams{}, err
+	}
 
 	var paymentOperations []txnbuild.Operation
 	paymentOP := txnbuild.Payment{
@@ -185,10 +285,10 @@ func (w *Wallet) generatePaymentOperation(amount uint64, destination string, inc
 	}
 	paymentOperations = append(paymentOperations, &paymentOP)
 
-	if includeWithdrawFee {
+	if withdrawFee > 0 {
 		feePaymentOP := txnbuild.Payment{
 			Destination: w.Config.StellarFeeWallet,
-			Amount:      big.NewRat(w.withdrawFee, Precision).FloatString(PrecisionDigits),
+			Amount:      big.NewRat(withdrawFee, Precision).FloatString(PrecisionDigits),
 			Asset: txnbuild.CreditAsset{
 				Code:   assetCode,
 				Issuer: issuer,
@@ -200,7 +300,7 @@ func (w *Wallet) generatePaymentOperation(amount uint64, destination string, inc
 
 	txnBuild := txnbuild.TransactionParams{
 		Operations:           paymentOperations,
-		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(int64(TransactionTimeout.Seconds()))},
 		SourceAccount:        &sourceAccount,
 		BaseFee:              Precision,
 		IncrementSequenceNum: true,
@@ -211,7 +311,8 @@ func (w *Wallet) generatePaymentOperation(amount uint64, destination string, inc
 
 // signAndSubmitTransaction gathers signatures from cosigners if required and submits the transaction to the Stellar network
 // If there already is a transaction with the same memo hash, no new transaction is created and submitted.
-func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.TransactionParams, signReq multisig.StellarSignRequest) (err error) {
+// The transfer type and amount are recorded in the metrics once the transaction is submitted.
+func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.TransactionParams, signReq multisig.SignRequest, transfer string, amount uint64) (err error) {
 	tx, err := txnbuild.NewTransaction(txn)
 	if err != nil {
 		return errors.Wrap(err, "failed to build transaction")
@@ -234,7 +335,8 @@ func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.Tran
 	}
 
 	// Only try to request signatures if there are signatures required
-	if w.signatureCount > 0 {
+	if signReq.RequiredSignatures > 0 && (w.decisions == nil || w.dryRunSignatures) {
+		tx = w.reuseTransaction(signReq.Kind, memo, tx)
 		xdr, err := tx.Base64()
 		if err != nil {
 			return errors.Wrap(err, "failed to serialize transaction")
@@ -246,12 +348,16 @@ func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.Tran
 			return err
 		}
 
-		if len(signatures) < w.signatureCount {
-			return fmt.Errorf("received %d signatures, need %d", len(signatures), w.signatureCount)
+		if len(signatures) < signReq.RequiredSignatures {
+			return fmt.Errorf("received %d signatures, need %d", len(signatures), signReq.RequiredSignatures)
 		}
 
+		passphrase, err := w.GetNetworkPassPhrase()
+		if err != nil {
+			return err
+		}
 		for _, signature := range signatures {
-			tx, err = tx.AddSignatureBase64(w.GetNetworkPassPhrase(), signature.Address, signature.Signature)
+			tx, err = tx.AddSignatureBase64(passphrase, signature.Address, signature.Signature)
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to add signature")
 				return err
@@ -259,10 +365,14 @@ func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.Tran
 		}
 	}
 
-	tx, err = tx.Sign(w.GetNetworkPassPhrase(), w.keypair)
+	tx, err = w.Sign(tx)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to sign transaction")
-		return errors.Wrap(err, "failed to sign transaction with keypair")
+		return errors.Wrap(err, "failed to sign transaction")
+	}
+
+	if w.decisions != nil {
+		return w.recordDecision(tx, txn, memo, transfer, amount)
 	}
 
 	// Submit the transaction
@@ -294,6 +404,7 @@ func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.Tran
 		return errors.Wrap(err, "error submitting transaction")
 	}
 	log.Info().Str("txHash", txResult.Hash).Msg("transaction submitted to the stellar network..")
+	metrics.Transfer(transfer, int64(amount))
 
 	// Store the transaction in the database
 	w.TransactionStorage.StoreTransaction(txResult)
@@ -301,34 +412,129 @@ func (w *Wallet) signAndSubmitTransaction(ctx context.Context, txn txnbuild.Tran
 	return
 }
 
+// reuseTransaction returns the transaction the cosigners were asked to sign before for kind and memo
+// if it makes the same payments, still has the next sequence number of the bridge account and is valid
+// for at least reuseMargin, so the partial signatures the cosigners gave for it are used when the signing is retried.
+// Otherwise tx is returned.
+func (w *Wallet) reuseTransaction(kind multisig.RequestKind, memo string, tx *txnbuild.Transaction) *txnbuild.Transaction {
+	encoded := w.client.Transaction(kind, memo)
+	if encoded == "" {
+		return tx
+	}
+	previous, err := parseTransaction(encoded)
+	if err != nil || !sameOperations(previous, tx) {
+		return tx
+	}
+	// tx is built with the next sequence number of the bridge account
+	if previous.SequenceNumber() != tx.SequenceNumber() || time.Until(time.Unix(previous.Timebounds().MaxTime, 0)) <= reuseMargin {
+		return tx
+	}
+	log.Debug().Str("kind", string(kind)).Str("memo", memo).Msg("Reusing the transaction built before")
+	return previous
+}
+
+func parseTransaction(encoded string) (*txnbuild.Transaction, error) {
+	generic, err := txnbuild.TransactionFromXDR(encoded)
+	if err != nil {
+		return nil, err
+	}
+	tx, ok := generic.Transaction()
+	if !ok {
+		return nil, errors.New("not a transaction")
+	}
+	return tx, nil
+}
+
+// sameOperations checks if the transactions have the same operations
+func sameOperations(a, b *txnbuild.Transaction) bool {
+	opsA, opsB := a.ToXDR().Operations(), b.ToXDR().Operations()
+	if len(opsA) != len(opsB) {
+		return false
+	}
+	for i := range opsA {
+		encodedA, errA := xdr.MarshalBase64(opsA[i])
+		encodedB, errB := xdr.MarshalBase64(opsB[i])
+		if errA != nil || errB != nil || encodedA != encodedB {
+			return false
+		}
+	}
+	return true
+}
+
+// recordDecision records a transaction in the decision log instead of submitting it
+func (w *Wallet) recordDecision(tx *txnbuild.Transaction, txn txnbuild.TransactionParams, memo string, transfer string, paid uint64) error {
+	encoded, err := tx.Base64()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize transaction")
+	}
+	decision := state.Decision{
+		Transfer:    transfer,
+		ID:          memo,
+		Amount:      int64(paid),
+		Signatures:  len(tx.Signatures()),
+		Transaction: encoded,
+	}
+	if payment, ok := txn.Operations[0].(*txnbuild.Payment); ok {
+		decision.Destination = payment.Destination
+	}
+	if len(txn.Operations) > 1 {
+		if feePayment, ok := txn.Operations[1].(*txnbuild.Payment); ok {
+			decision.Fee, _ = amount.ParseInt64(feePayment.Amount)
+		}
+	}
+	log.Info().Str("transfer", transfer).Str("memo", memo).Str("amount", StroopsToDecimal(int64(paid)).String()).Msg("Dry run, recording the transaction instead of submitting it")
+	return w.decisions.Record(decision)
+}
+
 // sender is the account that made the deposit
-func (w *Wallet) refundDeposit(ctx context.Context, totalAmount uint64, sender string, tx hProtocol.Transaction) {
-	if totalAmount <= uint64(w.withdrawFee) {
-		log.Warn().Str("tx", tx.Hash).Msg("Deposited amount is less than the withdraw fee, not refunding")
+func (w *Wallet) refundDeposit(ctx context.Context, totalAmount uint64, sender string, txID string) {
+	fee := w.withdrawFee.Fee(int64(totalAmount))
+	if totalAmount <= uint64(fee) {
+		log.Warn().Str("tx", txID).Msg("Deposited amount is less than the withdraw fee, not refunding")
 		return
 	}
-	amount := totalAmount - uint64(w.withdrawFee)
+	amount := totalAmount - uint64(fee)
 	log.Info().Msg("Calling refund")
+	if !w.waitResumed(ctx) {
+		return
+	}
 
-	err := w.CreateAndSubmitRefund(ctx, sender, amount, tx.Hash, true)
+	err := w.CreateAndSubmitRefund(ctx, sender, amount, txID, fee)
 	for err != nil {
-		log.Error().Err(err).Str("amount", StroopsToDecimal(int64(totalAmount)).String()).Str("tx", tx.Hash).Msg("could not refund")
+		log.Error().Err(err).Str("amount", StroopsToDecimal(int64(totalAmount)).String()).Str("tx", txID).Msg("could not refund")
 		select {
 		case <-ctx.Done():
 			return
 		case <-time.After(10 * time.Second):
-			err = w.CreateAndSubmitRefund(ctx, sender, amount, tx.Hash, true)
+			if !w.waitResumed(ctx) {
+				return
+			}
+			metrics.Retries.WithLabelValues(metrics.TransferRefund).Inc()
+			err = w.CreateAndSubmitRefund(ctx, sender, amount, txID, fee)
 		}
 	}
 }
 
+// TransactionTimeout bounds the time a transaction of the bridge account can be submitted after it is built
+const TransactionTimeout = 5 * time.Minute
+
+// reuseMargin is the time a transaction built before needs to stay valid to be signed again
+const reuseMargin = time.Minute
+
+// depositBacklog is the amount of transactions queued before the stellar account is not followed further
+const depositBacklog = 1000
+
 // mint handler
 type mint func(context.Context, solana.Address, *big.Int, string) error
 
+// heldMintInterval is the time between the attempts to mint the deposits which are held
+const heldMintInterval = time.Minute
+
 // MonitorBridgeAccountAndMint is a blocking function that keeps monitoring
 // the bridge account on the Stellar network for new transactions and calls the
-// mint function when a deposit is made
-func (w *Wallet) MonitorBridgeAccountAndMint(ctx context.Context, mintFn mint, persistency *state.ChainPersistency) error {
+// mint function when a deposit is made.
+// Deposits which do not fit the mint volume caps are kept in held and minted once they do.
+func (w *Wallet) MonitorBridgeAccountAndMint(ctx context.Context, mintFn mint, persistency *state.ChainPersistency, held *state.HeldMints) error {
 	transactionHandler := func(tx hProtocol.Transaction) {
 		if !tx.Successful {
 			return
@@ -341,100 +547,205 @@ func (w *Wallet) MonitorBridgeAccountAndMint(ctx context.Context, mintFn mint, p
 			log.Debug().Err(err).Int64("amount", totalAmount).Str("sender", sender).Msg("Could not extract deposit amount and sender")
 			return
 		}
+		metrics.Transfer(metrics.TransferDeposit, totalAmount)
 
-		if totalAmount <= IntToStroops(w.depositFee) {
+		depositFee := w.depositFee.Fee(totalAmount)
+		if totalAmount <= depositFee {
 			log.Warn().Msg("Deposited amount is less than the depositfee, refunding")
-			w.refundDeposit(ctx, uint64(totalAmount), sender, tx)
+			w.refundDeposit(ctx, uint64(totalAmount), sender, tx.Hash)
 			return
 		}
 
 		log.Info().Str("amount", StroopsToDecimal(totalAmount).String()).Msg("deposited amount")
-		depositedAmount := big.NewInt(totalAmount)
 		log.Info().Str("memo", tx.Memo).Msg("tx memo")
 
 		solanaAddress, err := solana.AddressFromB64(tx.Memo)
 		if err != nil {
 			log.Warn().Err(err).Msg("error converting transaction memo to a Solana address, refunding")
-			w.refundDeposit(ctx, uint64(totalAmount), sender, tx)
+			w.refundDeposit(ctx, uint64(totalAmount), sender, tx.Hash)
 			return
 		}
 
-		err = mintFn(ctx, solanaAddress, depositedAmount, tx.Hash)
-		for err != nil {
-			log.Error().Err(err).Msg("Error occured while minting")
-			// TODO: we already checked this above
-			if err == faults.ErrInsufficientDepositAmount {
-				log.Warn().Int64("amount", totalAmount).Msg("User is trying to swap less than the fee amount, refunding")
-				w.refundDeposit(ctx, uint64(totalAmount), sender, tx)
-				return
-			}
+		deposit := state.HeldMint{TxID: tx.Hash, Memo: tx.Memo, Sender: sender, Amount: totalAmount}
+		if !w.mintDeposit(ctx, mintFn, held, deposit, solanaAddress) {
+			return
+		}
 
-			if err == faults.ErrInvalidReceiver {
-				log.Warn().Str("Receiver", solanaAddress.String()).Msg("Target address is not valid to receive tokens, refunding")
-				w.refundDeposit(ctx, uint64(totalAmount), sender, tx)
-				return
-			}
+		log.Info().Msg("Mint succesfull or held, saving cursor now")
 
-			timeout := time.Second * 10
-			if errors.Is(err, solana.ErrMintSubmitFailed) {
-				// Make sure there is enough time in case the transaction was submitted, for it to be properly finalized.
-				// Otherwise we might mint again by mistake.
-				timeout = time.Second * 120
-			}
+		// save cursor
+		cursor := tx.PagingToken()
+		err = persistency.SaveStellarCursor(cursor)
+		if err != nil {
+			log.Error().Err(err).Msg("error while saving cursor")
+			return
+		}
+	}
+
+	// get saved cursor
+	blockHeight, err := persistency.GetHeight()
+	for err != nil {
+		log.Warn().Err(err).Msg("Error getting the bridge persistency")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(5 * time.Second):
+			blockHeight, err = persistency.GetHeight()
+		}
+	}
+
+	// The deposits are queued so they keep being ingested while the bridge is paused,
+	// they are processed in order once it is resumed.
+	deposits := make(chan hProtocol.Transaction, depositBacklog)
+	go func() {
+		ticker := time.NewTicker(heldMintInterval)
+		defer ticker.Stop()
+		for {
 			select {
+			case tx := <-deposits:
+				if !w.waitResumed(ctx) {
+					return
+				}
+				transactionHandler(tx)
+			case <-ticker.C:
+				w.mintHeld(ctx, mintFn, held)
 			case <-ctx.Done():
 				return
-			case <-time.After(timeout):
-				err = mintFn(ctx, solanaAddress, depositedAmount, tx.Hash)
 			}
 		}
+	}()
+	queueDeposit := func(tx hProtocol.Transaction) {
+		if w.pause != nil && w.pause.Paused() {
+			log.Info().Str("tx", tx.Hash).Int("queued", len(deposits)+1).Msg("Bridge is paused, queueing transaction")
+		}
+		select {
+		case deposits <- tx:
+		case <-ctx.Done():
+		}
+	}
 
-		log.Info().Str("address", w.Config.StellarFeeWallet).Msg("Transferring the fee to the fee wallet")
+	return w.StreamBridgeStellarTransactions(ctx, blockHeight.StellarCursor, queueDeposit)
+}
+
+// mintDeposit mints a deposit and transfers its fee to the fee wallet, deposits which can not be minted are refunded.
+// A mint over the volume caps is kept in held instead, so it does not block the deposits after it.
+// Other errors are retried until the context is done.
+// It returns true if the deposit is minted or held, the deposits before the cursor are never handled again.
+func (w *Wallet) mintDeposit(ctx context.Context, mintFn mint, held *state.HeldMints, deposit state.HeldMint, receiver solana.Address) bool {
+	err := mintFn(ctx, receiver, big.NewInt(deposit.Amount), deposit.TxID)
+	for err != nil {
+		if err == faults.ErrVolumeCapExceeded {
+			changed, holdErr := held.Hold(deposit, err)
+			if holdErr == nil {
+				// held mints are tried again every heldMintInterval, only log a change
+				if changed {
+					log.Warn().Str("tx", deposit.TxID).Str("amount", StroopsToDecimal(deposit.Amount).String()).Msg("Holding the mint until it fits the volume caps")
+				}
+				return true
+			}
+			log.Error().Err(holdErr).Str("tx", deposit.TxID).Msg("failed to hold the mint, retrying it")
+		}
+		log.Error().Err(err).Msg("Error occured while minting")
+		// TODO: we already checked this above
+		if err == faults.ErrInsufficientDepositAmount {
+			log.Warn().Int64("amount", deposit.Amount).Msg("User is trying to swap less than the fee amount, refunding")
+			w.refundHeld(ctx, held, deposit)
+			return false
+		}
+
+		if err == faults.ErrInvalidReceiver {
+			log.Warn().Str("Receiver", receiver.String()).Msg("Target address is not valid to receive tokens, refunding")
+			w.refundHeld(ctx, held, deposit)
+			return false
+		}
+
+		if err == faults.ErrDepositOutsideLimits {
+			log.Warn().Int64("amount", deposit.Amount).Msg("Deposit is outside the mint limits, refunding")
+			w.refundHeld(ctx, held, deposit)
+			return false
+		}
+
+		timeout := time.Second * 10
+		if errors.Is(err, solana.ErrMintSubmitFailed) {
+			// Make sure there is enough time in case the transaction was submitted, for it to be properly finalized.
+			// Otherwise we might mint again by mistake.
+			timeout = time.Second * 120
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(timeout):
+			if !w.waitResumed(ctx) {
+				return false
+			}
+			metrics.Retries.WithLabelValues(metrics.TransferMint).Inc()
+			err = mintFn(ctx, receiver, big.NewInt(deposit.Amount), deposit.TxID)
+		}
+	}
+	w.releaseMint(held, deposit.TxID)
+
+	depositFee := w.depositFee.Fee(deposit.Amount)
+	if depositFee > 0 {
+		log.Info().Str("address", w.Config.StellarFeeWallet).Str("fee", StroopsToDecimal(depositFee).String()).Msg("Transferring the fee to the fee wallet")
 
 		// convert tx hash string to bytes
-		parsedMessage, err := hex.DecodeString(tx.Hash)
+		parsedMessage, err := hex.DecodeString(deposit.TxID)
 		if err != nil {
 			log.Error().Err(err).Msg("Error hex decoding transaction hash")
-			return
+			return true
 		}
 		var memo [32]byte
 		copy(memo[:], parsedMessage)
 
-		err = w.CreateAndSubmitFeepayment(ctx, uint64(IntToStroops(w.depositFee)), memo)
+		err = w.CreateAndSubmitFeepayment(ctx, uint64(depositFee), memo)
 		for err != nil {
 			log.Error().Err(err).Msg("error sending fee to the fee wallet")
 			select {
 			case <-ctx.Done():
-				return
+				return false
 			case <-time.After(10 * time.Second):
-				err = w.CreateAndSubmitFeepayment(context.Background(), uint64(IntToStroops(w.depositFee)), memo)
+				if !w.waitResumed(ctx) {
+					return false
+				}
+				metrics.Retries.WithLabelValues(metrics.TransferFee).Inc()
+				err = w.CreateAndSubmitFeepayment(context.Background(), uint64(depositFee), memo)
 			}
 		}
+	}
+	return true
+}
 
-		log.Info().Msg("Mint succesfull, saving cursor now")
-
-		// save cursor
-		cursor := tx.PagingToken()
-		err = persistency.SaveStellarCursor(cursor)
+// mintHeld tries to mint the held deposits in the order they were held, the ones which still do not fit the caps stay held
+func (w *Wallet) mintHeld(ctx context.Context, mintFn mint, held *state.HeldMints) {
+	for _, deposit := range held.All() {
+		if w.pause != nil && w.pause.Paused() {
+			return
+		}
+		receiver, err := solana.AddressFromB64(deposit.Memo)
 		if err != nil {
-			log.Error().Err(err).Msg("error while saving cursor")
+			log.Error().Err(err).Str("tx", deposit.TxID).Msg("Held mint has an invalid receiver")
+			continue
+		}
+		w.mintDeposit(ctx, mintFn, held, deposit, receiver)
+		if ctx.Err() != nil {
 			return
 		}
 	}
+}
 
-	// get saved cursor
-	blockHeight, err := persistency.GetHeight()
-	for err != nil {
-		log.Warn().Err(err).Msg("Error getting the bridge persistency")
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-time.After(5 * time.Second):
-			blockHeight, err = persistency.GetHeight()
-		}
+// refundHeld refunds a deposit which can not be minted and removes it from the held mints once it is refunded
+func (w *Wallet) refundHeld(ctx context.Context, held *state.HeldMints, deposit state.HeldMint) {
+	w.refundDeposit(ctx, uint64(deposit.Amount), deposit.Sender, deposit.TxID)
+	if ctx.Err() == nil {
+		w.releaseMint(held, deposit.TxID)
 	}
+}
 
-	return w.StreamBridgeStellarTransactions(ctx, blockHeight.StellarCursor, transactionHandler)
+// releaseMint removes a deposit which is minted or refunded from the held mints
+func (w *Wallet) releaseMint(held *state.HeldMints, txID string) {
+	if err := held.Release(txID); err != nil {
+		log.Error().Err(err).Str("tx", txID).Msg("failed to release the held mint")
+	}
 }
 
 // GetDepositAmountAndSender returns the amount of TFT received by the bridge account in stroops
@@ -448,7 +759,10 @@ func (w *Wallet) GetDepositAmountAndSender(txHash string, bridgeAccount string)
 		log.Error().Err(err).Msg("error while fetching transaction effects")
 		return
 	}
-	assetCode, issuer := w.GetAssetCodeAndIssuer()
+	assetCode, issuer, err := w.GetAssetCodeAndIssuer()
+	if err != nil {
+		return
+	}
 
 	for _, effect := range transactionEffects.Embedded.Records {
 
@@ -547,22 +861,29 @@ func (w *Wallet) GetTransactionEffects(txHash string) (transactionEffects effect
 	return
 }
 
+// SetHorizonClient makes the wallet and its transaction storage use the given horizon client
+// instead of the one of the wallet's network
+func (w *Wallet) SetHorizonClient(client *horizonclient.Client) {
+	w.horizon = client
+	if w.TransactionStorage != nil {
+		w.TransactionStorage.SetHorizonClient(client)
+	}
+}
+
 // GetHorizonClient gets the horizon client based on the wallet's network
 func (w *Wallet) GetHorizonClient() (*horizonclient.Client, error) {
+	if w.horizon != nil {
+		return w.horizon, nil
+	}
 	return GetHorizonClient(w.Config.StellarNetwork)
 }
 
 // GetNetworkPassPhrase gets the Stellar network passphrase based on the wallet's network
-func (w *Wallet) GetNetworkPassPhrase() string {
+func (w *Wallet) GetNetworkPassPhrase() (string, error) {
 	return GetNetworkPassPhrase(w.Config.StellarNetwork)
 }
 
-func (w *Wallet) GetAssetCodeAndIssuer() (assetCode, issuer string) {
-	var assetCodeAndIssuerAsSlice []string
-	if w.Config.StellarNetwork == "production" {
-		assetCodeAndIssuerAsSlice = strings.Split(TFTMainnet, ":")
-	} else {
-		assetCodeAndIssuerAsSlice = strings.Split(TFTTest, ":")
-	}
-	return assetCodeAndIssuerAsSlice[0], assetCodeAndIssuerAsSlice[1]
+// GetAssetCodeAndIssuer returns the code and issuer of the TFT asset on the wallet's network
+func (w *Wallet) GetAssetCodeAndIssuer() (assetCode, issuer string, err error) {
+	return GetTFTAsset(w.Config.StellarNetwork)
 }